@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"github.com/yszaryszar/NicheFlow/backend/internal/service"
+)
+
+// adminUsage admin 子命令的用法
+const adminUsage = `用法: api [配置参数] admin <命令> [参数]
+
+命令:
+  create-admin --clerk-id <ID> --email <邮箱> [--role 角色] [--reason 原因]
+                    创建管理员，用户不存在时先创建用户，默认角色为 super-admin
+  reset-quota <Clerk ID|用户 ID> [--reason 原因]
+                    重置用户的使用次数
+
+命令行执行的操作同样写入审计日志，操作者记录为系统（用户 ID 为 0）。`
+
+// runAdmin 执行 admin 子命令
+//
+// 参数:
+//   - args: admin 之后的命令行参数
+//
+// 返回:
+//   - int: 进程退出码
+func runAdmin(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprintln(os.Stderr, adminUsage)
+		return 2
+	}
+
+	var run func(ctx context.Context, services *service.Services) error
+	var err error
+	switch args[0] {
+	case "create-admin":
+		run, err = parseCreateAdmin(args[1:])
+	case "reset-quota":
+		run, err = parseResetQuota(args[1:])
+	default:
+		err = fmt.Errorf("未知的管理命令 %q\n\n%s", args[0], adminUsage)
+	}
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return 2
+	}
+
+	application, err := connect()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	defer application.Shutdown()
+
+	if err := run(context.Background(), application.Container().Services); err != nil {
+		log.Println(err)
+		return 1
+	}
+	return 0
+}
+
+// parseCreateAdmin 解析 create-admin 命令的参数
+func parseCreateAdmin(args []string) (func(context.Context, *service.Services) error, error) {
+	fs := flag.NewFlagSet("admin create-admin", flag.ContinueOnError)
+	clerkID := fs.String("clerk-id", "", "用户的 Clerk ID，必填")
+	email := fs.String("email", "", "用户邮箱，用户不存在时必填")
+	role := fs.String("role", model.RoleSuperAdmin, "分配的角色")
+	reason := fs.String("reason", "通过命令行创建管理员", "写入审计日志的操作原因")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if *clerkID == "" {
+		return nil, errors.New("缺少 --clerk-id 参数")
+	}
+
+	return func(ctx context.Context, services *service.Services) error {
+		user, err := services.Users.GetUserByClerkID(ctx, *clerkID)
+		if err != nil {
+			if *email == "" {
+				return fmt.Errorf("用户 %s 不存在，创建用户需要 --email 参数: %w", *clerkID, err)
+			}
+			if user, err = services.Users.ProvisionUser(ctx, &model.User{
+				ClerkID:      *clerkID,
+				Email:        *email,
+				LastSignInAt: time.Now(),
+			}); err != nil {
+				return fmt.Errorf("创建用户失败: %w", err)
+			}
+			fmt.Printf("已创建用户 %s（ID %d）\n", user.ClerkID, user.ID)
+		}
+
+		if err := services.RBAC.AssignRole(ctx, service.Actor{}, user.ID, *role, *reason); err != nil {
+			return fmt.Errorf("分配角色失败: %w", err)
+		}
+		fmt.Printf("已将用户 %s 的角色设置为 %s\n", user.ClerkID, *role)
+		return nil
+	}, nil
+}
+
+// parseResetQuota 解析 reset-quota 命令的参数
+func parseResetQuota(args []string) (func(context.Context, *service.Services) error, error) {
+	fs := flag.NewFlagSet("admin reset-quota", flag.ContinueOnError)
+	reason := fs.String("reason", "通过命令行重置使用次数", "写入审计日志的操作原因")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	// 允许参数写在用户之后
+	if fs.NArg() > 1 {
+		target := fs.Arg(0)
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return nil, err
+		}
+		if fs.NArg() > 0 {
+			return nil, fmt.Errorf("多余的参数: %v", fs.Args())
+		}
+		return resetQuota(target, *reason), nil
+	}
+	if fs.NArg() == 0 {
+		return nil, errors.New("用法: admin reset-quota <Clerk ID|用户 ID> [--reason 原因]")
+	}
+	return resetQuota(fs.Arg(0), *reason), nil
+}
+
+// resetQuota 返回重置指定用户使用次数的操作
+// target 为纯数字时按用户 ID 查找，否则按 Clerk ID 查找
+func resetQuota(target, reason string) func(context.Context, *service.Services) error {
+	return func(ctx context.Context, services *service.Services) error {
+		var user *model.User
+		var err error
+		if id, parseErr := strconv.ParseUint(target, 10, 64); parseErr == nil {
+			user, err = services.Users.GetUserByID(ctx, uint(id))
+		} else {
+			user, err = services.Users.GetUserByClerkID(ctx, target)
+		}
+		if err != nil {
+			return fmt.Errorf("查找用户 %s 失败: %w", target, err)
+		}
+
+		if err := services.Admin.ResetUsage(ctx, service.Actor{}, user.ID, reason); err != nil {
+			return fmt.Errorf("重置使用次数失败: %w", err)
+		}
+		fmt.Printf("已重置用户 %s（ID %d）的使用次数\n", user.ClerkID, user.ID)
+		return nil
+	}
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
 	_ "github.com/yszaryszar/NicheFlow/backend/docs" // 导入 swagger 文档
 	"github.com/yszaryszar/NicheFlow/backend/internal/app"
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
 )
 
 // @title NicheFlow API
@@ -28,23 +34,93 @@ import (
 // @name Authorization
 // @description 请在此输入 Bearer {token}
 
+// usage 命令行用法
+const usage = `用法: api [配置参数] [子命令] [参数]
+
+子命令:
+  serve     启动 HTTP 服务（默认）
+  migrate   管理数据库迁移
+  worker    运行后台任务
+  seed      写入演示数据，不能在生产环境使用
+  admin     执行管理操作
+  help      显示本帮助
+
+全部子命令使用相同的配置加载方式，配置参数需要写在子命令之前。
+使用 api <子命令> -h 查看子命令的参数。
+
+配置参数:`
+
 func main() {
-	// 创建应用实例
-	application, err := app.New()
-	if err != nil {
-		log.Fatalf("创建应用实例失败: %v", err)
+	checkConfig := flag.Bool("check-config", false, "只加载并校验配置，不启动服务")
+	showSources := flag.Bool("show-config-sources", false, "与 --check-config 一起使用，输出每个配置键的来源")
+	config.BindFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, usage)
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
-	// 初始化应用
-	if err := application.Initialize(); err != nil {
-		log.Fatalf("初始化应用失败: %v", err)
+	// 只校验配置时输出报告后退出，用于部署前检查
+	if *checkConfig {
+		_, sources, err := config.Load(context.Background(), config.FlagOptions())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if *showSources {
+			for _, key := range sources.Keys() {
+				fmt.Printf("%s\t%s\n", key, sources[key])
+			}
+		}
+		fmt.Println("配置校验通过")
+		return
 	}
 
-	// 确保应用正常关闭
-	defer application.Shutdown()
+	var args []string
+	if flag.NArg() > 1 {
+		args = flag.Args()[1:]
+	}
+	switch flag.Arg(0) {
+	case "", "serve":
+		os.Exit(runServe(args))
+	case "migrate":
+		os.Exit(runMigrate(args))
+	case "worker":
+		os.Exit(runWorker(args))
+	case "seed":
+		os.Exit(runSeed(args))
+	case "admin":
+		os.Exit(runAdmin(args))
+	case "help":
+		flag.Usage()
+	default:
+		fmt.Fprintf(os.Stderr, "未知的子命令 %q\n\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+}
 
-	// 运行应用
-	if err := application.Run(); err != nil {
-		log.Fatalf("运行应用失败: %v", err)
+// connect 创建应用实例并建立数据库、Redis 连接和业务服务
+// 供 HTTP 服务以外的子命令使用，返回的应用实例由调用方调用 Shutdown 关闭
+func connect() (*app.Application, error) {
+	application, err := app.New(config.FlagOptions())
+	if err != nil {
+		return nil, fmt.Errorf("创建应用实例失败: %v", err)
 	}
+	if err := application.Connect(); err != nil {
+		application.Shutdown()
+		return nil, err
+	}
+	return application, nil
+}
+
+// signalContext 返回收到 SIGTERM 或 SIGINT 时取消的上下文
+// 取消后恢复默认的信号处理，再次收到信号时进程立即退出
+func signalContext() context.Context {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx
 }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+	"github.com/yszaryszar/NicheFlow/backend/migrations"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/database"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/migrate"
+	"gorm.io/gorm"
+)
+
+// migrateUsage migrate 子命令的用法
+const migrateUsage = `用法: api [配置参数] migrate <命令>
+
+命令:
+  up                执行全部未执行的迁移
+  down [数量]       回滚最近执行的迁移，默认回滚 1 个
+  status            查看迁移状态
+  create <名称>     在迁移目录中创建新的迁移文件
+
+使用的迁移文件由 database.driver 决定。修改表结构时需要分别在 migrations 和
+migrations/sqlite 中创建同名迁移，例如 migrate -dir migrations/sqlite create <名称>`
+
+// runMigrate 执行 migrate 子命令
+//
+// 参数:
+//   - args: migrate 之后的命令行参数
+//
+// 返回:
+//   - int: 进程退出码
+func runMigrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := fs.String("dir", "migrations", "迁移目录，create 命令在该目录中创建文件")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	if err := migrateCommand(fs.Arg(0), fs.Args()[1:], *dir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// migrateCommand 执行具体的迁移命令
+func migrateCommand(command string, args []string, dir string) error {
+	if command == "create" {
+		if len(args) != 1 {
+			return fmt.Errorf("用法: migrate create <名称>")
+		}
+		up, down, err := migrate.Create(dir, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已创建 %s\n已创建 %s\n", up, down)
+		return nil
+	}
+
+	switch command {
+	case "up", "down", "status":
+	default:
+		return fmt.Errorf("未知的迁移命令 %q\n\n%s", command, migrateUsage)
+	}
+
+	ctx := context.Background()
+	migrator, db, err := newMigrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("已执行 %06d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("数据库结构已是最新")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+				return fmt.Errorf("回滚数量必须是正整数: %s", args[0])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("已回滚 %06d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "版本\t名称\t状态\t执行时间")
+		for _, s := range statuses {
+			appliedAt := "-"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%06d\t%s\t%s\t%s\n", s.Version, s.Name, s.State, appliedAt)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+// newMigrator 加载配置并连接数据库，创建迁移执行器
+// 返回的数据库连接由调用方关闭
+func newMigrator(ctx context.Context) (*migrate.Migrator, *gorm.DB, error) {
+	cfg, _, err := config.Load(ctx, config.FlagOptions())
+	if err != nil {
+		return nil, nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	db, err := database.Open(&cfg.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		database.Close(db)
+		return nil, nil, fmt.Errorf("获取 SQL DB 失败: %w", err)
+	}
+	migrator, err := migrations.NewMigrator(sqlDB, cfg.Database.Driver)
+	if err != nil {
+		database.Close(db)
+		return nil, nil, err
+	}
+	return migrator, db, nil
+}
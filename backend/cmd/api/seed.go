@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/seed"
+)
+
+// seedUsage seed 子命令的用法
+const seedUsage = `用法: api [配置参数] seed [--plans 计划1,计划2]
+
+为每个订阅计划创建一个演示用户，并绑定社交账号、设置偏好。
+演示用户的 Clerk ID 为 demo_<计划>，可以重复执行。app.env 为 production 时拒绝执行。
+
+参数:`
+
+// runSeed 执行 seed 子命令
+//
+// 参数:
+//   - args: seed 之后的命令行参数
+//
+// 返回:
+//   - int: 进程退出码
+func runSeed(args []string) int {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	plans := fs.String("plans", "", "只创建指定计划的演示用户，多个计划用逗号分隔，默认为限流配置中的全部计划")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, seedUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	application, err := connect()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	defer application.Shutdown()
+
+	cfg := application.Config()
+	if cfg.App.Env == "production" {
+		log.Println("生产环境不能写入演示数据")
+		return 1
+	}
+
+	selected := splitList(*plans)
+	if len(selected) == 0 {
+		selected = seed.Plans(cfg)
+	}
+	users, err := seed.Demo(context.Background(), application.Container().Services.Users, selected)
+	for _, user := range users {
+		fmt.Printf("%s\t%s\t%s\n", user.ClerkID, user.Email, user.SubscriptionPlan)
+	}
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/app"
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+)
+
+// serveUsage serve 子命令的用法
+const serveUsage = `用法: api [配置参数] [serve]
+
+启动 HTTP 服务，收到 SIGTERM 或 SIGINT 后等待进行中的请求完成再退出。
+启动前会检查数据库结构，存在未执行的迁移时拒绝启动。`
+
+// runServe 执行 serve 子命令
+//
+// 参数:
+//   - args: serve 之后的命令行参数
+//
+// 返回:
+//   - int: 进程退出码
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, serveUsage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// 创建应用实例
+	application, err := app.New(config.FlagOptions())
+	if err != nil {
+		log.Printf("创建应用实例失败: %v", err)
+		return 1
+	}
+
+	// 初始化应用
+	if err := application.Initialize(); err != nil {
+		application.Shutdown()
+		log.Printf("初始化应用失败: %v", err)
+		return 1
+	}
+
+	// 运行应用，返回时进行中的请求已经完成
+	err = application.Run(signalContext())
+	application.Shutdown()
+	if err != nil {
+		log.Printf("运行应用失败: %v", err)
+		return 1
+	}
+	log.Println("服务已停止")
+	return 0
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/worker"
+)
+
+// workerUsage worker 子命令的用法
+const workerUsage = `用法: api [配置参数] worker [--once] [--jobs 任务1,任务2]
+
+运行后台任务。默认作为常驻进程按各任务的间隔重复执行，收到 SIGTERM 或 SIGINT 后
+等待进行中的任务完成再退出；使用 --once 时每个任务执行一次后退出，适合由 cron 等外部调度器触发。
+
+任务:`
+
+// runWorker 执行 worker 子命令
+//
+// 参数:
+//   - args: worker 之后的命令行参数
+//
+// 返回:
+//   - int: 进程退出码，--once 模式下任一任务失败时返回 1
+func runWorker(args []string) int {
+	fs := flag.NewFlagSet("worker", flag.ContinueOnError)
+	once := fs.Bool("once", false, "每个任务只执行一次后退出")
+	jobs := fs.String("jobs", "", "只运行指定的任务，多个任务用逗号分隔，默认运行全部任务")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, workerUsage)
+		for _, job := range worker.DefaultJobs(nil) {
+			fmt.Fprintf(os.Stderr, "  %-22s 每 %s 执行一次\n", job.Name, job.Interval)
+		}
+		fmt.Fprintln(os.Stderr, "\n参数:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	application, err := connect()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	defer application.Shutdown()
+
+	runner := worker.NewRunner(worker.DefaultJobs(application.Container().Services)...)
+	if err := runner.Select(splitList(*jobs)); err != nil {
+		log.Println(err)
+		return 2
+	}
+
+	if *once {
+		if err := runner.RunOnce(context.Background()); err != nil {
+			log.Printf("后台任务执行失败: %v", err)
+			return 1
+		}
+		return 0
+	}
+
+	log.Printf("后台任务进程已启动，共 %d 个任务", len(runner.Jobs()))
+	runner.Run(signalContext())
+	log.Println("后台任务进程已停止")
+	return 0
+}
+
+// splitList 拆分逗号分隔的参数，忽略空白项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
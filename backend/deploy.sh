@@ -19,6 +19,10 @@ docker-compose build --no-cache
 echo "停止旧容器..."
 docker-compose down || true
 
+# 执行数据库迁移，服务在数据库结构落后时拒绝启动
+echo "执行数据库迁移..."
+docker-compose run --rm api ./nicheflow-api migrate up
+
 # 启动新容器
 echo "启动新容器..."
 docker-compose up -d
@@ -4,27 +4,39 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+	"github.com/yszaryszar/NicheFlow/backend/internal/container"
 	"github.com/yszaryszar/NicheFlow/backend/internal/model"
 	"github.com/yszaryszar/NicheFlow/backend/internal/router"
-	"github.com/yszaryszar/NicheFlow/backend/pkg/cache"
-	"github.com/yszaryszar/NicheFlow/backend/pkg/database"
+	"github.com/yszaryszar/NicheFlow/backend/migrations"
 )
 
 // Application 应用结构体
 // 包含应用运行所需的核心组件
-// 管理应用的配置和 HTTP 引擎
+// 管理应用的配置、HTTP 服务器和后台任务
 type Application struct {
-	config *config.Config // 应用配置实例
-	engine *gin.Engine    // Gin HTTP 引擎实例
+	config     *config.Config       // 启动时的应用配置
+	watcher    *config.Watcher      // 运行时配置监视器
+	container  *container.Container // 数据库、Redis 和业务服务，由 Initialize 创建
+	server     *http.Server         // HTTP 服务器，由 Initialize 创建
+	background sync.WaitGroup       // 进行中的后台任务
+	stopTasks  context.CancelFunc   // 通知后台任务退出
 }
 
 // New 创建新的应用实例
 //
+// 参数:
+//   - opts: 配置加载选项，重新加载配置时使用相同的选项，但参数存储加载失败时不会跳过
+//
 // 返回:
 //   - *Application: 应用实例
 //   - error: 创建过程中的错误，如果成功则为 nil
@@ -35,9 +47,9 @@ type Application struct {
 //	1. 加载应用配置
 //	2. 设置 Gin 运行模式
 //	3. 创建应用实例
-func New() (*Application, error) {
+func New(opts config.Options) (*Application, error) {
 	// 加载配置
-	cfg, err := config.LoadConfig()
+	cfg, sources, err := config.Load(context.Background(), opts)
 	if err != nil {
 		return nil, fmt.Errorf("加载配置失败: %v", err)
 	}
@@ -45,12 +57,70 @@ func New() (*Application, error) {
 	// 设置运行模式
 	gin.SetMode(cfg.App.Mode)
 
+	// 重新加载时参数存储必须加载成功，否则保留当前配置，而不是回退到文件和环境变量中的值
+	reloadOpts := opts
+	reloadOpts.RequireParameterStore = true
+
 	return &Application{
 		config: cfg,
+		watcher: config.NewWatcher(cfg, sources, func(ctx context.Context) (*config.Config, config.Trace, error) {
+			return config.Load(ctx, reloadOpts)
+		}),
 	}, nil
 }
 
-// Initialize 初始化应用
+// Connect 建立应用依赖
+//
+// 返回:
+//   - error: 连接失败或数据库结构落后时返回错误
+//
+// 说明:
+//
+//	该函数完成以下操作，HTTP 服务和其他子命令共用：
+//	1. 创建依赖容器，建立数据库和 Redis 连接并创建业务服务
+//	2. 检查数据库结构版本，存在未执行的迁移时拒绝继续
+//	3. 同步内置角色和权限
+//
+//	失败时已建立的连接由 Shutdown 关闭
+func (app *Application) Connect() error {
+	// 创建依赖容器，之后的组件都从容器获取依赖
+	c, err := container.Open(app.watcher)
+	if err != nil {
+		return err
+	}
+	app.container = c
+
+	// 检查数据库结构版本，启动时不修改表结构，落后时需要先执行 migrate up
+	sqlDB, err := c.DB.DB()
+	if err != nil {
+		return fmt.Errorf("获取 SQL DB 失败: %v", err)
+	}
+	migrator, err := migrations.NewMigrator(sqlDB, app.config.Database.Driver)
+	if err != nil {
+		return fmt.Errorf("加载数据库迁移失败: %v", err)
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		return fmt.Errorf("数据库结构检查失败，请先执行 migrate up: %v", err)
+	}
+
+	// 同步内置角色和权限
+	if err := model.SeedRBAC(c.DB); err != nil {
+		return fmt.Errorf("同步内置角色失败: %v", err)
+	}
+	return nil
+}
+
+// Config 返回启动时的应用配置
+func (app *Application) Config() *config.Config {
+	return app.config
+}
+
+// Container 返回依赖容器，调用 Connect 之前为 nil
+func (app *Application) Container() *container.Container {
+	return app.container
+}
+
+// Initialize 初始化 HTTP 服务
 //
 // 返回:
 //   - error: 初始化过程中的错误，如果成功则为 nil
@@ -58,57 +128,124 @@ func New() (*Application, error) {
 // 说明:
 //
 //	该函数完成以下初始化：
-//	1. 建立数据库连接
-//	2. 执行数据库迁移
-//	3. 建立 Redis 连接
-//	4. 设置 HTTP 路由
+//	1. 调用 Connect 建立数据库、Redis 连接和业务服务
+//	2. 设置 HTTP 路由并创建 HTTP 服务器
 //
 // 注意:
 //
 //	必须在调用 Run 方法之前调用此方法
 //	初始化失败会返回详细的错误信息
 func (app *Application) Initialize() error {
-	// 初始化数据库连接
-	if _, err := database.NewPostgresDB(&app.config.Database); err != nil {
-		return fmt.Errorf("初始化数据库失败: %v", err)
+	if err := app.Connect(); err != nil {
+		return err
 	}
+	c := app.container
 
-	// 运行数据库迁移
-	if err := model.AutoMigrate(); err != nil {
-		return fmt.Errorf("数据库迁移失败: %v", err)
+	// 设置路由
+	engine, err := router.SetupRouter(c)
+	if err != nil {
+		return fmt.Errorf("设置路由失败: %v", err)
 	}
 
-	// 初始化 Redis 连接
-	if _, err := cache.NewRedisClient(&app.config.Redis); err != nil {
-		return fmt.Errorf("初始化 Redis 失败: %v", err)
+	serverCfg := app.config.Server
+	app.server = &http.Server{
+		Addr:              fmt.Sprintf(":%d", app.config.App.Port),
+		Handler:           engine,
+		ReadHeaderTimeout: serverCfg.ReadHeaderTimeout,
+		ReadTimeout:       serverCfg.ReadTimeout,
+		WriteTimeout:      serverCfg.WriteTimeout,
+		IdleTimeout:       serverCfg.IdleTimeout,
 	}
 
-	// 设置路由
-	app.engine = router.SetupRouter(app.config)
-
 	return nil
 }
 
 // Run 运行应用
 //
+// 参数:
+//   - ctx: 取消时开始关闭，通常在收到 SIGTERM 或 SIGINT 时取消
+//
 // 返回:
-//   - error: 运行过程中的错误，如果成功则为 nil
+//   - error: 服务器异常退出或关闭超时时返回错误
 //
 // 说明:
 //
-//	该函数启动 HTTP 服务器：
-//	1. 使用配置的端口
-//	2. 输出启动日志
-//	3. 开始接受 HTTP 请求
+//	该函数启动 HTTP 服务器和后台任务，ctx 取消后按以下顺序关闭：
+//	1. 就绪检查返回 503，继续接受请求 DrainDelay 时长，等待负载均衡摘除实例
+//	2. 停止接受新连接，等待进行中的请求完成
+//	3. 通知后台任务退出并等待其完成
+//	第 2、3 步共用 ShutdownTimeout，超时后强制关闭剩余连接。
+//	数据库和 Redis 连接由 Shutdown 关闭。
 //
 // 注意:
 //
-//	这是一个阻塞调用
-//	服务器会一直运行直到收到中断信号
-func (app *Application) Run() error {
-	addr := fmt.Sprintf(":%d", app.config.App.Port)
-	log.Printf("服务器启动在 %s", addr)
-	return app.engine.Run(addr)
+//	这是一个阻塞调用，返回后应调用 Shutdown 释放资源
+func (app *Application) Run(ctx context.Context) error {
+	taskCtx, cancel := context.WithCancel(context.Background())
+	app.stopTasks = cancel
+
+	// 运行时配置监视器，定期或在收到 SIGHUP 时刷新配置
+	app.goBackground(func() {
+		app.watcher.Run(taskCtx, app.config.App.ReloadInterval)
+	})
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("服务器启动在 %s", app.server.Addr)
+		serveErr <- app.server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("HTTP 服务器异常退出: %v", err)
+	case <-ctx.Done():
+	}
+	return app.drain()
+}
+
+// goBackground 启动后台任务，关闭时会等待其完成
+// 任务需要在 Run 创建的任务上下文取消后尽快返回
+func (app *Application) goBackground(task func()) {
+	app.background.Add(1)
+	go func() {
+		defer app.background.Done()
+		task()
+	}()
+}
+
+// drain 摘除实例并等待进行中的请求和后台任务完成
+func (app *Application) drain() error {
+	cfg := app.config.Server
+	log.Printf("收到停止信号，就绪检查开始返回 503，%s 后停止接受新连接", cfg.DrainDelay)
+	app.container.StartDraining()
+	time.Sleep(cfg.DrainDelay)
+
+	ctx := context.Background()
+	if cfg.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.ShutdownTimeout)
+		defer cancel()
+	}
+
+	var errs []error
+	log.Println("停止接受新连接，等待进行中的请求完成")
+	if err := app.server.Shutdown(ctx); err != nil {
+		app.server.Close()
+		errs = append(errs, fmt.Errorf("等待进行中的请求超时，已强制关闭连接: %w", err))
+	}
+
+	app.stopTasks()
+	done := make(chan struct{})
+	go func() {
+		app.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("等待后台任务超时: %w", ctx.Err()))
+	}
+	return errors.Join(errs...)
 }
 
 // Shutdown 关闭应用
@@ -116,15 +253,19 @@ func (app *Application) Run() error {
 // 说明:
 //
 //	该函数执行清理操作：
-//	1. 关闭数据库连接
-//	2. 关闭 Redis 连接
-//	3. 释放其他资源
+//	1. 通知后台任务退出（Run 正常返回时已完成）
+//	2. 先关闭数据库连接，再关闭 Redis 连接
 //
 // 注意:
 //
-//	应在应用退出前调用此方法
-//	建议使用 defer 确保资源被正确释放
+//	应在 Run 返回后或初始化失败时调用此方法，不能与 Run 并发调用
 func (app *Application) Shutdown() {
-	database.Close()
-	cache.Close()
+	if app.stopTasks != nil {
+		app.stopTasks()
+	}
+	if app.container != nil {
+		if err := app.container.Close(); err != nil {
+			log.Printf("释放资源失败: %v", err)
+		}
+	}
 }
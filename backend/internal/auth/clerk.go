@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/clerk/clerk-sdk-go/v2/jwks"
+	"github.com/clerk/clerk-sdk-go/v2/jwt"
+	"github.com/clerk/clerk-sdk-go/v2/user"
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+)
+
+// clerkJWKCacheTTL Clerk 公钥缓存时间
+const clerkJWKCacheTTL = time.Hour
+
+// ClerkProvider 基于 Clerk SDK 的身份提供方
+type ClerkProvider struct {
+	jwksClient *jwks.Client
+	userClient *user.Client
+	keys       *keySetCache[map[string]*clerk.JSONWebKey] // 按 kid 索引的公钥集
+}
+
+// NewClerkProvider 创建 Clerk 身份提供方
+//
+// 参数:
+//   - cfg: Clerk 配置，必须包含 API 密钥
+//
+// 返回:
+//   - *ClerkProvider: Clerk 身份提供方
+//   - error: 未配置 API 密钥时返回错误
+func NewClerkProvider(cfg *config.ClerkConfig) (*ClerkProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("未设置 Clerk API Key")
+	}
+	clerk.SetKey(cfg.APIKey)
+
+	clientConfig := &clerk.ClientConfig{
+		BackendConfig: clerk.BackendConfig{Key: clerk.String(cfg.APIKey)},
+	}
+	p := &ClerkProvider{
+		jwksClient: jwks.NewClient(clientConfig),
+		userClient: user.NewClient(clientConfig),
+	}
+	p.keys = &keySetCache[map[string]*clerk.JSONWebKey]{name: "Clerk JWKS", ttl: clerkJWKCacheTTL, load: p.loadKeys}
+	return p, nil
+}
+
+// Name 返回身份提供方名称
+func (p *ClerkProvider) Name() string {
+	return ProviderClerk
+}
+
+// Authenticate 验证 Clerk 会话令牌
+func (p *ClerkProvider) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+
+	decoded, err := jwt.Decode(ctx, &jwt.DecodeParams{Token: token})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	jwk, err := p.getJWK(ctx, decoded.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	claims, err := jwt.Verify(ctx, &jwt.VerifyParams{Token: token, JWK: jwk})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	identity := &Identity{
+		Subject:   claims.Subject,
+		SessionID: claims.SessionID,
+	}
+	if claims.Expiry != nil {
+		identity.ExpiresAt = time.Unix(*claims.Expiry, 0)
+	}
+	return identity, nil
+}
+
+// FetchProfile 通过 Clerk Backend API 获取用户资料
+func (p *ClerkProvider) FetchProfile(ctx context.Context, subject string) (*Identity, error) {
+	u, err := p.userClient.Get(ctx, subject)
+	if err != nil {
+		return nil, fmt.Errorf("获取 Clerk 用户失败: %w", err)
+	}
+
+	identity := &Identity{
+		Subject:   u.ID,
+		Username:  DerefString(u.Username),
+		FirstName: DerefString(u.FirstName),
+		LastName:  DerefString(u.LastName),
+		ImageURL:  DerefString(u.ImageURL),
+	}
+	for _, email := range u.EmailAddresses {
+		if email == nil || (u.PrimaryEmailAddressID != nil && email.ID != *u.PrimaryEmailAddressID) {
+			continue
+		}
+		identity.Email = email.EmailAddress
+		identity.EmailVerified = email.Verification != nil && email.Verification.Status == "verified"
+		break
+	}
+	for _, phone := range u.PhoneNumbers {
+		if phone == nil || (u.PrimaryPhoneNumberID != nil && phone.ID != *u.PrimaryPhoneNumberID) {
+			continue
+		}
+		identity.PhoneNumber = phone.PhoneNumber
+		identity.PhoneVerified = phone.Verification != nil && phone.Verification.Status == "verified"
+		break
+	}
+	return identity, nil
+}
+
+// DerefString 安全地解引用字符串指针，Clerk 的可选字段为 nil 时返回空字符串
+func DerefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// getJWK 获取指定 kid 的公钥，优先使用缓存
+func (p *ClerkProvider) getJWK(ctx context.Context, kid string) (*clerk.JSONWebKey, error) {
+	keys, err := p.keys.get(ctx, func(keys map[string]*clerk.JSONWebKey) bool {
+		return keys[kid] == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys[kid]
+	if key == nil {
+		return nil, fmt.Errorf("未找到公钥: %s", kid)
+	}
+	return key, nil
+}
+
+// loadKeys 从 Clerk 拉取公钥集
+func (p *ClerkProvider) loadKeys(ctx context.Context) (map[string]*clerk.JSONWebKey, error) {
+	keySet, err := p.jwksClient.Get(ctx, &jwks.GetParams{})
+	if err != nil {
+		return nil, fmt.Errorf("获取 Clerk JWKS 失败: %w", err)
+	}
+
+	keys := make(map[string]*clerk.JSONWebKey, len(keySet.Keys))
+	for _, key := range keySet.Keys {
+		if key != nil && key.KeyID != "" {
+			keys[key.KeyID] = key
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,269 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+)
+
+// JWKS 提供方默认参数
+const (
+	defaultJWKSRefreshInterval = 10 * time.Minute // 公钥集刷新间隔
+	jwksHTTPTimeout            = 5 * time.Second  // 拉取公钥集的超时时间
+)
+
+// oidcClaims 通用 OIDC 令牌声明
+type oidcClaims struct {
+	jwt.Claims
+	SessionID     string `json:"sid"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	Picture       string `json:"picture"`
+}
+
+// JWKSProvider 基于 JWKS/OIDC 的通用身份提供方
+// 使用配置的签发者和公钥集离线验证令牌，不依赖 Clerk
+type JWKSProvider struct {
+	issuer   string
+	audience string
+	leeway   time.Duration
+	file     string
+	client   *http.Client
+	keys     *keySetCache[*jose.JSONWebKeySet]
+
+	mu  sync.RWMutex
+	url string // 公钥集地址，未配置时通过 discovery 获取后写入
+}
+
+// NewJWKSProvider 创建 JWKS 身份提供方
+//
+// 参数:
+//   - cfg: JWKS 配置，必须包含签发者
+//
+// 返回:
+//   - *JWKSProvider: JWKS 身份提供方
+//   - error: 配置缺失或本地公钥集无法加载时返回错误
+//
+// 说明:
+//
+//	公钥集来源优先级：本地文件 > 配置的 URL > 签发者的 OIDC discovery 文档。
+//	使用本地文件时启动阶段即加载，配置错误会立即暴露。
+//	公钥集按 refresh_interval 刷新，刷新失败时继续使用上次成功获取的公钥集。
+func NewJWKSProvider(cfg *config.JWKSConfig) (*JWKSProvider, error) {
+	if cfg.Issuer == "" {
+		return nil, errors.New("未设置 JWKS 签发者")
+	}
+
+	refresh := cfg.RefreshInterval
+	if refresh <= 0 {
+		refresh = defaultJWKSRefreshInterval
+	}
+
+	p := &JWKSProvider{
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		leeway:   cfg.Leeway,
+		file:     cfg.File,
+		client:   &http.Client{Timeout: jwksHTTPTimeout},
+		url:      cfg.URL,
+	}
+	p.keys = &keySetCache[*jose.JSONWebKeySet]{name: "JWKS", ttl: refresh, load: p.loadKeySet}
+
+	if p.file != "" {
+		if _, err := p.keys.refresh(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Name 返回身份提供方名称
+func (p *JWKSProvider) Name() string {
+	return ProviderJWKS
+}
+
+// Authenticate 验证 JWKS 签名的令牌
+func (p *JWKSProvider) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if len(parsed.Headers) == 0 {
+		return nil, fmt.Errorf("%w: 缺少 JWT 头", ErrInvalidToken)
+	}
+	header := parsed.Headers[0]
+
+	key, err := p.getKey(ctx, header.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if key.Algorithm != "" && key.Algorithm != header.Algorithm {
+		return nil, fmt.Errorf("%w: 签名算法不匹配 %s", ErrInvalidToken, header.Algorithm)
+	}
+
+	var claims oidcClaims
+	if err := parsed.Claims(key.Key, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	expected := jwt.Expected{Issuer: p.issuer, Time: time.Now()}
+	if p.audience != "" {
+		expected.Audience = jwt.Audience{p.audience}
+	}
+	if err := claims.ValidateWithLeeway(expected, p.leeway); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Subject == "" || claims.Expiry == nil {
+		return nil, fmt.Errorf("%w: 缺少 sub 或 exp 声明", ErrInvalidToken)
+	}
+
+	return &Identity{
+		Subject:       claims.Subject,
+		SessionID:     claims.SessionID,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		FirstName:     claims.GivenName,
+		LastName:      claims.FamilyName,
+		ImageURL:      claims.Picture,
+		ExpiresAt:     claims.Expiry.Time(),
+	}, nil
+}
+
+// getKey 获取指定 kid 的公钥，找不到时尝试刷新公钥集
+func (p *JWKSProvider) getKey(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	keySet, err := p.keys.get(ctx, func(keySet *jose.JSONWebKeySet) bool {
+		// 可能发生了密钥轮换
+		return findKey(keySet, kid) == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	key := findKey(keySet, kid)
+	if key == nil {
+		return nil, fmt.Errorf("未找到公钥: %s", kid)
+	}
+	return key, nil
+}
+
+// findKey 在公钥集中查找签名公钥，kid 为空且只有一个公钥时直接使用该公钥
+func findKey(keySet *jose.JSONWebKeySet, kid string) *jose.JSONWebKey {
+	if kid == "" {
+		if len(keySet.Keys) == 1 {
+			return &keySet.Keys[0]
+		}
+		return nil
+	}
+	for _, key := range keySet.Key(kid) {
+		if key.Use == "" || key.Use == "sig" {
+			return &key
+		}
+	}
+	return nil
+}
+
+// loadKeySet 从本地文件或远程地址加载公钥集
+func (p *JWKSProvider) loadKeySet(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if p.file != "" {
+		data, err = os.ReadFile(p.file)
+		if err != nil {
+			return nil, fmt.Errorf("读取 JWKS 文件失败: %w", err)
+		}
+	} else {
+		p.mu.RLock()
+		url := p.url
+		p.mu.RUnlock()
+		if url == "" {
+			if url, err = p.discoverJWKSURL(ctx); err != nil {
+				return nil, err
+			}
+		}
+		if data, err = p.fetch(ctx, url); err != nil {
+			return nil, fmt.Errorf("获取 JWKS 失败: %w", err)
+		}
+	}
+
+	var keySet jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &keySet); err != nil {
+		return nil, fmt.Errorf("解析 JWKS 失败: %w", err)
+	}
+	for _, key := range keySet.Keys {
+		if !key.IsPublic() {
+			return nil, errors.New("JWKS 中包含私钥")
+		}
+	}
+	return &keySet, nil
+}
+
+// discoverJWKSURL 通过 OIDC discovery 文档获取 JWKS 地址
+// 文档中的 issuer 必须与配置的签发者完全一致，否则不信任其中的 jwks_uri
+func (p *JWKSProvider) discoverJWKSURL(ctx context.Context) (string, error) {
+	data, err := p.fetch(ctx, strings.TrimSuffix(p.issuer, "/")+"/.well-known/openid-configuration")
+	if err != nil {
+		return "", fmt.Errorf("获取 OIDC 配置失败: %w", err)
+	}
+
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := json.Unmarshal(data, &discovery); err != nil {
+		return "", fmt.Errorf("解析 OIDC 配置失败: %w", err)
+	}
+	if discovery.Issuer != p.issuer {
+		return "", fmt.Errorf("OIDC 配置中的 issuer %q 与配置的签发者 %q 不一致", discovery.Issuer, p.issuer)
+	}
+	if discovery.JWKSURI == "" {
+		return "", errors.New("OIDC 配置中缺少 jwks_uri")
+	}
+
+	p.mu.Lock()
+	p.url = discovery.JWKSURI
+	p.mu.Unlock()
+
+	return discovery.JWKSURI, nil
+}
+
+// fetch 发起 GET 请求并返回响应体
+func (p *JWKSProvider) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("请求 %s 返回 %d", url, resp.StatusCode)
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
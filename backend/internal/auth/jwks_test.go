@@ -0,0 +1,286 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+)
+
+// testIssuer 身份提供方服务，提供 OIDC discovery 文档和公钥集
+type testIssuer struct {
+	server *httptest.Server
+	issuer string // discovery 文档中返回的 issuer，为空时使用服务地址
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PrivateKey // 当前发布的签名密钥
+	fail      bool                       // 公钥集请求是否返回 503
+	jwksLoads atomic.Int32               // 公钥集被请求的次数
+}
+
+// newTestIssuer 启动身份提供方服务，初始发布 kids 中的密钥
+func newTestIssuer(t *testing.T, kids ...string) *testIssuer {
+	t.Helper()
+	ti := &testIssuer{keys: make(map[string]*rsa.PrivateKey)}
+	for _, kid := range kids {
+		ti.addKey(t, kid)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer := ti.issuer
+		if issuer == "" {
+			issuer = ti.server.URL
+		}
+		json.NewEncoder(w).Encode(map[string]string{"issuer": issuer, "jwks_uri": ti.server.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		ti.jwksLoads.Add(1)
+		ti.mu.Lock()
+		defer ti.mu.Unlock()
+		if ti.fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var keySet jose.JSONWebKeySet
+		for kid, key := range ti.keys {
+			keySet.Keys = append(keySet.Keys, jose.JSONWebKey{Key: &key.PublicKey, KeyID: kid, Algorithm: string(jose.RS256), Use: "sig"})
+		}
+		json.NewEncoder(w).Encode(keySet)
+	})
+	ti.server = httptest.NewServer(mux)
+	t.Cleanup(ti.server.Close)
+	return ti
+}
+
+// addKey 生成并发布一个签名密钥
+func (ti *testIssuer) addKey(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成 RSA 密钥失败: %v", err)
+	}
+	ti.mu.Lock()
+	ti.keys[kid] = key
+	ti.mu.Unlock()
+}
+
+// sign 使用 kid 对应的密钥签发令牌，密钥不存在时使用新生成的密钥
+func (ti *testIssuer) sign(t *testing.T, kid string, claims oidcClaims) string {
+	t.Helper()
+	ti.mu.Lock()
+	key := ti.keys[kid]
+	ti.mu.Unlock()
+	if key == nil {
+		var err error
+		if key, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			t.Fatalf("生成 RSA 密钥失败: %v", err)
+		}
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: kid}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		t.Fatalf("创建签名器失败: %v", err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	return token
+}
+
+// claims 返回签发者正确、一小时后过期的声明
+func (ti *testIssuer) claims(subject string) oidcClaims {
+	now := time.Now()
+	return oidcClaims{Claims: jwt.Claims{
+		Issuer:   ti.server.URL,
+		Subject:  subject,
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+	}}
+}
+
+// newTestJWKSProvider 创建通过 discovery 获取公钥集的提供方
+func newTestJWKSProvider(t *testing.T, ti *testIssuer) *JWKSProvider {
+	t.Helper()
+	p, err := NewJWKSProvider(&config.JWKSConfig{Issuer: ti.server.URL})
+	if err != nil {
+		t.Fatalf("创建 JWKS 提供方失败: %v", err)
+	}
+	return p
+}
+
+// TestJWKSProviderAuthenticate 校验令牌的签名、kid 和声明
+func TestJWKSProviderAuthenticate(t *testing.T) {
+	ti := newTestIssuer(t, "k1")
+
+	cases := []struct {
+		name    string
+		token   func() string
+		wantErr bool
+	}{
+		{
+			name:  "有效令牌",
+			token: func() string { return ti.sign(t, "k1", ti.claims("user_1")) },
+		},
+		{
+			name:    "未知 kid",
+			token:   func() string { return ti.sign(t, "unknown", ti.claims("user_1")) },
+			wantErr: true,
+		},
+		{
+			name: "签发者不一致",
+			token: func() string {
+				claims := ti.claims("user_1")
+				claims.Issuer = "https://evil.example.com"
+				return ti.sign(t, "k1", claims)
+			},
+			wantErr: true,
+		},
+		{
+			name: "令牌已过期",
+			token: func() string {
+				claims := ti.claims("user_1")
+				claims.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+				return ti.sign(t, "k1", claims)
+			},
+			wantErr: true,
+		},
+		{
+			name:    "缺少 sub",
+			token:   func() string { return ti.sign(t, "k1", ti.claims("")) },
+			wantErr: true,
+		},
+		{
+			name:    "不是 JWT",
+			token:   func() string { return "not-a-token" },
+			wantErr: true,
+		},
+	}
+
+	p := newTestJWKSProvider(t, ti)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			identity, err := p.Authenticate(context.Background(), tc.token())
+			if tc.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Authenticate 返回 %v，期望 ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate 返回 %v", err)
+			}
+			if identity.Subject != "user_1" {
+				t.Fatalf("Subject 为 %q，期望 user_1", identity.Subject)
+			}
+		})
+	}
+
+	// 未知 kid 只会在首次加载后的最小间隔之外触发刷新，上面的用例不应重复拉取
+	if loads := ti.jwksLoads.Load(); loads != 1 {
+		t.Fatalf("公钥集被拉取了 %d 次，期望 1 次", loads)
+	}
+}
+
+// TestJWKSProviderRefresh 校验密钥轮换后的刷新、刷新节流和公钥服务故障时的回退
+func TestJWKSProviderRefresh(t *testing.T) {
+	ti := newTestIssuer(t, "k1")
+	p := newTestJWKSProvider(t, ti)
+	ctx := context.Background()
+
+	if _, err := p.Authenticate(ctx, ti.sign(t, "k1", ti.claims("user_1"))); err != nil {
+		t.Fatalf("首次验证失败: %v", err)
+	}
+
+	// 轮换后的新密钥在最小间隔内不会触发刷新
+	ti.addKey(t, "k2")
+	rotated := ti.sign(t, "k2", ti.claims("user_1"))
+	if _, err := p.Authenticate(ctx, rotated); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("最小间隔内使用新密钥返回 %v，期望 ErrInvalidToken", err)
+	}
+	if loads := ti.jwksLoads.Load(); loads != 1 {
+		t.Fatalf("最小间隔内公钥集被拉取了 %d 次，期望 1 次", loads)
+	}
+
+	// 超过最小间隔后，未知 kid 触发刷新并接受新密钥
+	rewind(p.keys, minKeySetRefreshInterval)
+	if _, err := p.Authenticate(ctx, rotated); err != nil {
+		t.Fatalf("刷新后使用新密钥验证失败: %v", err)
+	}
+	if loads := ti.jwksLoads.Load(); loads != 2 {
+		t.Fatalf("公钥集被拉取了 %d 次，期望 2 次", loads)
+	}
+
+	// 公钥集过期后刷新失败，继续使用上次成功获取的公钥集
+	ti.mu.Lock()
+	ti.fail = true
+	ti.mu.Unlock()
+	rewind(p.keys, defaultJWKSRefreshInterval)
+	if _, err := p.Authenticate(ctx, ti.sign(t, "k1", ti.claims("user_1"))); err != nil {
+		t.Fatalf("刷新失败时使用旧公钥集验证失败: %v", err)
+	}
+	if loads := ti.jwksLoads.Load(); loads != 3 {
+		t.Fatalf("公钥集被拉取了 %d 次，期望 3 次", loads)
+	}
+}
+
+// TestJWKSProviderDiscovery 校验 discovery 文档中的 issuer 必须与配置一致
+func TestJWKSProviderDiscovery(t *testing.T) {
+	cases := []struct {
+		name    string
+		issuer  func(ti *testIssuer) string
+		wantErr bool
+	}{
+		{
+			name:   "issuer 一致",
+			issuer: func(ti *testIssuer) string { return ti.server.URL },
+		},
+		{
+			name:    "issuer 为其他地址",
+			issuer:  func(*testIssuer) string { return "https://evil.example.com" },
+			wantErr: true,
+		},
+		{
+			name:    "issuer 多了末尾斜杠",
+			issuer:  func(ti *testIssuer) string { return ti.server.URL + "/" },
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ti := newTestIssuer(t, "k1")
+			ti.issuer = tc.issuer(ti)
+			p := newTestJWKSProvider(t, ti)
+
+			_, err := p.Authenticate(context.Background(), ti.sign(t, "k1", ti.claims("user_1")))
+			if tc.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Authenticate 返回 %v，期望 ErrInvalidToken", err)
+				}
+				if loads := ti.jwksLoads.Load(); loads != 0 {
+					t.Fatalf("issuer 不一致时仍拉取了 %d 次公钥集", loads)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate 返回 %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// minKeySetRefreshInterval 两次拉取公钥集的最小间隔
+// 遇到未知 kid 或拉取失败后，在该时间内不会再次请求公钥服务
+const minKeySetRefreshInterval = 30 * time.Second
+
+// keySetCache 身份提供方公钥集的缓存
+// 公钥集过期或找不到令牌使用的公钥时刷新，刷新失败时继续使用上次成功获取的公钥集。
+// 同一时刻只会有一次拉取，两次拉取至少间隔 minKeySetRefreshInterval，
+// 避免携带伪造 kid 的令牌放大对公钥服务的请求
+type keySetCache[T any] struct {
+	name  string                           // 日志中使用的公钥集名称
+	ttl   time.Duration                    // 公钥集的有效期
+	load  func(context.Context) (T, error) // 拉取公钥集
+	group singleflight.Group               // 合并并发的拉取
+
+	mu          sync.RWMutex
+	keySet      T
+	loaded      bool      // 是否成功拉取过
+	fetchedAt   time.Time // 最近一次成功拉取的时间
+	attemptedAt time.Time // 最近一次尝试拉取的时间
+	lastErr     error     // 最近一次拉取失败的原因
+}
+
+// get 返回当前公钥集
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - missing: 判断公钥集中是否缺少令牌使用的公钥，缺少时尝试刷新
+//
+// 返回:
+//   - T: 公钥集，刷新失败时为上次成功获取的公钥集
+//   - error: 从未成功获取过公钥集时返回错误
+func (c *keySetCache[T]) get(ctx context.Context, missing func(T) bool) (T, error) {
+	c.mu.RLock()
+	keySet, loaded := c.keySet, c.loaded
+	fetchedAt, attemptedAt, lastErr := c.fetchedAt, c.attemptedAt, c.lastErr
+	c.mu.RUnlock()
+
+	if loaded && time.Since(fetchedAt) < c.ttl && !missing(keySet) {
+		return keySet, nil
+	}
+	// 首次拉取仍在进行时 lastErr 为空，继续等待该次拉取的结果
+	if time.Since(attemptedAt) < minKeySetRefreshInterval && (loaded || lastErr != nil) {
+		if loaded {
+			return keySet, nil
+		}
+		return keySet, fmt.Errorf("%s 暂不可用: %w", c.name, lastErr)
+	}
+
+	value, err, _ := c.group.Do(c.name, func() (interface{}, error) {
+		// 与发起请求的生命周期解耦，避免首个请求取消导致其他等待者失败
+		return c.refresh(context.WithoutCancel(ctx))
+	})
+	if err != nil {
+		if loaded {
+			log.Printf("刷新 %s 失败，继续使用 %s 获取的公钥集: %v", c.name, fetchedAt.Format(time.RFC3339), err)
+			return keySet, nil
+		}
+		return keySet, err
+	}
+	return value.(T), nil
+}
+
+// refresh 拉取公钥集并更新缓存
+func (c *keySetCache[T]) refresh(ctx context.Context) (T, error) {
+	c.mu.Lock()
+	c.attemptedAt = time.Now()
+	c.mu.Unlock()
+
+	keySet, err := c.load(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.lastErr = err
+		return keySet, err
+	}
+	c.keySet = keySet
+	c.loaded = true
+	c.fetchedAt = time.Now()
+	c.lastErr = nil
+	return keySet, nil
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// keySetStep 对公钥集缓存的一次读取
+type keySetStep struct {
+	elapsed   time.Duration // 读取前经过的时间
+	missing   bool          // 令牌使用的公钥是否不在当前公钥集中
+	fail      bool          // 本次拉取是否失败
+	want      string        // 期望返回的公钥集
+	wantErr   bool          // 是否期望返回错误
+	wantLoads int           // 读取后累计的拉取次数
+}
+
+// TestKeySetCache 校验公钥集的过期刷新、未知 kid 刷新、刷新节流和失败时的回退
+func TestKeySetCache(t *testing.T) {
+	const ttl = 10 * time.Minute
+
+	cases := []struct {
+		name  string
+		steps []keySetStep
+	}{
+		{
+			name: "有效期内使用缓存",
+			steps: []keySetStep{
+				{want: "v1", wantLoads: 1},
+				{elapsed: time.Minute, want: "v1", wantLoads: 1},
+			},
+		},
+		{
+			name: "过期后刷新",
+			steps: []keySetStep{
+				{want: "v1", wantLoads: 1},
+				{elapsed: ttl, want: "v2", wantLoads: 2},
+			},
+		},
+		{
+			name: "未知 kid 触发刷新",
+			steps: []keySetStep{
+				{want: "v1", wantLoads: 1},
+				{elapsed: minKeySetRefreshInterval, missing: true, want: "v2", wantLoads: 2},
+			},
+		},
+		{
+			name: "未知 kid 的刷新受最小间隔限制",
+			steps: []keySetStep{
+				{want: "v1", wantLoads: 1},
+				{elapsed: time.Second, missing: true, want: "v1", wantLoads: 1},
+				{elapsed: time.Second, missing: true, want: "v1", wantLoads: 1},
+				{elapsed: minKeySetRefreshInterval, missing: true, want: "v2", wantLoads: 2},
+			},
+		},
+		{
+			name: "刷新失败时使用上次成功的公钥集",
+			steps: []keySetStep{
+				{want: "v1", wantLoads: 1},
+				{elapsed: ttl, fail: true, want: "v1", wantLoads: 2},
+			},
+		},
+		{
+			name: "刷新失败后在最小间隔内不再拉取",
+			steps: []keySetStep{
+				{want: "v1", wantLoads: 1},
+				{elapsed: ttl, fail: true, want: "v1", wantLoads: 2},
+				{elapsed: time.Second, want: "v1", wantLoads: 2},
+				{elapsed: minKeySetRefreshInterval, want: "v3", wantLoads: 3},
+			},
+		},
+		{
+			name: "从未成功拉取时返回错误",
+			steps: []keySetStep{
+				{fail: true, wantErr: true, wantLoads: 1},
+				{elapsed: time.Second, wantErr: true, wantLoads: 1},
+				{elapsed: minKeySetRefreshInterval, want: "v2", wantLoads: 2},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				loads int
+				fail  bool
+			)
+			cache := &keySetCache[string]{
+				name: "测试公钥集",
+				ttl:  ttl,
+				load: func(context.Context) (string, error) {
+					loads++
+					if fail {
+						return "", errors.New("公钥服务不可用")
+					}
+					return fmt.Sprintf("v%d", loads), nil
+				},
+			}
+
+			for i, step := range tc.steps {
+				rewind(cache, step.elapsed)
+				fail = step.fail
+
+				got, err := cache.get(context.Background(), func(string) bool { return step.missing })
+				if (err != nil) != step.wantErr {
+					t.Fatalf("第 %d 次读取返回错误 %v，期望出错: %v", i+1, err, step.wantErr)
+				}
+				if err == nil && got != step.want {
+					t.Fatalf("第 %d 次读取返回 %q，期望 %q", i+1, got, step.want)
+				}
+				if loads != step.wantLoads {
+					t.Fatalf("第 %d 次读取后拉取了 %d 次，期望 %d 次", i+1, loads, step.wantLoads)
+				}
+			}
+		})
+	}
+}
+
+// rewind 把缓存记录的拉取时间提前，模拟经过了 d
+func rewind[T any](c *keySetCache[T], d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.fetchedAt.IsZero() {
+		c.fetchedAt = c.fetchedAt.Add(-d)
+	}
+	if !c.attemptedAt.IsZero() {
+		c.attemptedAt = c.attemptedAt.Add(-d)
+	}
+}
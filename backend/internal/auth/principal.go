@@ -0,0 +1,144 @@
+// Package auth 提供认证相关的公共类型和工具
+// 包含已认证主体的定义以及在请求上下文中存取主体的辅助函数
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+)
+
+// principalContextKey 已认证主体在 Gin 上下文中的键
+const principalContextKey = "auth_principal"
+
+// Method 认证方式
+type Method string
+
+// 支持的认证方式
+const (
+	MethodSession Method = "session" // Clerk 会话令牌
+	MethodAPIKey  Method = "api_key" // 用户个人 API 密钥
+
+	MethodImpersonation Method = "impersonation" // 管理员模拟登录会话
+)
+
+// Principal 已认证的请求主体
+// 由认证中间件在每个请求中构建一次，处理器只能通过本包的辅助函数读取
+type Principal struct {
+	UserID    uint        `json:"user_id"`    // 本地用户 ID
+	ClerkID   string      `json:"clerk_id"`   // Clerk 用户 ID
+	Role      string      `json:"role"`       // 用户角色
+	Plan      string      `json:"plan"`       // 订阅计划
+	SessionID string      `json:"session_id"` // 会话 ID
+	Method    Method      `json:"method"`     // 认证方式
+	APIKeyID  uint        `json:"api_key_id"` // API 密钥 ID，仅 API 密钥认证时有值
+	Scopes    []string    `json:"scopes"`     // 权限范围，为空表示不受限（会话认证）
+	User      *model.User `json:"-"`          // 认证时加载的用户信息
+
+	// 模拟登录时 UserID 等字段为被模拟的用户，以下字段记录实际操作者
+	ImpersonatorID  uint                        `json:"impersonator_id,omitempty"`  // 发起模拟的管理员用户 ID
+	ImpersonationID uint                        `json:"impersonation_id,omitempty"` // 模拟登录会话 ID
+	Impersonation   *model.ImpersonationSession `json:"-"`                          // 模拟登录会话
+}
+
+// NewPrincipal 根据用户信息构建已认证主体
+//
+// 参数:
+//   - user: 已认证的用户
+//   - sessionID: 会话 ID
+//   - method: 认证方式
+//
+// 返回:
+//   - *Principal: 已认证主体
+func NewPrincipal(user *model.User, sessionID string, method Method) *Principal {
+	return &Principal{
+		UserID:    user.ID,
+		ClerkID:   user.ClerkID,
+		Role:      user.Role,
+		Plan:      user.SubscriptionPlan,
+		SessionID: sessionID,
+		Method:    method,
+		User:      user,
+	}
+}
+
+// NewAPIKeyPrincipal 根据 API 密钥构建已认证主体
+//
+// 说明:
+//
+//	API 密钥认证得到的主体与会话认证指向同一用户，
+//	因此按用户计算的使用配额对两种认证方式同样生效。
+func NewAPIKeyPrincipal(user *model.User, key *model.APIKey) *Principal {
+	p := NewPrincipal(user, "", MethodAPIKey)
+	p.APIKeyID = key.ID
+	p.Scopes = key.ScopeList()
+	return p
+}
+
+// NewImpersonationPrincipal 根据模拟登录会话构建已认证主体
+//
+// 说明:
+//
+//	主体的用户字段为被模拟的用户，处理器按该用户处理请求；
+//	权限范围取自会话，实际操作者记录在 ImpersonatorID 中。
+func NewImpersonationPrincipal(user *model.User, session *model.ImpersonationSession) *Principal {
+	p := NewPrincipal(user, "", MethodImpersonation)
+	p.Scopes = session.ScopeList()
+	p.ImpersonatorID = session.ActorID
+	p.ImpersonationID = session.ID
+	p.Impersonation = session
+	return p
+}
+
+// IsImpersonated 判断主体是否来自模拟登录
+func (p *Principal) IsImpersonated() bool {
+	return p.Method == MethodImpersonation
+}
+
+// HasScope 判断主体是否拥有指定权限范围
+func (p *Principal) HasScope(scope string) bool {
+	if p.Method != MethodAPIKey && p.Method != MethodImpersonation {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPrincipal 将已认证主体写入请求上下文
+func SetPrincipal(c *gin.Context, p *Principal) {
+	c.Set(principalContextKey, p)
+}
+
+// GetPrincipal 从请求上下文读取已认证主体
+//
+// 返回:
+//   - *Principal: 已认证主体
+//   - bool: 请求是否已通过认证
+func GetPrincipal(c *gin.Context) (*Principal, bool) {
+	value, exists := c.Get(principalContextKey)
+	if !exists {
+		return nil, false
+	}
+	p, ok := value.(*Principal)
+	return p, ok && p != nil
+}
+
+// GetClerkID 返回当前请求主体的 Clerk ID，未认证时返回空字符串
+func GetClerkID(c *gin.Context) string {
+	if p, ok := GetPrincipal(c); ok {
+		return p.ClerkID
+	}
+	return ""
+}
+
+// GetUser 返回当前请求主体对应的用户信息
+func GetUser(c *gin.Context) (*model.User, bool) {
+	p, ok := GetPrincipal(c)
+	if !ok || p.User == nil {
+		return nil, false
+	}
+	return p.User, true
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+)
+
+// 支持的身份提供方
+const (
+	ProviderClerk = "clerk" // Clerk 会话令牌
+	ProviderJWKS  = "jwks"  // 通用 JWKS/OIDC 令牌，适用于本地开发、CI 和 Auth.js
+)
+
+// 身份验证错误
+var (
+	ErrMissingToken = errors.New("缺少认证令牌")
+	ErrInvalidToken = errors.New("无效的认证令牌")
+)
+
+// Identity 身份提供方验证令牌后得到的身份信息
+type Identity struct {
+	Subject       string    // 外部用户 ID，对应 model.User.ClerkID
+	SessionID     string    // 会话 ID
+	Email         string    // 邮箱（令牌中包含时）
+	EmailVerified bool      // 邮箱是否已验证
+	Username      string    // 用户名
+	FirstName     string    // 名
+	LastName      string    // 姓
+	ImageURL      string    // 头像
+	PhoneNumber   string    // 手机号
+	PhoneVerified bool      // 手机号是否已验证
+	ExpiresAt     time.Time // 令牌过期时间
+}
+
+// Profile 将身份信息转换为只包含资料字段的用户模型
+func (i *Identity) Profile() *model.User {
+	return &model.User{
+		ClerkID:       i.Subject,
+		Email:         i.Email,
+		EmailVerified: i.EmailVerified,
+		Username:      i.Username,
+		FirstName:     i.FirstName,
+		LastName:      i.LastName,
+		ImageURL:      i.ImageURL,
+		PhoneNumber:   i.PhoneNumber,
+		PhoneVerified: i.PhoneVerified,
+	}
+}
+
+// IdentityProvider 身份提供方接口
+// 负责验证请求携带的令牌并返回对应的身份信息，不涉及本地用户的加载
+type IdentityProvider interface {
+	// Name 返回身份提供方名称
+	Name() string
+	// Authenticate 验证令牌并返回身份信息
+	Authenticate(ctx context.Context, token string) (*Identity, error)
+}
+
+// ProfileFetcher 可选接口，支持按外部用户 ID 拉取完整资料的身份提供方实现此接口
+// 会话令牌通常只包含少量声明，首次自动创建本地用户时用它补全邮箱、姓名等资料
+type ProfileFetcher interface {
+	FetchProfile(ctx context.Context, subject string) (*Identity, error)
+}
+
+// NewIdentityProvider 根据配置创建身份提供方
+//
+// 参数:
+//   - cfg: 应用配置对象
+//
+// 返回:
+//   - IdentityProvider: 身份提供方实例
+//   - error: 配置缺失或无效时返回错误
+//
+// 说明:
+//
+//	未配置 auth.provider 时默认使用 Clerk。
+//	使用 jwks 时不依赖 Clerk 账号，可在离线环境中启动服务。
+func NewIdentityProvider(cfg *config.Config) (IdentityProvider, error) {
+	switch cfg.Auth.Provider {
+	case "", ProviderClerk:
+		return NewClerkProvider(&cfg.Clerk)
+	case ProviderJWKS:
+		return NewJWKSProvider(&cfg.Auth.JWKS)
+	default:
+		return nil, fmt.Errorf("不支持的身份提供方: %s", cfg.Auth.Provider)
+	}
+}
+
+// BearerToken 从 Authorization 头中提取 Bearer 令牌
+func BearerToken(authorization string) string {
+	authorization = strings.TrimSpace(authorization)
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(authorization[7:])
+}
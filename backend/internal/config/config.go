@@ -1,76 +1,115 @@
 // Package config 提供应用配置管理功能
-// 负责加载、解析和管理应用的所有配置项，支持从配置文件和环境变量加载配置
+// 负责加载、解析和管理应用的所有配置项，按优先级合并内置默认值、配置文件、环境变量、
+// 参数存储和命令行参数
 package config
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"log"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
-	"github.com/spf13/viper"
 )
 
 // Config 应用配置结构
-// 包含应用运行所需的所有配置信息
+// 包含应用运行所需的所有配置信息。带有 reload:"true" 标签的字段可以在运行时重新加载，
+// 其余字段（包括密钥和连接配置）只在启动时读取，修改后需要重启。
+// 带有 secret:"true" 标签的字段为密钥，格式化输出、JSON 编码和配置查看接口中都会被隐藏
 type Config struct {
-	App        AppConfig        `mapstructure:"app"`        // 基础应用配置
-	Database   DatabaseConfig   `mapstructure:"database"`   // 数据库配置
-	Redis      RedisConfig      `mapstructure:"redis"`      // Redis 配置
-	Clerk      ClerkConfig      `mapstructure:"clerk"`      // Clerk 认证配置
-	Middleware MiddlewareConfig `mapstructure:"middleware"` // 中间件配置
-	OpenAI     OpenAIConfig     `mapstructure:"openai"`     // OpenAI 配置
-	Anthropic  AnthropicConfig  `mapstructure:"anthropic"`  // Anthropic 配置
-	CORS       CORSConfig       `mapstructure:"cors"`       // CORS 配置
+	App        AppConfig        `mapstructure:"app"`                      // 基础应用配置
+	Server     ServerConfig     `mapstructure:"server"`                   // HTTP 服务器配置
+	Database   DatabaseConfig   `mapstructure:"database"`                 // 数据库配置
+	Redis      RedisConfig      `mapstructure:"redis"`                    // Redis 配置
+	Clerk      ClerkConfig      `mapstructure:"clerk"`                    // Clerk 认证配置
+	Auth       AuthConfig       `mapstructure:"auth"`                     // 身份认证配置
+	Middleware MiddlewareConfig `mapstructure:"middleware" reload:"true"` // 中间件配置
+	OpenAI     OpenAIConfig     `mapstructure:"openai"`                   // OpenAI 配置
+	Anthropic  AnthropicConfig  `mapstructure:"anthropic"`                // Anthropic 配置
+	Features   map[string]bool  `mapstructure:"features" reload:"true"`   // 功能开关
 }
 
 // AppConfig 应用基础配置
 type AppConfig struct {
-	Env     string `mapstructure:"env"`      // 运行环境（development/production）
-	Name    string `mapstructure:"name"`     // 应用名称
-	Version string `mapstructure:"version"`  // 应用版本
-	Mode    string `mapstructure:"mode"`     // 运行模式（debug/release）
-	Port    int    `mapstructure:"port"`     // 服务端口
-	BaseURL string `mapstructure:"base_url"` // 基础 URL
-	Region  string `mapstructure:"region"`   // 运行区域（hk/us）
+	Env            string        `mapstructure:"env" validate:"required,oneof=development staging production test"` // 运行环境（development/production）
+	Name           string        `mapstructure:"name"`                                                              // 应用名称
+	Version        string        `mapstructure:"version"`                                                           // 应用版本
+	Mode           string        `mapstructure:"mode" validate:"required,oneof=debug release test"`                 // 运行模式（debug/release）
+	Port           int           `mapstructure:"port" validate:"min=1,max=65535"`                                   // 服务端口
+	BaseURL        string        `mapstructure:"base_url" validate:"omitempty,url"`                                 // 基础 URL
+	Region         string        `mapstructure:"region" validate:"required,oneof=hk us"`                            // 运行区域（hk/us），需要与 AWS_REGION 对应
+	ReloadInterval time.Duration `mapstructure:"reload_interval" validate:"min=0"`                                  // 运行时配置的刷新间隔，为 0 时只在收到 SIGHUP 时刷新
+}
+
+// ServerConfig HTTP 服务器配置
+// 超时为 0 表示不限制。关闭时先等待 DrainDelay 让负载均衡摘除实例，再最多等待 ShutdownTimeout
+// 让进行中的请求完成，两者之和需要小于编排系统的停止等待时间（ECS 默认 30 秒）
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout" validate:"min=0"` // 读取请求头的超时时间
+	ReadTimeout       time.Duration `mapstructure:"read_timeout" validate:"min=0"`        // 读取整个请求的超时时间
+	WriteTimeout      time.Duration `mapstructure:"write_timeout" validate:"min=0"`       // 写出响应的超时时间，需要覆盖耗时最长的 AI 生成请求
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout" validate:"min=0"`        // 保持连接的空闲超时时间
+	DrainDelay        time.Duration `mapstructure:"drain_delay" validate:"min=0"`         // 收到停止信号后就绪检查返回 503、继续接受请求的时长
+	ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout" validate:"min=0"`    // 等待进行中的请求和后台任务完成的最长时间
 }
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
-	Driver          string `mapstructure:"driver"`            // 数据库驱动
-	Host            string `mapstructure:"host"`              // 数据库主机
-	Port            int    `mapstructure:"port"`              // 数据库端口
-	Name            string `mapstructure:"name"`              // 数据库名称
-	User            string `mapstructure:"user"`              // 数据库用户
-	Password        string `mapstructure:"password"`          // 数据库密码
-	SSLMode         string `mapstructure:"ssl_mode"`          // SSL 模式
-	SSLTunnel       bool   `mapstructure:"ssl_tunnel"`        // 是否使用 SSL 隧道
-	MaxIdleConns    int    `mapstructure:"max_idle_conns"`    // 最大空闲连接数
-	MaxOpenConns    int    `mapstructure:"max_open_conns"`    // 最大打开连接数
-	ConnMaxLifetime string `mapstructure:"conn_max_lifetime"` // 连接最大生命周期
+	Driver          string `mapstructure:"driver" validate:"omitempty,oneof=postgres sqlite"`                                      // 数据库驱动，sqlite 只用于本地开发
+	Host            string `mapstructure:"host" validate:"required_unless=Driver sqlite,omitempty,hostname_rfc1123|ip"`            // 数据库主机，sqlite 不需要
+	Port            int    `mapstructure:"port" validate:"min=1,max=65535"`                                                        // 数据库端口
+	Name            string `mapstructure:"name" validate:"required"`                                                               // 数据库名称，sqlite 为数据库文件路径
+	User            string `mapstructure:"user" validate:"required_unless=Driver sqlite"`                                          // 数据库用户，sqlite 不需要
+	Password        string `mapstructure:"password" secret:"true"`                                                                 // 数据库密码
+	SSLMode         string `mapstructure:"ssl_mode" validate:"omitempty,oneof=disable allow prefer require verify-ca verify-full"` // SSL 模式
+	SSLTunnel       bool   `mapstructure:"ssl_tunnel"`                                                                             // 是否使用 SSL 隧道
+	MaxIdleConns    int    `mapstructure:"max_idle_conns" validate:"min=0"`                                                        // 最大空闲连接数
+	MaxOpenConns    int    `mapstructure:"max_open_conns" validate:"min=0"`                                                        // 最大打开连接数
+	ConnMaxLifetime string `mapstructure:"conn_max_lifetime" validate:"required,duration"`                                         // 连接最大生命周期
+
+	Replicas              []string      `mapstructure:"replicas" validate:"dive,required" secret:"true"` // 只读副本的连接字符串，为空时全部查询使用主库，仅支持 postgres
+	ReplicaStickiness     time.Duration `mapstructure:"replica_stickiness" validate:"min=0"`             // 用户写入后继续从主库读取的时长，用于规避副本延迟
+	ReplicaHealthInterval time.Duration `mapstructure:"replica_health_interval" validate:"min=0"`        // 副本健康检查间隔，检查失败的副本暂停使用
 }
 
 // RedisConfig Redis 配置
 type RedisConfig struct {
-	Host        string `mapstructure:"host"`          // Redis 主机
-	Port        int    `mapstructure:"port"`          // Redis 端口
-	Password    string `mapstructure:"password"`      // Redis 密码
-	DB          int    `mapstructure:"db"`            // Redis 数据库编号
-	SSLTunnel   bool   `mapstructure:"ssl_tunnel"`    // 是否使用 SSL 隧道
-	TLSEnable   bool   `mapstructure:"tls_enable"`    // 是否启用 TLS
-	TLSCertFile string `mapstructure:"tls_cert_file"` // TLS 证书文件
-	TLSKeyFile  string `mapstructure:"tls_key_file"`  // TLS 密钥文件
-	TLSCAFile   string `mapstructure:"tls_ca_file"`   // TLS CA 证书文件
+	Host                  string `mapstructure:"host" validate:"required,hostname_rfc1123|ip"` // Redis 主机
+	Port                  int    `mapstructure:"port" validate:"min=1,max=65535"`              // Redis 端口
+	Password              string `mapstructure:"password" secret:"true"`                       // Redis 密码
+	DB                    int    `mapstructure:"db" validate:"min=0,max=15"`                   // Redis 数据库编号
+	SSLTunnel             bool   `mapstructure:"ssl_tunnel"`                                   // 是否使用 SSL 隧道
+	TLSEnable             bool   `mapstructure:"tls_enable"`                                   // 是否启用 TLS
+	TLSCertFile           string `mapstructure:"tls_cert_file" validate:"omitempty,file"`      // TLS 证书文件
+	TLSKeyFile            string `mapstructure:"tls_key_file" validate:"omitempty,file"`       // TLS 密钥文件
+	TLSCAFile             string `mapstructure:"tls_ca_file" validate:"omitempty,file"`        // TLS CA 证书文件
+	TLSServerName         string `mapstructure:"tls_server_name"`                              // 校验证书使用的服务器名称，默认为 host
+	TLSInsecureSkipVerify bool   `mapstructure:"tls_insecure_skip_verify"`                     // 跳过服务器证书校验，只能用于本地开发
 }
 
 // ClerkConfig Clerk 认证配置
 type ClerkConfig struct {
-	APIKey      string `mapstructure:"api_key"`      // Clerk API 密钥
-	FrontendAPI string `mapstructure:"frontend_api"` // Clerk 前端 API 密钥
-	WebhookKey  string `mapstructure:"webhook_key"`  // Webhook 密钥
+	APIKey      string `mapstructure:"api_key" secret:"true"`                                            // Clerk API 密钥
+	FrontendAPI string `mapstructure:"frontend_api"`                                                     // Clerk 前端 API 密钥
+	WebhookKey  string `mapstructure:"webhook_key" validate:"omitempty,startswith=whsec_" secret:"true"` // Webhook 密钥
+}
+
+// AuthConfig 身份认证配置
+type AuthConfig struct {
+	Provider string     `mapstructure:"provider" validate:"omitempty,oneof=clerk jwks"` // 身份提供方（clerk/jwks），默认 clerk
+	JWKS     JWKSConfig `mapstructure:"jwks"`                                           // 通用 JWKS/OIDC 配置
+}
+
+// JWKSConfig 通用 JWKS/OIDC 身份提供方配置
+type JWKSConfig struct {
+	Issuer          string        `mapstructure:"issuer" validate:"omitempty,url"`   // 令牌签发者，需与 iss 声明一致
+	Audience        string        `mapstructure:"audience"`                          // 期望的 aud 声明，为空时不校验
+	URL             string        `mapstructure:"url" validate:"omitempty,url"`      // JWKS 地址，为空时通过 OIDC discovery 获取
+	File            string        `mapstructure:"file" validate:"omitempty,file"`    // 本地 JWKS 文件，优先于 URL
+	RefreshInterval time.Duration `mapstructure:"refresh_interval" validate:"min=0"` // 公钥集刷新间隔
+	Leeway          time.Duration `mapstructure:"leeway" validate:"min=0"`           // 允许的时钟偏差
 }
 
 // MiddlewareConfig 中间件配置
@@ -80,128 +119,203 @@ type MiddlewareConfig struct {
 }
 
 // RateLimitConfig 速率限制配置
+// 顶层字段为默认规则，未认证请求按 IP 使用默认规则，
+// 已认证请求按用户或 API 密钥计数，并依次使用路由组和订阅计划的覆盖规则
 type RateLimitConfig struct {
-	Enabled  bool          `mapstructure:"enabled"`  // 是否启用速率限制
-	Limit    int           `mapstructure:"limit"`    // 限制次数
-	Duration time.Duration `mapstructure:"duration"` // 限制时间窗口
+	Enabled   bool          `mapstructure:"enabled"`                                                          // 是否启用速率限制
+	Algorithm string        `mapstructure:"algorithm" validate:"omitempty,oneof=sliding_window token_bucket"` // 限流算法：sliding_window（默认）或 token_bucket
+	Limit     int           `mapstructure:"limit" validate:"min=0"`                                           // 限制次数
+	Duration  time.Duration `mapstructure:"duration" validate:"min=0"`                                        // 限制时间窗口
+	Burst     int           `mapstructure:"burst" validate:"min=0"`                                           // 令牌桶容量，为 0 时等于 limit
+
+	Plans  map[string]RateLimitRule `mapstructure:"plans" validate:"dive"`  // 按订阅计划覆盖默认规则，未订阅的用户使用 free
+	Routes []RouteRateLimitConfig   `mapstructure:"routes" validate:"dive"` // 按路由组覆盖规则，路由组使用独立的计数
+	PerIP  RateLimitRule            `mapstructure:"per_ip"`                 // 认证之前对全部请求按客户端 IP 的限制，limit 为 0 时不启用
+}
+
+// RateLimitRule 限流规则，为零值的字段沿用上一级规则
+type RateLimitRule struct {
+	Algorithm string        `mapstructure:"algorithm" validate:"omitempty,oneof=sliding_window token_bucket"` // 限流算法
+	Limit     int           `mapstructure:"limit" validate:"min=0"`                                           // 限制次数
+	Duration  time.Duration `mapstructure:"duration" validate:"min=0"`                                        // 限制时间窗口
+	Burst     int           `mapstructure:"burst" validate:"min=0"`                                           // 令牌桶容量
+}
+
+// RouteRateLimitConfig 路由组限流规则
+type RouteRateLimitConfig struct {
+	Name          string                   `mapstructure:"name" validate:"required"`                                             // 路由组名称，作为计数键的一部分
+	Prefix        string                   `mapstructure:"prefix" validate:"required,startswith=/"`                              // 匹配的路由前缀，例如 /v1/user/profile，多个匹配时取最长的
+	Methods       []string                 `mapstructure:"methods" validate:"dive,oneof=GET HEAD POST PUT PATCH DELETE OPTIONS"` // 匹配的请求方法，为空表示全部
+	RateLimitRule `mapstructure:",squash"` // 路由组的默认规则
+	Plans         map[string]RateLimitRule `mapstructure:"plans" validate:"dive"` // 路由组内按订阅计划覆盖的规则
 }
 
 // CORSConfig CORS 配置
+// 列表字段为空时使用默认值，未配置允许的源时按运行环境选择默认的源
 type CORSConfig struct {
-	AllowOrigins     []string `mapstructure:"allow_origins"`     // 允许的源
-	AllowMethods     []string `mapstructure:"allow_methods"`     // 允许的方法
-	AllowHeaders     []string `mapstructure:"allow_headers"`     // 允许的头部
-	ExposeHeaders    []string `mapstructure:"expose_headers"`    // 暴露的头部
-	AllowCredentials bool     `mapstructure:"allow_credentials"` // 是否允许凭证
-	MaxAge           int      `mapstructure:"max_age"`           // 预检请求缓存时间
+	AllowOrigins       []string `mapstructure:"allow_origins"`            // 允许的源，支持 https://*.example.com 形式的通配符，允许凭证时不能使用
+	VercelTeam         string   `mapstructure:"vercel_team"`              // Vercel 团队标识，未配置允许的源时用于限定允许的预览部署域名
+	AllowMethods       []string `mapstructure:"allow_methods"`            // 允许的方法
+	AllowHeaders       []string `mapstructure:"allow_headers"`            // 允许的头部
+	ExposeHeaders      []string `mapstructure:"expose_headers"`           // 暴露的头部
+	AllowCredentials   bool     `mapstructure:"allow_credentials"`        // 是否允许凭证
+	EchoRequestHeaders bool     `mapstructure:"echo_request_headers"`     // 预检请求是否回显请求的头部，而不是使用 allow_headers
+	MaxAge             int      `mapstructure:"max_age" validate:"min=0"` // 预检请求缓存时间（秒）
 }
 
 // OpenAIConfig OpenAI 配置
 type OpenAIConfig struct {
-	APIKey       string  `mapstructure:"api_key"`      // OpenAI API 密钥
-	Organization string  `mapstructure:"organization"` // OpenAI 组织 ID
-	Model        string  `mapstructure:"model"`        // 使用的模型
-	MaxTokens    int     `mapstructure:"max_tokens"`   // 最大 token 数
-	Temperature  float64 `mapstructure:"temperature"`  // 温度参数
+	APIKey       string  `mapstructure:"api_key" secret:"true"`                            // OpenAI API 密钥
+	Organization string  `mapstructure:"organization"`                                     // OpenAI 组织 ID
+	Model        string  `mapstructure:"model" reload:"true"`                              // 使用的模型
+	MaxTokens    int     `mapstructure:"max_tokens" validate:"min=0" reload:"true"`        // 最大 token 数
+	Temperature  float64 `mapstructure:"temperature" validate:"min=0,max=2" reload:"true"` // 温度参数
 }
 
 // AnthropicConfig Anthropic 配置
 type AnthropicConfig struct {
-	APIKey      string  `mapstructure:"api_key"`     // Anthropic API 密钥
-	Model       string  `mapstructure:"model"`       // 使用的模型
-	MaxTokens   int     `mapstructure:"max_tokens"`  // 最大 token 数
-	Temperature float64 `mapstructure:"temperature"` // 温度参数
+	APIKey      string  `mapstructure:"api_key" secret:"true"`                            // Anthropic API 密钥
+	Model       string  `mapstructure:"model" reload:"true"`                              // 使用的模型
+	MaxTokens   int     `mapstructure:"max_tokens" validate:"min=0" reload:"true"`        // 最大 token 数
+	Temperature float64 `mapstructure:"temperature" validate:"min=0,max=1" reload:"true"` // 温度参数
 }
 
-var cfg *Config
+// FeatureEnabled 判断功能开关是否开启，未配置的功能视为关闭
+func (c *Config) FeatureEnabled(name string) bool {
+	return c.Features[name]
+}
 
-// LoadConfig 加载配置
-func LoadConfig() (*Config, error) {
-	v := viper.New()
+// DefaultConfigFile 默认的 YAML 配置文件
+const DefaultConfigFile = "configs/config.yaml"
 
-	// 设置基本配置
-	v.SetConfigName("config")
-	v.SetConfigType("yaml")
-	v.AddConfigPath("configs")
+// Options 配置加载选项，通常来自命令行参数
+type Options struct {
+	ConfigFile     string   // YAML 配置文件，为空时使用 DefaultConfigFile，且文件不存在时跳过
+	ParameterStore string   // 本地参数存储（目录或文件），设置后代替 AWS SSM
+	DisableSSM     bool     // 是否跳过参数存储
+	Overrides      []string // 命令行覆盖的配置值，格式为 key=value
 
-	// 绑定环境变量
-	bindEnvs(v, "")
+	// RequireParameterStore AWS SSM 加载失败时返回错误而不是跳过
+	// 重新加载配置时使用，避免临时故障导致可重新加载的配置回退到文件和环境变量中的值
+	RequireParameterStore bool
+}
 
-	// 首先尝试从 SSM 加载配置
-	cfg, err := LoadFromSSM(context.Background())
-	if err == nil {
-		return cfg, nil
-	}
+// flagOptions 通过 BindFlags 绑定的加载选项
+var flagOptions Options
 
-	// 如果从 SSM 加载失败，回退到本地配置文件
-	log.Printf("从 SSM 加载配置失败，尝试从本地文件加载: %v", err)
-	return loadLocalConfig()
+// overridesFlag 可重复的 --set 参数
+type overridesFlag struct {
+	values *[]string
 }
 
-// loadLocalConfig 从本地文件加载配置
-func loadLocalConfig() (*Config, error) {
-	if cfg != nil {
-		return cfg, nil
+// String 返回参数的当前值
+func (f overridesFlag) String() string {
+	if f.values == nil {
+		return ""
 	}
+	return strings.Join(*f.values, ",")
+}
 
-	// 加载 .env 文件
-	if err := loadEnv(); err != nil {
-		return nil, fmt.Errorf("加载 .env 文件失败: %w", err)
+// Set 追加一个覆盖的配置值
+func (f overridesFlag) Set(value string) error {
+	*f.values = append(*f.values, value)
+	return nil
+}
+
+// BindFlags 在命令行参数集中注册配置加载参数
+//
+// 参数:
+//   - fs: 命令行参数集
+//
+// 说明:
+//
+//	注册 --config、--ssm-local、--no-ssm 和可重复的 --set key=value，
+//	解析后的值通过 FlagOptions 获取
+func BindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&flagOptions.ConfigFile, "config", "", "YAML 配置文件，默认为 "+DefaultConfigFile)
+	fs.StringVar(&flagOptions.ParameterStore, "ssm-local", "", "使用本地目录或文件代替 AWS SSM 参数存储")
+	fs.BoolVar(&flagOptions.DisableSSM, "no-ssm", false, "不从参数存储加载配置")
+	fs.Var(overridesFlag{values: &flagOptions.Overrides}, "set", "覆盖配置值，格式为 key=value，可重复使用")
+}
+
+// NewDefaultLoader 创建按默认优先级加载配置的加载器
+//
+// 参数:
+//   - opts: 加载选项
+//
+// 返回:
+//   - *Loader: 配置加载器
+//
+// 说明:
+//
+//	优先级从低到高依次为：内置默认值、YAML 配置文件、环境变量、参数存储、命令行参数。
+//	参数存储的路径由 APP_ENV 和 AWS_REGION 决定；使用 AWS SSM 时加载失败只记录日志，
+//	设置 RequireParameterStore 或使用本地参数存储时加载失败会返回错误。
+func NewDefaultLoader(opts Options) *Loader {
+	loader := NewLoader().Add(DefaultsSource())
+	if opts.ConfigFile != "" {
+		loader.Add(FileSource(opts.ConfigFile))
+	} else {
+		loader.AddOptional(FileSource(DefaultConfigFile))
 	}
+	loader.Add(EnvSource())
 
-	// 设置配置文件
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("configs")
-
-	// 启用环境变量替换
-	viper.AutomaticEnv()
-	viper.SetEnvPrefix("")
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	viper.AllowEmptyEnv(true)
-
-	// 绑定应用配置环境变量
-	viper.BindEnv("app.env", "APP_ENV")
-	viper.BindEnv("app.mode", "APP_MODE")
-	viper.BindEnv("app.port", "APP_PORT")
-	viper.BindEnv("app.base_url", "APP_BASE_URL")
-
-	// 绑定数据库配置环境变量
-	viper.BindEnv("database.host", "DB_HOST")
-	viper.BindEnv("database.port", "DB_PORT")
-	viper.BindEnv("database.name", "DB_NAME")
-	viper.BindEnv("database.user", "DB_USER")
-	viper.BindEnv("database.password", "DB_PASSWORD")
-	viper.BindEnv("database.ssl_mode", "DB_SSL_MODE")
-	viper.BindEnv("database.ssl_tunnel", "DB_SSL_TUNNEL")
-
-	// 绑定 Clerk 配置环境变量
-	viper.BindEnv("clerk.api_key", "CLERK_API_KEY")
-	viper.BindEnv("clerk.frontend_api", "CLERK_FRONTEND_API")
-	viper.BindEnv("clerk.webhook_key", "CLERK_WEBHOOK_KEY")
-
-	// 读取配置文件
-	if err := viper.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "development"
+	}
+	paramPath := ssmPath(env, awsRegion())
+	switch {
+	case opts.DisableSSM:
+	case opts.ParameterStore != "":
+		loader.Add(SSMSource(NewLocalParameterStore(opts.ParameterStore), paramPath))
+	case opts.RequireParameterStore:
+		loader.Add(SSMSource(NewAWSParameterStore(awsRegion()), paramPath))
+	default:
+		loader.AddOptional(SSMSource(NewAWSParameterStore(awsRegion()), paramPath))
 	}
 
-	// 替换配置文件中的环境变量
-	for _, key := range viper.AllKeys() {
-		val := viper.GetString(key)
-		if strings.HasPrefix(val, "${") && strings.HasSuffix(val, "}") {
-			envKey := strings.TrimSuffix(strings.TrimPrefix(val, "${"), "}")
-			if envVal := os.Getenv(envKey); envVal != "" {
-				viper.Set(key, envVal)
-			}
-		}
+	return loader.Add(OverrideSource(opts.Overrides))
+}
+
+// FlagOptions 返回通过 BindFlags 绑定的加载选项，需要在解析命令行参数之后调用
+func FlagOptions() Options {
+	opts := flagOptions
+	opts.Overrides = append([]string(nil), flagOptions.Overrides...)
+	return opts
+}
+
+// Load 加载配置
+//
+// 参数:
+//   - ctx: 上下文
+//   - opts: 加载选项
+//
+// 返回:
+//   - *Config: 校验通过的配置
+//   - Trace: 每个配置键的来源
+//   - error: 加载失败时返回错误，校验失败时返回 *ValidationError
+//
+// 说明:
+//
+//	先加载 .env 文件中的环境变量，再使用 NewDefaultLoader 按优先级合并各配置来源。
+//	每次调用都返回新的配置，不会修改任何包级状态。
+func Load(ctx context.Context, opts Options) (*Config, Trace, error) {
+	// 加载 .env 文件，其中的变量作为环境变量参与加载
+	if err := loadEnv(); err != nil {
+		return nil, nil, fmt.Errorf("加载 .env 文件失败: %w", err)
 	}
 
-	// 解析配置到结构体
-	if err := viper.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	cfg, sources, err := NewDefaultLoader(opts).Load(ctx)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return cfg, nil
+	// 校验配置，任何问题都会阻止启动
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, sources, nil
 }
 
 // loadEnv 加载环境变量文件
@@ -236,25 +350,6 @@ func loadEnv() error {
 	return nil
 }
 
-// bindEnvs 递归绑定环境变量
-//
-// 参数:
-//   - v: Viper 实例
-//   - prefix: 环境变量前缀
-//
-// 说明:
-//
-//	该函数将配置键绑定到对应的环境变量
-func bindEnvs(v *viper.Viper, prefix string) {
-	for _, key := range v.AllKeys() {
-		envKey := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
-		if prefix != "" {
-			envKey = prefix + "_" + envKey
-		}
-		_ = v.BindEnv(key, envKey)
-	}
-}
-
 // GetDSN 获取数据库连接字符串
 //
 // 返回:
@@ -262,15 +357,30 @@ func bindEnvs(v *viper.Viper, prefix string) {
 //
 // 说明:
 //
-//	该方法根据配置生成 PostgreSQL 数据库连接字符串
+//	该方法根据配置生成 PostgreSQL 数据库连接字符串，sqlite 驱动返回数据库文件路径。
+//	返回值包含明文密码，只能用于建立连接，日志和错误信息中请使用 RedactedDSN
 func (c *DatabaseConfig) GetDSN() string {
+	return c.dsn(c.Password)
+}
+
+// RedactedDSN 获取隐藏了密码的数据库连接字符串，用于日志和错误信息
+func (c *DatabaseConfig) RedactedDSN() string {
+	return c.dsn(redactString(c.Password))
+}
+
+// dsn 使用指定的密码生成数据库连接字符串
+func (c *DatabaseConfig) dsn(password string) string {
+	if c.Driver == "sqlite" {
+		return c.Name
+	}
+
 	sslMode := "disable"
 	if c.SSLMode != "" {
 		sslMode = c.SSLMode
 	}
 
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		c.Host, c.Port, c.User, c.Password, c.Name, sslMode)
+		c.Host, c.Port, c.User, password, c.Name, sslMode)
 }
 
 // GetRedisAddr 获取 Redis 连接地址
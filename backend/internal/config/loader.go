@@ -0,0 +1,162 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Setting 配置来源提供的单个配置值
+type Setting struct {
+	Value  interface{} // 配置值，字符串会按目标字段类型转换
+	Origin string      // 配置值的具体来源，例如 env DB_HOST
+}
+
+// Source 配置来源
+// Load 返回以配置键（例如 database.host）为键的配置值，未提供的键不应出现在结果中
+type Source interface {
+	Name() string
+	Load(ctx context.Context) (map[string]Setting, error)
+}
+
+// Trace 记录每个配置键最终生效的来源，未出现的键使用字段零值
+type Trace map[string]string
+
+// Keys 返回排序后的配置键
+func (t Trace) Keys() []string {
+	keys := make([]string, 0, len(t))
+	for key := range t {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// layer 加载器中的一层配置来源
+type layer struct {
+	source   Source
+	optional bool // 为 true 时加载失败只记录日志
+}
+
+// Loader 分层配置加载器
+// 按添加顺序依次加载配置来源，后添加的来源覆盖先添加的来源
+type Loader struct {
+	layers []layer
+}
+
+// NewLoader 创建分层配置加载器
+func NewLoader() *Loader {
+	return &Loader{}
+}
+
+// Add 添加配置来源，加载失败时整个加载过程失败
+func (l *Loader) Add(source Source) *Loader {
+	l.layers = append(l.layers, layer{source: source})
+	return l
+}
+
+// AddOptional 添加可选的配置来源，加载失败时跳过该来源
+func (l *Loader) AddOptional(source Source) *Loader {
+	l.layers = append(l.layers, layer{source: source, optional: true})
+	return l
+}
+
+// Load 加载并合并所有配置来源
+//
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - *Config: 合并后的配置，尚未校验
+//   - Trace: 每个配置键最终生效的来源
+//   - error: 加载或解析过程中的错误
+func (l *Loader) Load(ctx context.Context) (*Config, Trace, error) {
+	merged := make(map[string]Setting)
+	for _, layer := range l.layers {
+		settings, err := layer.source.Load(ctx)
+		if err != nil {
+			if layer.optional {
+				log.Printf("跳过配置来源 %s: %v", layer.source.Name(), err)
+				continue
+			}
+			return nil, nil, fmt.Errorf("加载配置来源 %s 失败: %w", layer.source.Name(), err)
+		}
+		for key, setting := range settings {
+			merged[key] = setting
+		}
+	}
+
+	v := viper.New()
+	trace := make(Trace, len(merged))
+	for key, setting := range merged {
+		v.Set(key, setting.Value)
+		trace[key] = setting.Origin
+	}
+
+	cfg := &Config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	return cfg, trace, nil
+}
+
+// configKeys 所有配置键及其字段类型，映射和结构体切片作为一个整体的配置键
+var configKeys = collectKeys(reflect.TypeOf(Config{}), "", map[string]reflect.Type{})
+
+// collectKeys 根据 mapstructure 标签收集配置键
+func collectKeys(t reflect.Type, prefix string, keys map[string]reflect.Type) map[string]reflect.Type {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if opts == "squash" {
+			collectKeys(field.Type, prefix, keys)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + name
+		if field.Type.Kind() == reflect.Struct {
+			collectKeys(field.Type, key+".", keys)
+			continue
+		}
+		keys[key] = field.Type
+	}
+	return keys
+}
+
+// isScalarKey 判断配置键能否使用单个字符串表示
+// 字符串切片使用逗号分隔，映射和结构体切片只能来自配置文件或 SSM 文档
+func isScalarKey(key string) bool {
+	t, ok := configKeys[key]
+	if !ok {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return false
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	default:
+		return true
+	}
+}
+
+// flatten 将嵌套的配置映射展开为配置键，忽略未知的键
+func flatten(values map[string]interface{}, prefix, origin string, out map[string]Setting) {
+	for name, value := range values {
+		key := prefix + strings.ToLower(name)
+		if _, ok := configKeys[key]; ok {
+			out[key] = Setting{Value: value, Origin: origin}
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(nested, key+".", origin, out)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// localParameterStore 本地参数存储，用于离线测试参数存储的加载路径
+type localParameterStore struct {
+	root string
+}
+
+// NewLocalParameterStore 创建本地参数存储
+//
+// 参数:
+//   - root: 目录或 YAML/JSON 文件
+//
+// 说明:
+//
+//	root 为目录时，每个文件对应一个参数，文件路径即参数名，例如
+//	root/nicheflow/development/hk/database/host 对应参数 /nicheflow/development/hk/database/host，
+//	文件内容末尾的换行会被去掉。
+//	root 为文件时，文件内容为参数名到参数值的映射，参数名使用完整路径，例如:
+//
+//	/nicheflow/development/hk/database/host: 127.0.0.1
+//	/nicheflow/development/hk/database/port: "5432"
+func NewLocalParameterStore(root string) ParameterStore {
+	return &localParameterStore{root: root}
+}
+
+// GetParametersByPath 获取指定路径下的所有参数
+func (s *localParameterStore) GetParametersByPath(ctx context.Context, paramPath string) (map[string]string, error) {
+	info, err := os.Stat(s.root)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return s.readDir(paramPath)
+	}
+	return s.readFile(paramPath)
+}
+
+// readDir 从目录读取参数
+func (s *localParameterStore) readDir(paramPath string) (map[string]string, error) {
+	params := make(map[string]string)
+	dir := filepath.Join(s.root, filepath.FromSlash(strings.TrimPrefix(paramPath, "/")))
+	err := filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		params[filepath.ToSlash(name)] = strings.TrimRight(string(content), "\r\n")
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取本地参数目录失败: %w", err)
+	}
+	return params, nil
+}
+
+// readFile 从 YAML/JSON 文件读取参数
+func (s *localParameterStore) readFile(paramPath string) (map[string]string, error) {
+	// 参数名中包含点和斜杠，使用不会出现在参数名中的分隔符，避免被拆分为嵌套键
+	v := viper.NewWithOptions(viper.KeyDelimiter("\x00"))
+	v.SetConfigFile(s.root)
+	if ext := strings.TrimPrefix(path.Ext(s.root), "."); ext != "json" {
+		v.SetConfigType("yaml")
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取本地参数文件失败: %w", err)
+	}
+
+	prefix := strings.TrimSuffix(paramPath, "/") + "/"
+	params := make(map[string]string)
+	for _, name := range v.AllKeys() {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			params[rest] = v.GetString(name)
+		}
+	}
+	return params, nil
+}
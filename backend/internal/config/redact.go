@@ -0,0 +1,225 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RedactedValue 密钥被隐藏后显示的值，未设置的密钥仍显示为空字符串
+const RedactedValue = "******"
+
+// Entry 配置查看接口中的单个配置项
+type Entry struct {
+	Key        string      `json:"key"`                  // 配置键，例如 database.host
+	Value      interface{} `json:"value"`                // 生效的值，密钥已被隐藏
+	Source     string      `json:"source,omitempty"`     // 值的来源，为空表示没有来源设置该键，使用零值
+	Secret     bool        `json:"secret,omitempty"`     // 是否为密钥
+	Reloadable bool        `json:"reloadable,omitempty"` // 是否可以在运行时重新加载
+}
+
+// Entries 返回隐藏密钥后的全部配置项
+//
+// 参数:
+//   - trace: 每个配置键的来源
+//
+// 返回:
+//   - []Entry: 按配置键排序的配置项
+func (c *Config) Entries(trace Trace) []Entry {
+	var entries []Entry
+	collectEntries(reflect.ValueOf(c).Elem(), "", trace, &entries)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// collectEntries 按 mapstructure 标签收集配置项
+func collectEntries(v reflect.Value, prefix string, trace Trace, entries *[]Entry) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if opts == "squash" {
+			collectEntries(v.Field(i), prefix, trace, entries)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := prefix + name
+		if field.Type.Kind() == reflect.Struct {
+			collectEntries(v.Field(i), key+".", trace, entries)
+			continue
+		}
+
+		secret := field.Tag.Get("secret") == "true"
+		value := plainValue(v.Field(i))
+		if secret {
+			value = plainValue(redactField(v.Field(i)))
+		}
+		*entries = append(*entries, Entry{
+			Key:        key,
+			Value:      value,
+			Source:     trace[key],
+			Secret:     secret,
+			Reloadable: isReloadableKey(key),
+		})
+	}
+}
+
+// plainValue 将配置值转换为便于输出的形式
+// 结构体转换为以配置键命名的映射，时长转换为字符串，密钥字段被隐藏
+func plainValue(v reflect.Value) interface{} {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(v.Int()).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		plainStruct(v, out)
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return []interface{}{}
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = plainValue(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = plainValue(iter.Value())
+		}
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+// plainStruct 将结构体字段写入以配置键命名的映射
+func plainStruct(v reflect.Value, out map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		switch {
+		case opts == "squash":
+			plainStruct(v.Field(i), out)
+		case name == "" || name == "-":
+		case field.Tag.Get("secret") == "true":
+			out[name] = plainValue(redactField(v.Field(i)))
+		default:
+			out[name] = plainValue(v.Field(i))
+		}
+	}
+}
+
+// redactString 隐藏非空的密钥
+func redactString(value string) string {
+	if value == "" {
+		return ""
+	}
+	return RedactedValue
+}
+
+// redactField 返回隐藏了密钥后的字段值，支持字符串和字符串切片
+func redactField(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Slice {
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).SetString(redactString(v.Index(i).String()))
+		}
+		return out
+	}
+	return reflect.ValueOf(redactString(v.String())).Convert(v.Type())
+}
+
+// redactedCopy 返回隐藏了密钥字段的结构体副本
+func redactedCopy(value interface{}) interface{} {
+	v := reflect.New(reflect.TypeOf(value)).Elem()
+	v.Set(reflect.ValueOf(value))
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Tag.Get("secret") == "true" {
+			v.Field(i).Set(redactField(v.Field(i)))
+		}
+	}
+	return v.Interface()
+}
+
+// formatRedacted 按原有的格式化动词输出隐藏了密钥字段的结构体
+// value 必须是没有 Format 方法的结构体类型，否则会无限递归
+func formatRedacted(f fmt.State, verb rune, value interface{}) {
+	fmt.Fprintf(f, fmt.FormatString(f, verb), redactedCopy(value))
+}
+
+// Format 格式化输出数据库配置，密码会被隐藏
+func (c DatabaseConfig) Format(f fmt.State, verb rune) {
+	type plain DatabaseConfig
+	formatRedacted(f, verb, plain(c))
+}
+
+// MarshalJSON 编码数据库配置，密码会被隐藏
+func (c DatabaseConfig) MarshalJSON() ([]byte, error) {
+	type plain DatabaseConfig
+	return json.Marshal(redactedCopy(plain(c)))
+}
+
+// Format 格式化输出 Redis 配置，密码会被隐藏
+func (c RedisConfig) Format(f fmt.State, verb rune) {
+	type plain RedisConfig
+	formatRedacted(f, verb, plain(c))
+}
+
+// MarshalJSON 编码 Redis 配置，密码会被隐藏
+func (c RedisConfig) MarshalJSON() ([]byte, error) {
+	type plain RedisConfig
+	return json.Marshal(redactedCopy(plain(c)))
+}
+
+// Format 格式化输出 Clerk 配置，密钥会被隐藏
+func (c ClerkConfig) Format(f fmt.State, verb rune) {
+	type plain ClerkConfig
+	formatRedacted(f, verb, plain(c))
+}
+
+// MarshalJSON 编码 Clerk 配置，密钥会被隐藏
+func (c ClerkConfig) MarshalJSON() ([]byte, error) {
+	type plain ClerkConfig
+	return json.Marshal(redactedCopy(plain(c)))
+}
+
+// Format 格式化输出 OpenAI 配置，API 密钥会被隐藏
+func (c OpenAIConfig) Format(f fmt.State, verb rune) {
+	type plain OpenAIConfig
+	formatRedacted(f, verb, plain(c))
+}
+
+// MarshalJSON 编码 OpenAI 配置，API 密钥会被隐藏
+func (c OpenAIConfig) MarshalJSON() ([]byte, error) {
+	type plain OpenAIConfig
+	return json.Marshal(redactedCopy(plain(c)))
+}
+
+// Format 格式化输出 Anthropic 配置，API 密钥会被隐藏
+func (c AnthropicConfig) Format(f fmt.State, verb rune) {
+	type plain AnthropicConfig
+	formatRedacted(f, verb, plain(c))
+}
+
+// MarshalJSON 编码 Anthropic 配置，API 密钥会被隐藏
+func (c AnthropicConfig) MarshalJSON() ([]byte, error) {
+	type plain AnthropicConfig
+	return json.Marshal(redactedCopy(plain(c)))
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// defaults 内置默认值，优先级最低
+var defaults = map[string]interface{}{
+	"app.name":            "NicheFlow",
+	"app.env":             "development",
+	"app.mode":            "debug",
+	"app.port":            8080,
+	"app.reload_interval": 5 * time.Minute,
+
+	"server.read_header_timeout": 10 * time.Second,
+	"server.read_timeout":        30 * time.Second,
+	"server.write_timeout":       2 * time.Minute,
+	"server.idle_timeout":        2 * time.Minute,
+	"server.drain_delay":         5 * time.Second,
+	"server.shutdown_timeout":    20 * time.Second,
+
+	"database.driver":                  "postgres",
+	"database.port":                    5432,
+	"database.max_idle_conns":          10,
+	"database.max_open_conns":          100,
+	"database.conn_max_lifetime":       "1h",
+	"database.replica_stickiness":      5 * time.Second,
+	"database.replica_health_interval": 10 * time.Second,
+
+	"redis.port": 6379,
+	"redis.db":   0,
+
+	"auth.provider":              "clerk",
+	"auth.jwks.refresh_interval": 10 * time.Minute,
+	"auth.jwks.leeway":           30 * time.Second,
+
+	"openai.model":       "gpt-4-turbo-preview",
+	"openai.max_tokens":  2000,
+	"openai.temperature": 0.7,
+
+	"anthropic.model":       "claude-3-opus",
+	"anthropic.max_tokens":  2000,
+	"anthropic.temperature": 0.7,
+
+	"middleware.rate_limit.enabled":   true,
+	"middleware.rate_limit.algorithm": "sliding_window",
+	"middleware.rate_limit.limit":     100,
+	"middleware.rate_limit.duration":  time.Minute,
+
+	"middleware.rate_limit.per_ip.limit":    300,
+	"middleware.rate_limit.per_ip.duration": time.Minute,
+
+	"middleware.cors.allow_credentials": true,
+	"middleware.cors.max_age":           300,
+}
+
+// defaultsSource 内置默认值
+type defaultsSource struct{}
+
+// DefaultsSource 返回内置默认值配置来源
+func DefaultsSource() Source {
+	return defaultsSource{}
+}
+
+// Name 返回来源名称
+func (defaultsSource) Name() string {
+	return "default"
+}
+
+// Load 返回内置默认值
+func (defaultsSource) Load(ctx context.Context) (map[string]Setting, error) {
+	settings := make(map[string]Setting, len(defaults))
+	for key, value := range defaults {
+		settings[key] = Setting{Value: value, Origin: "default"}
+	}
+	return settings, nil
+}
+
+// fileSource YAML 配置文件
+type fileSource struct {
+	path string
+}
+
+// FileSource 返回 YAML 配置文件来源
+//
+// 说明:
+//
+//	文件中的 ${VAR} 和 ${VAR:-default} 会在解析前替换为环境变量的值，
+//	环境变量未设置或为空时使用 default，没有 default 时替换为空字符串
+func FileSource(path string) Source {
+	return fileSource{path: path}
+}
+
+// Name 返回来源名称
+func (s fileSource) Name() string {
+	return "file " + s.path
+}
+
+// Load 读取并解析配置文件
+func (s fileSource) Load(ctx context.Context) (map[string]Setting, error) {
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(expandEnv(string(content)))); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+
+	settings := make(map[string]Setting)
+	flatten(v.AllSettings(), "", s.Name(), settings)
+	return settings, nil
+}
+
+// envPattern 匹配 ${VAR} 和 ${VAR:-default}
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnv 替换文本中的环境变量引用
+func expandEnv(content string) string {
+	return envPattern.ReplaceAllStringFunc(content, func(ref string) string {
+		match := envPattern.FindStringSubmatch(ref)
+		if value := os.Getenv(match[1]); value != "" {
+			return value
+		}
+		return match[2]
+	})
+}
+
+// envAliases 兼容的环境变量名称，标准名称为配置键转为大写并以下划线分隔，例如 DATABASE_HOST
+var envAliases = map[string]string{
+	"openai.organization": "OPENAI_ORG_ID",
+}
+
+// envAliasPrefixes 按配置键前缀生成的兼容名称，例如 database.host 也可以使用 DB_HOST
+var envAliasPrefixes = map[string]string{
+	"database.": "DB_",
+}
+
+// envSource 环境变量
+type envSource struct {
+	lookup func(string) (string, bool)
+}
+
+// EnvSource 返回环境变量配置来源
+//
+// 说明:
+//
+//	每个配置键对应的环境变量为键名转为大写并以下划线分隔，例如 middleware.cors.max_age
+//	对应 MIDDLEWARE_CORS_MAX_AGE；字符串列表使用逗号分隔。空值视为未设置。
+//	标准名称未设置时再查找兼容名称，例如 DB_HOST 和 OPENAI_ORG_ID。
+func EnvSource() Source {
+	return envSource{lookup: os.LookupEnv}
+}
+
+// Name 返回来源名称
+func (envSource) Name() string {
+	return "env"
+}
+
+// Load 读取环境变量
+func (s envSource) Load(ctx context.Context) (map[string]Setting, error) {
+	settings := make(map[string]Setting)
+	for key := range configKeys {
+		if !isScalarKey(key) {
+			continue
+		}
+		for _, name := range envNames(key) {
+			if value, ok := s.lookup(name); ok && value != "" {
+				settings[key] = Setting{Value: value, Origin: "env " + name}
+				break
+			}
+		}
+	}
+	return settings, nil
+}
+
+// envNames 返回配置键对应的环境变量名称，按优先级排序
+func envNames(key string) []string {
+	names := []string{strings.ToUpper(strings.ReplaceAll(key, ".", "_"))}
+	if alias, ok := envAliases[key]; ok {
+		names = append(names, alias)
+	}
+	for prefix, alias := range envAliasPrefixes {
+		if rest, ok := strings.CutPrefix(key, prefix); ok {
+			names = append(names, alias+strings.ToUpper(strings.ReplaceAll(rest, ".", "_")))
+		}
+	}
+	return names
+}
+
+// overrideSource 命令行参数指定的配置值
+type overrideSource struct {
+	overrides []string
+}
+
+// OverrideSource 返回命令行覆盖配置来源，每一项的格式为 key=value
+func OverrideSource(overrides []string) Source {
+	return overrideSource{overrides: overrides}
+}
+
+// Name 返回来源名称
+func (overrideSource) Name() string {
+	return "flag"
+}
+
+// Load 解析命令行覆盖的配置值
+func (s overrideSource) Load(ctx context.Context) (map[string]Setting, error) {
+	settings := make(map[string]Setting, len(s.overrides))
+	for _, override := range s.overrides {
+		key, value, ok := strings.Cut(override, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return nil, fmt.Errorf("无效的配置覆盖 %q，格式应为 key=value", override)
+		}
+		if !isScalarKey(key) {
+			return nil, fmt.Errorf("配置键 %q 不存在或不支持在命令行中设置", key)
+		}
+		settings[key] = Setting{Value: value, Origin: "flag --set " + key}
+	}
+	return settings, nil
+}
@@ -5,56 +5,38 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/spf13/viper"
 )
 
-// LoadFromSSM 从 AWS SSM Parameter Store 加载配置
-func LoadFromSSM(ctx context.Context) (*Config, error) {
-	// 获取 AWS 区域
-	region := os.Getenv("AWS_REGION")
-	if region == "" {
-		region = "ap-east-1" // 默认使用香港区域
-	}
-
-	// 获取环境
-	env := os.Getenv("APP_ENV")
-	if env == "" {
-		env = "development" // 默认使用开发环境
-	}
+// ParameterStore 参数存储
+// GetParametersByPath 返回指定路径下的所有参数，参数名为去掉路径前缀后的相对路径，例如 database/host
+type ParameterStore interface {
+	GetParametersByPath(ctx context.Context, path string) (map[string]string, error)
+}
 
-	// 获取区域代码
-	regionCode := getRegionCode(region)
+// awsParameterStore AWS SSM Parameter Store
+type awsParameterStore struct {
+	region string
+}
 
-	// 构建参数路径
-	paramPath := fmt.Sprintf("/nicheflow/%s/%s", env, regionCode)
+// NewAWSParameterStore 创建 AWS SSM Parameter Store，凭证使用 AWS SDK 的默认来源
+func NewAWSParameterStore(region string) ParameterStore {
+	return &awsParameterStore{region: region}
+}
 
+// GetParametersByPath 获取指定路径下的所有参数
+func (s *awsParameterStore) GetParametersByPath(ctx context.Context, paramPath string) (map[string]string, error) {
 	// 创建 AWS 会话
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion(region),
-	)
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(s.region))
 	if err != nil {
 		return nil, fmt.Errorf("无法加载 AWS 配置: %v", err)
 	}
-
-	// 创建 SSM 客户端
 	client := ssm.NewFromConfig(cfg)
 
-	// 获取所有参数
-	params, err := getAllParameters(ctx, client, paramPath)
-	if err != nil {
-		return nil, fmt.Errorf("获取参数失败: %v", err)
-	}
-
-	// 转换为配置结构
-	return paramsToConfig(params), nil
-}
-
-// getAllParameters 获取指定路径下的所有参数
-func getAllParameters(ctx context.Context, client *ssm.Client, paramPath string) (map[string]string, error) {
 	params := make(map[string]string)
 	var nextToken *string
 
@@ -86,82 +68,74 @@ func getAllParameters(ctx context.Context, client *ssm.Client, paramPath string)
 	return params, nil
 }
 
-// paramsToConfig 将参数映射转换为配置结构
-func paramsToConfig(params map[string]string) *Config {
-	cfg := &Config{}
-
-	// 应用配置
-	cfg.App.Name = params["app/name"]
-	cfg.App.Version = params["app/version"]
-	cfg.App.Env = params["app/env"]
-	cfg.App.Mode = params["app/mode"]
-	cfg.App.Port = getIntOrDefault(params["app/port"], 80)
-	cfg.App.BaseURL = params["app/base_url"]
-	cfg.App.Region = params["app/region"]
-
-	// 数据库配置
-	cfg.Database.Driver = params["database/driver"]
-	cfg.Database.Host = params["database/host"]
-	cfg.Database.Port = getIntOrDefault(params["database/port"], 5432)
-	cfg.Database.Name = params["database/name"]
-	cfg.Database.User = params["database/user"]
-	cfg.Database.Password = params["database/password"]
-	cfg.Database.SSLMode = params["database/ssl_mode"]
-	cfg.Database.MaxIdleConns = getIntOrDefault(params["database/max_idle_conns"], 10)
-	cfg.Database.MaxOpenConns = getIntOrDefault(params["database/max_open_conns"], 100)
-	cfg.Database.SSLTunnel = params["database/ssl_tunnel"] == "true"
-
-	// Redis 配置
-	cfg.Redis.Host = params["redis/host"]
-	cfg.Redis.Port = getIntOrDefault(params["redis/port"], 6379)
-	cfg.Redis.Password = params["redis/password"]
-	cfg.Redis.DB = getIntOrDefault(params["redis/db"], 0)
-	cfg.Redis.SSLTunnel = params["redis/ssl_tunnel"] == "true"
-	cfg.Redis.TLSEnable = params["redis/tls_enable"] == "true"
-
-	// Clerk 配置
-	cfg.Clerk.APIKey = params["clerk/api_key"]
-	cfg.Clerk.FrontendAPI = params["clerk/frontend_api"]
-	cfg.Clerk.WebhookKey = params["clerk/webhook_key"]
-
-	// OpenAI 配置
-	cfg.OpenAI.APIKey = params["openai/api_key"]
-	cfg.OpenAI.Organization = params["openai/org_id"]
-	cfg.OpenAI.Model = "gpt-4-turbo-preview"
-	cfg.OpenAI.MaxTokens = 2000
-	cfg.OpenAI.Temperature = 0.7
-
-	// Anthropic 配置
-	cfg.Anthropic.APIKey = params["anthropic/api_key"]
-	cfg.Anthropic.Model = "claude-3-opus"
-	cfg.Anthropic.MaxTokens = 2000
-	cfg.Anthropic.Temperature = 0.7
-
-	// 中间件配置
-	cfg.Middleware.RateLimit.Enabled = true
-	cfg.Middleware.RateLimit.Limit = 100
-	cfg.Middleware.RateLimit.Duration = time.Minute
-
-	cfg.Middleware.CORS.AllowOrigins = []string{
-		"https://getnicheflow.com",
-		"https://www.getnicheflow.com",
-		"http://localhost:3000", // 开发环境
-	}
-	cfg.Middleware.CORS.AllowMethods = []string{
-		"GET",
-		"POST",
-		"PUT",
-		"DELETE",
-		"OPTIONS",
+// ssmParamAliases 与配置键名称不一致的参数
+var ssmParamAliases = map[string]string{
+	"openai/org_id": "openai.organization",
+}
+
+// ssmDocumentParams 值为 YAML 或 JSON 文档的参数，以及文档中对应的配置键
+var ssmDocumentParams = map[string]map[string]string{
+	"middleware/rate_limit/policies": {
+		"plans":  "middleware.rate_limit.plans",
+		"routes": "middleware.rate_limit.routes",
+	},
+	"features": {
+		"": "features",
+	},
+}
+
+// ssmSource 参数存储中的配置
+type ssmSource struct {
+	store ParameterStore
+	path  string
+}
+
+// SSMSource 返回参数存储配置来源
+//
+// 说明:
+//
+//	参数名为配置键将点替换为斜杠，例如 /nicheflow/production/hk/database/host
+//	对应 database.host；字符串列表使用逗号分隔。未知的参数会被忽略。
+func SSMSource(store ParameterStore, path string) Source {
+	return ssmSource{store: store, path: strings.TrimSuffix(path, "/")}
+}
+
+// Name 返回来源名称
+func (s ssmSource) Name() string {
+	return "ssm " + s.path
+}
+
+// Load 读取参数存储中的配置
+func (s ssmSource) Load(ctx context.Context) (map[string]Setting, error) {
+	params, err := s.store.GetParametersByPath(ctx, s.path)
+	if err != nil {
+		return nil, fmt.Errorf("获取参数失败: %w", err)
 	}
-	cfg.Middleware.CORS.AllowHeaders = []string{
-		"Authorization",
-		"Content-Type",
-		"X-Clerk-User-Id",
+
+	settings := make(map[string]Setting, len(params))
+	for name, value := range params {
+		origin := "ssm " + s.path + "/" + name
+		if keys, ok := ssmDocumentParams[name]; ok {
+			if err := parseDocumentParam(value, origin, keys, settings); err != nil {
+				return nil, fmt.Errorf("解析参数 %s 失败: %w", name, err)
+			}
+			continue
+		}
+
+		key, ok := ssmParamAliases[name]
+		if !ok {
+			key = strings.ReplaceAll(name, "/", ".")
+		}
+		if isScalarKey(key) {
+			settings[key] = Setting{Value: value, Origin: origin}
+		}
 	}
-	cfg.Middleware.CORS.MaxAge = 300
+	return settings, nil
+}
 
-	return cfg
+// ssmPath 返回当前运行环境和区域对应的参数路径，例如 /nicheflow/production/hk
+func ssmPath(env, region string) string {
+	return fmt.Sprintf("/nicheflow/%s/%s", env, getRegionCode(region))
 }
 
 // getRegionCode 获取区域代码
@@ -176,12 +150,46 @@ func getRegionCode(region string) string {
 	}
 }
 
-// getIntOrDefault 获取整数值或默认值
-func getIntOrDefault(value string, defaultValue int) int {
+// awsRegion 返回 AWS 区域，默认使用香港区域
+func awsRegion() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return "ap-east-1"
+}
+
+// parseDocumentParam 解析值为 YAML 或 JSON 文档的参数
+//
+// 说明:
+//
+//	keys 为文档中的字段到配置键的映射，空字段表示整个文档。
+//	例如限流策略参数的结构与 config.yaml 中 middleware.rate_limit 的 plans 和 routes 相同:
+//
+//	plans:
+//	  pro: {limit: 600}
+//	routes:
+//	  - name: generate
+//	    prefix: /v1/generate
+//	    limit: 10
+//
+//	功能开关参数为功能名称到是否开启的映射，例如 {"new_editor": true}
+func parseDocumentParam(value, origin string, keys map[string]string, settings map[string]Setting) error {
 	if value == "" {
-		return defaultValue
+		return nil
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(value)); err != nil {
+		return err
+	}
+
+	for field, key := range keys {
+		if field == "" {
+			settings[key] = Setting{Value: v.AllSettings(), Origin: origin}
+		} else if v.IsSet(field) {
+			settings[key] = Setting{Value: v.Get(field), Origin: origin}
+		}
 	}
-	var result int
-	fmt.Sscanf(value, "%d", &result)
-	return result
+	return nil
 }
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Problem 单个配置问题
+type Problem struct {
+	Field   string // 配置键，例如 database.host
+	Message string // 问题描述
+}
+
+// ValidationError 配置校验错误，包含全部问题
+type ValidationError struct {
+	Problems []Problem
+}
+
+// Error 返回包含全部问题的可读报告
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "配置校验失败，共 %d 个问题:", len(e.Problems))
+	for _, p := range e.Problems {
+		fmt.Fprintf(&b, "\n  - %s: %s", p.Field, p.Message)
+	}
+	return b.String()
+}
+
+// add 记录一个问题
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Problems = append(e.Problems, Problem{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// newValidator 创建使用配置键作为字段名的校验器
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "" || name == "-" {
+			return ""
+		}
+		return name
+	})
+	_ = v.RegisterValidation("duration", func(fl validator.FieldLevel) bool {
+		_, err := time.ParseDuration(fl.Field().String())
+		return err == nil
+	})
+	return v
+}
+
+// Validate 校验配置
+//
+// 返回:
+//   - error: 存在问题时返回 *ValidationError，其中包含全部问题；配置有效时返回 nil
+//
+// 说明:
+//
+//	先按结构体上的 validate 标签逐字段校验，再检查字段之间的依赖关系，
+//	例如使用 Clerk 时必须配置 API 密钥。所有问题会一次性返回，便于部署前集中修正。
+func (c *Config) Validate() error {
+	report := &ValidationError{}
+
+	if err := newValidator().Struct(c); err != nil {
+		var fieldErrors validator.ValidationErrors
+		if !errors.As(err, &fieldErrors) {
+			return err
+		}
+		for _, fe := range fieldErrors {
+			report.add(fieldKey(fe), "%s", describe(fe))
+		}
+	}
+
+	c.validateDependencies(report)
+
+	if len(report.Problems) > 0 {
+		return report
+	}
+	return nil
+}
+
+// validateDependencies 检查字段之间的依赖关系
+func (c *Config) validateDependencies(report *ValidationError) {
+	// 参数存储的路径按 AWS_REGION 选择区域，两者不一致时会读到其他区域的配置
+	if region := os.Getenv("AWS_REGION"); region != "" && (c.App.Region == "hk" || c.App.Region == "us") {
+		if code := getRegionCode(region); code != c.App.Region {
+			report.add("app.region", "与 AWS_REGION=%s 对应的区域 %s 不一致，当前值为 %q", region, code, c.App.Region)
+		}
+	}
+
+	switch c.Auth.Provider {
+	case "", "clerk":
+		if c.Clerk.APIKey == "" {
+			report.add("clerk.api_key", "使用 Clerk 身份提供方时不能为空")
+		}
+	case "jwks":
+		if c.Auth.JWKS.Issuer == "" && c.Auth.JWKS.URL == "" && c.Auth.JWKS.File == "" {
+			report.add("auth.jwks", "使用 jwks 身份提供方时必须设置 issuer、url 或 file 之一")
+		}
+	}
+
+	if c.Redis.TLSEnable && (c.Redis.TLSCertFile == "") != (c.Redis.TLSKeyFile == "") {
+		report.add("redis.tls_cert_file", "tls_cert_file 和 tls_key_file 必须同时设置")
+	}
+	if c.Redis.TLSInsecureSkipVerify {
+		if c.Redis.TLSCAFile != "" {
+			report.add("redis.tls_insecure_skip_verify", "跳过证书校验时 tls_ca_file 不会生效，请删除其中一项")
+		}
+		if c.App.Env == "production" {
+			report.add("redis.tls_insecure_skip_verify", "生产环境不能跳过 Redis 证书校验")
+		}
+	}
+
+	rl := c.Middleware.RateLimit
+	if rl.Enabled {
+		if rl.Limit <= 0 {
+			report.add("middleware.rate_limit.limit", "启用速率限制时必须大于 0")
+		}
+		if rl.Duration <= 0 {
+			report.add("middleware.rate_limit.duration", "启用速率限制时必须大于 0")
+		}
+	}
+	cors := c.Middleware.CORS
+	if cors.AllowCredentials {
+		for _, origin := range cors.AllowOrigins {
+			if strings.Contains(origin, "*") {
+				report.add("middleware.cors.allow_origins", "启用 allow_credentials 时不能使用 * 或通配符源 %q，请列出具体的源", strings.TrimSpace(origin))
+				break
+			}
+		}
+	}
+	if strings.Trim(cors.VercelTeam, "abcdefghijklmnopqrstuvwxyz0123456789-") != "" {
+		report.add("middleware.cors.vercel_team", "只能包含小写字母、数字和 -，当前值为 %q", cors.VercelTeam)
+	}
+
+	names := make(map[string]bool, len(rl.Routes))
+	for i, route := range rl.Routes {
+		if names[route.Name] {
+			report.add(fmt.Sprintf("middleware.rate_limit.routes[%d].name", i), "路由组名称 %q 重复", route.Name)
+		}
+		names[route.Name] = true
+	}
+}
+
+// fieldKey 将校验器的字段路径转换为配置键
+func fieldKey(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if _, rest, ok := strings.Cut(ns, "."); ok {
+		return rest
+	}
+	return ns
+}
+
+// describe 将校验错误转换为中文描述
+func describe(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "不能为空"
+	case "required_unless":
+		return "不能为空（使用 sqlite 驱动时除外）"
+	case "oneof":
+		return fmt.Sprintf("必须是以下值之一: %s，当前值为 %q", fe.Param(), fmt.Sprint(fe.Value()))
+	case "min":
+		return fmt.Sprintf("不能小于 %s，当前值为 %v", fe.Param(), fe.Value())
+	case "max":
+		return fmt.Sprintf("不能大于 %s，当前值为 %v", fe.Param(), fe.Value())
+	case "url":
+		return fmt.Sprintf("必须是有效的 URL，当前值为 %q", fmt.Sprint(fe.Value()))
+	case "file":
+		return fmt.Sprintf("文件 %q 不存在", fmt.Sprint(fe.Value()))
+	case "duration":
+		return fmt.Sprintf("必须是有效的时长（例如 30s、5m、1h），当前值为 %q", fmt.Sprint(fe.Value()))
+	case "startswith":
+		return fmt.Sprintf("必须以 %q 开头", fe.Param())
+	case "hostname_rfc1123", "ip", "hostname_rfc1123|ip":
+		return fmt.Sprintf("必须是有效的主机名或 IP 地址，当前值为 %q", fmt.Sprint(fe.Value()))
+	default:
+		return fmt.Sprintf("不满足 %s 规则", fe.Tag())
+	}
+}
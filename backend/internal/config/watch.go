@@ -0,0 +1,266 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Watcher 运行时配置监视器
+// 定期或在收到 SIGHUP 时重新加载配置，只替换带有 reload:"true" 标签的字段，
+// 并把新配置推送给订阅者。其余字段保持启动时的值，修改后需要重启才能生效。
+type Watcher struct {
+	load    func(ctx context.Context) (*Config, Trace, error) // 加载并校验完整配置
+	current atomic.Pointer[snapshot]                          // 当前生效的配置，替换后不再修改
+
+	reloadMu sync.Mutex // 串行化重新加载
+
+	subMu       sync.Mutex
+	subscribers map[int]Subscriber
+	nextID      int
+}
+
+// Subscriber 配置变更的订阅者
+// 根据新配置准备需要替换的状态并返回提交函数，新配置不可用时返回错误。
+// 所有订阅者都准备成功后才会替换当前配置并依次调用提交函数，任一订阅者返回错误时全部放弃
+type Subscriber func(*Config) (commit func(), err error)
+
+// snapshot 配置及其来源
+type snapshot struct {
+	config  *Config
+	sources Trace
+}
+
+// NewWatcher 创建运行时配置监视器
+//
+// 参数:
+//   - initial: 启动时加载的配置
+//   - sources: 启动时加载的配置来源
+//   - load: 加载并校验完整配置的函数，返回配置及其来源；
+//     提供运行时配置的来源（例如参数存储）加载失败时必须返回错误，不能跳过该来源
+//
+// 返回:
+//   - *Watcher: 配置监视器
+func NewWatcher(initial *Config, sources Trace, load func(ctx context.Context) (*Config, Trace, error)) *Watcher {
+	w := &Watcher{
+		load:        load,
+		subscribers: make(map[int]Subscriber),
+	}
+	w.current.Store(&snapshot{config: initial, sources: sources})
+	return w
+}
+
+// Current 返回当前生效的配置
+// 返回的配置不能被修改，需要最新值的调用方应在每次使用时调用 Current
+func (w *Watcher) Current() *Config {
+	return w.current.Load().config
+}
+
+// Sources 返回当前生效的配置中每个配置键的来源
+func (w *Watcher) Sources() Trace {
+	return w.current.Load().sources
+}
+
+// Subscribe 订阅配置变更
+//
+// 参数:
+//   - fn: 订阅者，参数为待替换的完整配置
+//
+// 返回:
+//   - func(): 取消订阅
+//
+// 说明:
+//
+//	订阅者在重新加载的协程中按顺序同步调用，不应长时间阻塞。
+//	准备阶段不能修改订阅者正在使用的状态，只能在提交函数中替换。
+func (w *Watcher) Subscribe(fn Subscriber) func() {
+	w.subMu.Lock()
+	defer w.subMu.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.subscribers[id] = fn
+
+	return func() {
+		w.subMu.Lock()
+		defer w.subMu.Unlock()
+		delete(w.subscribers, id)
+	}
+}
+
+// Reload 重新加载配置
+//
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - bool: 可重新加载的字段是否有变化
+//   - error: 加载、校验或任一订阅者准备失败时返回错误，此时当前配置和订阅者都保持不变
+//
+// 说明:
+//
+//	新配置校验通过且所有订阅者都接受后才会替换当前配置。只需要重启才能生效的字段发生变化时只记录日志。
+func (w *Watcher) Reload(ctx context.Context) (bool, error) {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	loaded, loadedSources, err := w.load(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	current := w.current.Load()
+	next := *current.config
+	restartOnly := applyReloadable(reflect.ValueOf(&next).Elem(), reflect.ValueOf(loaded).Elem(), "")
+	if len(restartOnly) > 0 {
+		log.Printf("以下配置已修改，需要重启才能生效: %s", strings.Join(restartOnly, ", "))
+	}
+	if reflect.DeepEqual(&next, current.config) {
+		return false, nil
+	}
+
+	// 合并后的配置再校验一次，保证替换进去的配置始终有效
+	if err := next.Validate(); err != nil {
+		return false, err
+	}
+
+	w.subMu.Lock()
+	subscribers := make([]Subscriber, 0, len(w.subscribers))
+	for _, fn := range w.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	w.subMu.Unlock()
+
+	// 先让所有订阅者准备，全部成功后再替换配置，被拒绝的配置不会出现在 Current 中
+	commits := make([]func(), 0, len(subscribers))
+	for _, fn := range subscribers {
+		commit, err := fn(&next)
+		if err != nil {
+			return false, err
+		}
+		commits = append(commits, commit)
+	}
+
+	w.current.Store(&snapshot{config: &next, sources: mergeSources(current.sources, loadedSources)})
+	for _, commit := range commits {
+		commit()
+	}
+	return true, nil
+}
+
+// Run 运行配置监视器，直到 ctx 结束
+//
+// 参数:
+//   - ctx: 上下文
+//   - interval: 定期刷新间隔，为 0 时只在收到 SIGHUP 时刷新
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Println("收到 SIGHUP，重新加载配置")
+		case <-tick:
+		}
+
+		changed, err := w.Reload(ctx)
+		if err != nil {
+			log.Printf("重新加载配置失败，继续使用当前配置: %v", err)
+			continue
+		}
+		if changed {
+			log.Println("运行时配置已更新")
+		}
+	}
+}
+
+// applyReloadable 将 src 中可重新加载的字段复制到 dst
+// 返回发生变化但需要重启才能生效的配置键
+func applyReloadable(dst, src reflect.Value, prefix string) []string {
+	var restartOnly []string
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		key := prefix + name
+
+		switch {
+		case field.Tag.Get("reload") == "true":
+			dst.Field(i).Set(src.Field(i))
+		case field.Type.Kind() == reflect.Struct:
+			nested := key + "."
+			if opts == "squash" {
+				nested = prefix
+			}
+			restartOnly = append(restartOnly, applyReloadable(dst.Field(i), src.Field(i), nested)...)
+		case !reflect.DeepEqual(dst.Field(i).Interface(), src.Field(i).Interface()):
+			restartOnly = append(restartOnly, key)
+		}
+	}
+	return restartOnly
+}
+
+// reloadableKeys 可重新加载的配置键前缀
+var reloadableKeys = collectReloadable(reflect.TypeOf(Config{}), "", nil)
+
+// collectReloadable 根据 reload 标签收集可重新加载的配置键
+func collectReloadable(t reflect.Type, prefix string, keys []string) []string {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		switch {
+		case field.Tag.Get("reload") == "true":
+			keys = append(keys, prefix+name)
+		case field.Type.Kind() != reflect.Struct:
+		case opts == "squash":
+			keys = collectReloadable(field.Type, prefix, keys)
+		default:
+			keys = collectReloadable(field.Type, prefix+name+".", keys)
+		}
+	}
+	return keys
+}
+
+// isReloadableKey 判断配置键能否在运行时重新加载
+func isReloadableKey(key string) bool {
+	for _, prefix := range reloadableKeys {
+		if key == prefix || strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSources 合并配置来源，可重新加载的配置键使用新的来源，其余保持启动时的来源
+func mergeSources(current, loaded Trace) Trace {
+	merged := make(Trace, len(current))
+	for key, origin := range current {
+		if !isReloadableKey(key) {
+			merged[key] = origin
+		}
+	}
+	for key, origin := range loaded {
+		if isReloadableKey(key) {
+			merged[key] = origin
+		}
+	}
+	return merged
+}
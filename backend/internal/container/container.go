@@ -0,0 +1,116 @@
+// Package container 提供应用依赖的组装
+// 在一处创建数据库连接、Redis 客户端和业务服务，并显式传递给处理器和中间件，
+// 不依赖任何包级全局变量，同一进程中可以存在多个互不影响的容器
+package container
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+	"github.com/yszaryszar/NicheFlow/backend/internal/service"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/cache"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/database"
+	"gorm.io/gorm"
+)
+
+// Container 应用依赖容器
+// 持有一个应用实例使用的全部外部连接和业务服务
+type Container struct {
+	Config   *config.Watcher   // 运行时配置监视器
+	DB       *gorm.DB          // 数据库连接
+	Redis    *redis.Client     // Redis 客户端，为 nil 时缓存和限流退化为进程内实现
+	Cache    *cache.Cache      // 基于 Redis 的 JSON 缓存
+	Services *service.Services // 业务服务
+
+	draining atomic.Bool // 实例是否正在关闭
+	unwatch  func()      // 取消业务配置对运行时配置的订阅
+}
+
+// New 使用已建立的连接创建依赖容器
+//
+// 参数:
+//   - watcher: 运行时配置监视器
+//   - db: 数据库连接
+//   - rdb: Redis 客户端，可以为 nil
+//
+// 返回:
+//   - *Container: 依赖容器
+//
+// 说明:
+//
+//	主要用于测试或嵌入场景，调用方可以传入独立的数据库（例如 SQLite 内存库）。
+//	通过 New 创建的容器调用 Close 时同样会关闭传入的连接。
+//	AI 模型参数和功能开关随运行时配置更新。
+func New(watcher *config.Watcher, db *gorm.DB, rdb *redis.Client) *Container {
+	c := cache.New(rdb)
+	services := service.NewServices(watcher.Current(), db, c)
+	return &Container{
+		Config:   watcher,
+		DB:       db,
+		Redis:    rdb,
+		Cache:    c,
+		Services: services,
+		unwatch:  services.Settings.Watch(watcher),
+	}
+}
+
+// Open 按配置建立数据库和 Redis 连接并创建依赖容器
+//
+// 参数:
+//   - watcher: 运行时配置监视器，连接参数取自当前配置
+//
+// 返回:
+//   - *Container: 依赖容器
+//   - error: 任一连接失败时返回错误，已建立的连接会被关闭
+func Open(watcher *config.Watcher) (*Container, error) {
+	cfg := watcher.Current()
+
+	db, err := database.Open(&cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("初始化数据库失败: %w", err)
+	}
+
+	rdb, err := cache.NewRedisClient(&cfg.Redis)
+	if err != nil {
+		database.Close(db)
+		return nil, fmt.Errorf("初始化 Redis 失败: %w", err)
+	}
+
+	return New(watcher, db, rdb), nil
+}
+
+// StartDraining 标记实例开始关闭，之后就绪检查返回 503，负载均衡不再转发新请求
+func (c *Container) StartDraining() {
+	c.draining.Store(true)
+}
+
+// Draining 返回实例是否正在关闭
+func (c *Container) Draining() bool {
+	return c.draining.Load()
+}
+
+// Close 关闭容器持有的数据库和 Redis 连接
+//
+// 返回:
+//   - error: 关闭过程中的错误，多个错误会被合并
+//
+// 说明:
+//
+//	先取消配置订阅，再关闭数据库和 Redis，调用前应确保没有进行中的请求和后台任务
+func (c *Container) Close() error {
+	c.unwatch()
+
+	var errs []error
+	if err := database.Close(c.DB); err != nil {
+		errs = append(errs, fmt.Errorf("关闭数据库失败: %w", err))
+	}
+	if c.Redis != nil {
+		if err := c.Redis.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("关闭 Redis 失败: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
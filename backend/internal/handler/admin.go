@@ -0,0 +1,619 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yszaryszar/NicheFlow/backend/internal/auth"
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"github.com/yszaryszar/NicheFlow/backend/internal/service"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/response"
+	"gorm.io/gorm"
+)
+
+// PageData 分页数据
+type PageData struct {
+	Items    interface{} `json:"items"`     // 当前页数据
+	Total    int64       `json:"total"`     // 总数
+	Page     int         `json:"page"`      // 页码
+	PageSize int         `json:"page_size"` // 每页数量
+}
+
+// AssignRoleRequest 分配角色请求
+type AssignRoleRequest struct {
+	Role   string `json:"role" binding:"required"`           // 角色名称
+	Reason string `json:"reason" binding:"required,max=255"` // 操作原因
+}
+
+// UpdateUserStatusRequest 修改用户状态请求
+type UpdateUserStatusRequest struct {
+	Status string     `json:"status" binding:"required,oneof=active suspended banned pending_deletion"` // 新状态
+	Until  *time.Time `json:"until"`                                                                    // 暂停截止时间，仅暂停时可用，为空表示无限期
+	Reason string     `json:"reason" binding:"required,max=255"`                                        // 操作原因
+}
+
+// UpdateUsageLimitsRequest 调整使用限制请求
+type UpdateUsageLimitsRequest struct {
+	service.UsageLimits
+	Reason string `json:"reason" binding:"required,max=255"` // 操作原因
+}
+
+// AdminActionRequest 只需要填写原因的管理操作请求
+type AdminActionRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"` // 操作原因
+}
+
+// StartImpersonationRequest 发起模拟登录请求
+type StartImpersonationRequest struct {
+	UserID     uint     `json:"user_id" binding:"required"`                   // 被模拟的用户 ID
+	Scopes     []string `json:"scopes"`                                       // 权限范围，默认只读
+	TTLMinutes int      `json:"ttl_minutes" binding:"omitempty,min=1,max=60"` // 有效期（分钟），默认 15
+	Reason     string   `json:"reason" binding:"required,max=255"`            // 发起原因，例如工单编号
+}
+
+// StartImpersonationResponse 发起模拟登录响应
+type StartImpersonationResponse struct {
+	Session *model.ImpersonationSession `json:"session"` // 会话记录
+	Token   string                      `json:"token"`   // 模拟登录令牌，仅返回一次
+}
+
+// AdminHandler 处理管理员相关的 HTTP 请求
+type AdminHandler struct {
+	userService  *service.UserService
+	adminService *service.AdminService
+	rbacService  *service.RBACService
+	auditService *service.AuditService
+	impService   *service.ImpersonationService
+	identity     auth.IdentityProvider
+	watcher      *config.Watcher
+}
+
+// NewAdminHandler 创建一个新的管理员处理器实例
+//
+// 参数:
+//   - services: 业务服务集合
+//   - identity: 身份提供方，用于强制重新同步用户资料
+//   - watcher: 配置监视器，用于查看当前生效的配置
+func NewAdminHandler(services *service.Services, identity auth.IdentityProvider, watcher *config.Watcher) *AdminHandler {
+	return &AdminHandler{
+		userService:  services.Users,
+		adminService: services.Admin,
+		rbacService:  services.RBAC,
+		auditService: services.Audit,
+		impService:   services.Impersonation,
+		identity:     identity,
+		watcher:      watcher,
+	}
+}
+
+// ConfigResponse 当前生效的配置
+type ConfigResponse struct {
+	Entries []config.Entry `json:"entries"` // 按配置键排序的配置项，密钥已被隐藏
+}
+
+// GetConfig godoc
+// @Summary 查看运行配置
+// @Description 返回当前实例生效的配置及每个值的来源，密钥会被隐藏
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Success 200 {object} response.Response{data=ConfigResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /v1/admin/config [get]
+func (h *AdminHandler) GetConfig(c *gin.Context) {
+	response.Success(c, ConfigResponse{
+		Entries: h.watcher.Current().Entries(h.watcher.Sources()),
+	})
+}
+
+// ListRoles godoc
+// @Summary 获取角色列表
+// @Description 获取所有角色及其权限
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Success 200 {object} response.Response{data=[]model.Role}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/admin/roles [get]
+func (h *AdminHandler) ListRoles(c *gin.Context) {
+	roles, err := h.rbacService.ListRoles(c.Request.Context())
+	if err != nil {
+		response.ServerError(c, err)
+		return
+	}
+
+	response.Success(c, roles)
+}
+
+// ListUsers godoc
+// @Summary 查询用户列表
+// @Description 按邮箱、Clerk ID、订阅计划、状态和注册时间分页查询用户
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Param email query string false "邮箱（模糊匹配）"
+// @Param clerk_id query string false "Clerk ID"
+// @Param plan query string false "订阅计划"
+// @Param status query string false "用户状态"
+// @Param created_from query string false "注册时间起（RFC3339 或 2006-01-02）"
+// @Param created_to query string false "注册时间止，不含（RFC3339 或 2006-01-02）"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response{data=PageData}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/admin/users [get]
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	filter := service.UserFilter{
+		Email:    c.Query("email"),
+		ClerkID:  c.Query("clerk_id"),
+		Plan:     c.Query("plan"),
+		Status:   c.Query("status"),
+		Page:     int(queryUint(c, "page")),
+		PageSize: int(queryUint(c, "page_size")),
+	}
+
+	var ok bool
+	if filter.CreatedFrom, ok = queryTime(c, "created_from"); !ok {
+		return
+	}
+	if filter.CreatedTo, ok = queryTime(c, "created_to"); !ok {
+		return
+	}
+
+	users, total, err := h.adminService.SearchUsers(c.Request.Context(), filter)
+	if err != nil {
+		response.ServerError(c, err)
+		return
+	}
+
+	page, pageSize := service.NormalizePage(filter.Page, filter.PageSize)
+	response.Success(c, PageData{Items: users, Total: total, Page: page, PageSize: pageSize})
+}
+
+// GetUser godoc
+// @Summary 获取用户记录
+// @Description 获取指定用户的完整记录，支持客服只读访问
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Param id path int true "用户 ID"
+// @Success 200 {object} response.Response{data=model.User}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/admin/users/{id} [get]
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetUserByID(c.Request.Context(), userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.NotFoundError(c, "用户不存在")
+		return
+	}
+	if err != nil {
+		response.ServerError(c, err)
+		return
+	}
+
+	response.Success(c, user)
+}
+
+// AssignRole godoc
+// @Summary 分配用户角色
+// @Description 修改指定用户的角色，操作会写入审计日志
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Param id path int true "用户 ID"
+// @Param request body AssignRoleRequest true "角色和原因"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/admin/users/{id}/role [put]
+func (h *AdminHandler) AssignRole(c *gin.Context) {
+	userID, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "无效的请求数据")
+		return
+	}
+
+	err := h.rbacService.AssignRole(c.Request.Context(), actorFromContext(c), userID, req.Role, req.Reason)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		response.NotFoundError(c, err.Error())
+		return
+	case errors.Is(err, service.ErrRoleNotFound), errors.Is(err, service.ErrCannotChangeSelf):
+		response.ValidationError(c, err.Error())
+		return
+	case err != nil:
+		response.ServerError(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// UpdateUserStatus godoc
+// @Summary 修改用户状态
+// @Description 暂停、封禁或恢复用户，暂停可以设置截止时间，到期后自动恢复；操作会写入审计日志
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Param id path int true "用户 ID"
+// @Param request body UpdateUserStatusRequest true "状态和原因"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/admin/users/{id}/status [put]
+func (h *AdminHandler) UpdateUserStatus(c *gin.Context) {
+	userID, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "无效的请求数据")
+		return
+	}
+
+	actor := actorFromContext(c)
+	if actor.UserID == userID {
+		response.ValidationError(c, service.ErrCannotChangeSelf.Error())
+		return
+	}
+
+	err := h.adminService.UpdateUserStatus(c.Request.Context(), actor, userID, req.Status, req.Until, req.Reason)
+	h.respondAdminAction(c, err)
+}
+
+// UpdateUsageLimits godoc
+// @Summary 调整使用限制
+// @Description 调整用户的总使用限制和月度使用限制，未提供的字段保持不变
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Param id path int true "用户 ID"
+// @Param request body UpdateUsageLimitsRequest true "使用限制和原因"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/admin/users/{id}/limits [put]
+func (h *AdminHandler) UpdateUsageLimits(c *gin.Context) {
+	userID, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateUsageLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "无效的请求数据")
+		return
+	}
+	if req.UsageLimit == nil && req.MonthlyLimit == nil {
+		response.ValidationError(c, "至少需要提供一个使用限制")
+		return
+	}
+
+	err := h.adminService.UpdateUsageLimits(c.Request.Context(), actorFromContext(c), userID, req.UsageLimits, req.Reason)
+	h.respondAdminAction(c, err)
+}
+
+// ResetUsage godoc
+// @Summary 重置使用计数
+// @Description 将用户的总使用计数和月度使用计数清零
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Param id path int true "用户 ID"
+// @Param request body AdminActionRequest true "操作原因"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/admin/users/{id}/usage/reset [post]
+func (h *AdminHandler) ResetUsage(c *gin.Context) {
+	userID, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req AdminActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "无效的请求数据")
+		return
+	}
+
+	err := h.adminService.ResetUsage(c.Request.Context(), actorFromContext(c), userID, req.Reason)
+	h.respondAdminAction(c, err)
+}
+
+// ResyncUser godoc
+// @Summary 重新同步用户资料
+// @Description 从身份提供方拉取最新资料并覆盖本地记录
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Param id path int true "用户 ID"
+// @Param request body AdminActionRequest true "操作原因"
+// @Success 200 {object} response.Response{data=model.User}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Failure 502 {object} response.Response
+// @Router /v1/admin/users/{id}/resync [post]
+func (h *AdminHandler) ResyncUser(c *gin.Context) {
+	userID, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req AdminActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "无效的请求数据")
+		return
+	}
+
+	fetcher, ok := h.identity.(auth.ProfileFetcher)
+	if !ok {
+		response.ValidationError(c, "当前身份提供方不支持拉取用户资料")
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.userService.GetUserByID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.NotFoundError(c, "用户不存在")
+		return
+	}
+	if err != nil {
+		response.ServerError(c, err)
+		return
+	}
+
+	identity, err := fetcher.FetchProfile(ctx, user.ClerkID)
+	if err != nil {
+		response.Error(c, http.StatusBadGateway, "拉取用户资料失败", err)
+		return
+	}
+
+	if err := h.adminService.ResyncUser(ctx, actorFromContext(c), userID, identity.Profile(), req.Reason); err != nil {
+		h.respondAdminAction(c, err)
+		return
+	}
+
+	user, err = h.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		response.ServerError(c, err)
+		return
+	}
+	response.Success(c, user)
+}
+
+// StartImpersonation godoc
+// @Summary 发起模拟登录
+// @Description 签发以目标用户身份访问的短期令牌，只能模拟普通用户，不能执行账单、删除账号等操作
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Param request body StartImpersonationRequest true "目标用户、权限范围和原因"
+// @Success 200 {object} response.Response{data=StartImpersonationResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/admin/impersonations [post]
+func (h *AdminHandler) StartImpersonation(c *gin.Context) {
+	if _, ok := requireSessionPrincipal(c); !ok {
+		return
+	}
+
+	var req StartImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "无效的请求数据")
+		return
+	}
+
+	ttl := time.Duration(req.TTLMinutes) * time.Minute
+	session, token, err := h.impService.StartImpersonation(c.Request.Context(), actorFromContext(c), req.UserID, req.Scopes, ttl, req.Reason)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		response.NotFoundError(c, err.Error())
+		return
+	case errors.Is(err, service.ErrCannotImpersonate), errors.Is(err, service.ErrInvalidAPIKeyScope):
+		response.ValidationError(c, err.Error())
+		return
+	case err != nil:
+		response.ServerError(c, err)
+		return
+	}
+
+	response.Success(c, StartImpersonationResponse{Session: session, Token: token})
+}
+
+// RevokeImpersonation godoc
+// @Summary 结束模拟登录
+// @Description 提前结束模拟登录会话，令牌立即失效
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Param id path int true "会话 ID"
+// @Param request body AdminActionRequest true "操作原因"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/admin/impersonations/{id} [delete]
+func (h *AdminHandler) RevokeImpersonation(c *gin.Context) {
+	sessionID, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req AdminActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "无效的请求数据")
+		return
+	}
+
+	err := h.impService.RevokeImpersonation(c.Request.Context(), actorFromContext(c), sessionID, req.Reason)
+	switch {
+	case errors.Is(err, service.ErrImpersonationNotFound):
+		response.NotFoundError(c, err.Error())
+		return
+	case errors.Is(err, service.ErrImpersonationExpired):
+		response.ValidationError(c, err.Error())
+		return
+	case err != nil:
+		response.ServerError(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// ListAuditLogs godoc
+// @Summary 获取审计日志
+// @Description 分页查询管理操作的审计日志
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Param actor_id query int false "操作者用户 ID"
+// @Param subject_id query int false "被操作用户 ID"
+// @Param action query string false "操作类型"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response{data=PageData}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/admin/audit-logs [get]
+func (h *AdminHandler) ListAuditLogs(c *gin.Context) {
+	filter := service.AuditLogFilter{
+		ActorID:   uint(queryUint(c, "actor_id")),
+		SubjectID: uint(queryUint(c, "subject_id")),
+		Action:    c.Query("action"),
+		Page:      int(queryUint(c, "page")),
+		PageSize:  int(queryUint(c, "page_size")),
+	}
+
+	logs, total, err := h.auditService.ListAuditLogs(c.Request.Context(), filter)
+	if err != nil {
+		response.ServerError(c, err)
+		return
+	}
+
+	page, pageSize := service.NormalizePage(filter.Page, filter.PageSize)
+	response.Success(c, PageData{Items: logs, Total: total, Page: page, PageSize: pageSize})
+}
+
+// respondAdminAction 将管理操作的结果转换为响应
+func (h *AdminHandler) respondAdminAction(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		response.NotFoundError(c, err.Error())
+	case errors.Is(err, service.ErrInvalidUserStatus), errors.Is(err, service.ErrInvalidSuspendedUntil):
+		response.ValidationError(c, err.Error())
+	case errors.Is(err, service.ErrEmailInUse):
+		response.Error(c, http.StatusConflict, err.Error(), nil)
+	case err != nil:
+		response.ServerError(c, err)
+	default:
+		response.Success(c, nil)
+	}
+}
+
+// actorFromContext 根据当前已认证主体构建审计操作者
+func actorFromContext(c *gin.Context) service.Actor {
+	actor := service.Actor{IP: c.ClientIP()}
+	if principal, ok := auth.GetPrincipal(c); ok {
+		actor.UserID = principal.UserID
+	}
+	return actor
+}
+
+// parseIDParam 解析路径中的 id 参数，失败时写入 400 响应
+func parseIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.ValidationError(c, "无效的请求参数")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// queryUint 解析无符号整数查询参数，缺失或无效时返回 0
+func queryUint(c *gin.Context, key string) uint64 {
+	value, err := strconv.ParseUint(c.Query(key), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// queryTime 解析时间查询参数，支持 RFC3339 和 2006-01-02 两种格式
+// 参数缺失时返回 nil，格式无效时写入 400 响应
+func queryTime(c *gin.Context, key string) (*time.Time, bool) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, true
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, true
+		}
+	}
+
+	response.ValidationError(c, "无效的时间参数: "+key)
+	return nil, false
+}
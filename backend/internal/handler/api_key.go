@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yszaryszar/NicheFlow/backend/internal/auth"
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"github.com/yszaryszar/NicheFlow/backend/internal/service"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/response"
+)
+
+// CreateAPIKeyRequest 创建 API 密钥请求
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"` // 密钥名称
+	Scopes    []string   `json:"scopes"`                          // 权限范围（read/write），默认只读
+	ExpiresAt *time.Time `json:"expires_at"`                      // 过期时间，为空表示永不过期
+}
+
+// CreateAPIKeyResponse 创建 API 密钥响应
+type CreateAPIKeyResponse struct {
+	model.APIKey
+	Key string `json:"key"` // 密钥明文，仅返回一次
+}
+
+// APIKeyHandler 处理 API 密钥相关的 HTTP 请求
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+// NewAPIKeyHandler 创建一个新的 API 密钥处理器实例
+//
+// 参数:
+//   - apiKeyService: API 密钥服务
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary 创建 API 密钥
+// @Description 为当前用户创建个人 API 密钥，密钥明文只在本次响应中返回
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Param request body CreateAPIKeyRequest true "密钥信息"
+// @Success 200 {object} response.Response{data=CreateAPIKeyResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/user/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	principal, ok := requireSessionPrincipal(c)
+	if !ok {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, "无效的请求数据")
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		response.ValidationError(c, "过期时间必须晚于当前时间")
+		return
+	}
+
+	key, plaintext, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), principal.UserID, req.Name, req.Scopes, req.ExpiresAt)
+	switch {
+	case errors.Is(err, service.ErrInvalidAPIKeyScope), errors.Is(err, service.ErrAPIKeyLimitReached):
+		response.ValidationError(c, err.Error())
+		return
+	case err != nil:
+		response.ServerError(c, err)
+		return
+	}
+
+	response.Success(c, CreateAPIKeyResponse{APIKey: *key, Key: plaintext})
+}
+
+// ListAPIKeys godoc
+// @Summary 获取 API 密钥列表
+// @Description 获取当前用户的所有 API 密钥，不包含密钥明文
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Success 200 {object} response.Response{data=[]model.APIKey}
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/user/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	principal, ok := auth.GetPrincipal(c)
+	if !ok {
+		response.UnauthorizedError(c, "未授权访问")
+		return
+	}
+
+	keys, err := h.apiKeyService.ListAPIKeys(c.Request.Context(), principal.UserID)
+	if err != nil {
+		response.ServerError(c, err)
+		return
+	}
+
+	response.Success(c, keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary 吊销 API 密钥
+// @Description 吊销当前用户的指定 API 密钥，吊销后立即失效
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Param id path int true "API 密钥 ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/user/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	principal, ok := requireSessionPrincipal(c)
+	if !ok {
+		return
+	}
+
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.ValidationError(c, "无效的请求参数")
+		return
+	}
+
+	err = h.apiKeyService.RevokeAPIKey(c.Request.Context(), principal.UserID, uint(keyID))
+	switch {
+	case errors.Is(err, service.ErrAPIKeyNotFound):
+		response.NotFoundError(c, err.Error())
+		return
+	case err != nil:
+		response.ServerError(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// requireSessionPrincipal 要求请求通过会话认证
+// API 密钥不能用于管理密钥本身，也不能用于签发模拟登录等敏感凭证
+func requireSessionPrincipal(c *gin.Context) (*auth.Principal, bool) {
+	principal, ok := auth.GetPrincipal(c)
+	if !ok {
+		response.UnauthorizedError(c, "未授权访问")
+		return nil, false
+	}
+	if principal.Method != auth.MethodSession {
+		response.Error(c, http.StatusForbidden, "此操作需要使用登录会话", nil)
+		return nil, false
+	}
+	return principal, true
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yszaryszar/NicheFlow/backend/internal/auth"
 	"github.com/yszaryszar/NicheFlow/backend/internal/model"
 	"github.com/yszaryszar/NicheFlow/backend/internal/service"
 )
@@ -29,22 +30,38 @@ type AuthHandler struct {
 }
 
 // NewAuthHandler 创建一个新的认证处理器实例
-func NewAuthHandler() *AuthHandler {
+//
+// 参数:
+//   - userService: 用户服务
+func NewAuthHandler(userService *service.UserService) *AuthHandler {
 	return &AuthHandler{
-		userService: service.NewUserService(),
+		userService: userService,
 	}
 }
 
 // SyncUserData 同步用户数据
 // @Summary 同步 Clerk 用户数据到数据库
-// @Description 将 Clerk 返回的用户数据同步到本地数据库
+// @Description 将 Clerk 返回的资料同步到当前登录用户，用户由认证中间件确定，请求体中的 id 会被忽略。
+// @Description 邮箱、手机号及其验证状态不能通过该接口修改，以 Clerk Webhook 同步的结果为准。
 // @Tags auth
 // @Accept json
 // @Produce json
+// @Security ClerkAuth
 // @Param user body ClerkUser true "Clerk 用户数据"
 // @Success 200 {object} model.User
-// @Router /api/auth/sync [post]
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /v1/auth/sync [post]
 func (h *AuthHandler) SyncUserData(c *gin.Context) {
+	// 用户只能来自认证中间件，首次访问的用户已由中间件自动创建
+	current, ok := auth.GetUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return
+	}
+
 	// 解析请求体中的用户数据
 	var clerkUser ClerkUser
 	if err := c.ShouldBindJSON(&clerkUser); err != nil {
@@ -54,54 +71,27 @@ func (h *AuthHandler) SyncUserData(c *gin.Context) {
 		return
 	}
 
-	// 检查用户是否已存在
-	user, err := h.userService.GetUserByClerkID(c.Request.Context(), clerkUser.ID)
-	if err != nil {
-		// 如果用户不存在，创建新用户
-		user = &model.User{
-			ClerkID:       clerkUser.ID,
-			Email:         clerkUser.Email,
-			Username:      clerkUser.Username,
-			FirstName:     clerkUser.FirstName,
-			LastName:      clerkUser.LastName,
-			ImageURL:      clerkUser.ImageURL,
-			EmailVerified: clerkUser.EmailVerified,
-			PhoneNumber:   clerkUser.PhoneNumber,
-			PhoneVerified: clerkUser.PhoneVerified,
-			LastSignInAt:  clerkUser.LastSignInAt,
-			// 设置默认值
-			Role:          "user",
-			Status:        "active",
-			UsageLimit:    5,
-			MonthlyLimit:  3,
-			LastResetTime: time.Now(),
-		}
-		if err := h.userService.CreateUser(c.Request.Context(), user); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "创建用户失败",
-			})
-			return
-		}
-	} else {
-		// 如果用户存在，更新用户信息
-		user.Email = clerkUser.Email
-		user.Username = clerkUser.Username
-		user.FirstName = clerkUser.FirstName
-		user.LastName = clerkUser.LastName
-		user.ImageURL = clerkUser.ImageURL
-		user.EmailVerified = clerkUser.EmailVerified
-		user.PhoneNumber = clerkUser.PhoneNumber
-		user.PhoneVerified = clerkUser.PhoneVerified
-		user.LastSignInAt = clerkUser.LastSignInAt
-
-		if err := h.userService.UpdateUser(c.Request.Context(), user); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "更新用户失败",
-			})
-			return
-		}
+	// 只更新资料字段，邮箱和手机号由客户端提供时无法确认真实性
+	if err := h.userService.UpdateUserProfile(c.Request.Context(), current, &model.User{
+		Username:     clerkUser.Username,
+		FirstName:    clerkUser.FirstName,
+		LastName:     clerkUser.LastName,
+		ImageURL:     clerkUser.ImageURL,
+		LastSignInAt: clerkUser.LastSignInAt,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "更新用户失败",
+		})
+		return
 	}
 
 	// 返回同步后的用户数据
+	user, err := h.userService.GetUserByClerkID(c.Request.Context(), current.ClerkID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "获取用户失败",
+		})
+		return
+	}
 	c.JSON(http.StatusOK, user)
 }
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/auth"
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+)
+
+// Clerk Webhook 事件类型
+const (
+	clerkEventUserCreated = "user.created"
+	clerkEventUserUpdated = "user.updated"
+	clerkEventUserDeleted = "user.deleted"
+)
+
+// clerkWebhookEvent Clerk Webhook 事件外层结构
+type clerkWebhookEvent struct {
+	Type   string          `json:"type"`   // 事件类型
+	Object string          `json:"object"` // 固定为 event
+	Data   json.RawMessage `json:"data"`   // 事件数据，结构随事件类型变化
+}
+
+// clerkVerification Clerk 验证状态
+type clerkVerification struct {
+	Status string `json:"status"` // verified/unverified 等
+}
+
+// clerkEmailAddress Clerk 邮箱地址
+type clerkEmailAddress struct {
+	ID           string             `json:"id"`
+	EmailAddress string             `json:"email_address"`
+	Verification *clerkVerification `json:"verification"`
+}
+
+// clerkPhoneNumber Clerk 手机号
+type clerkPhoneNumber struct {
+	ID           string             `json:"id"`
+	PhoneNumber  string             `json:"phone_number"`
+	Verification *clerkVerification `json:"verification"`
+}
+
+// clerkExternalAccount Clerk 第三方登录账号
+type clerkExternalAccount struct {
+	ID             string `json:"id"`
+	Provider       string `json:"provider"` // 如 oauth_google
+	ProviderUserID string `json:"provider_user_id"`
+	EmailAddress   string `json:"email_address"`
+	Username       string `json:"username"`
+	ImageURL       string `json:"image_url"`
+	AvatarURL      string `json:"avatar_url"`
+}
+
+// clerkWebhookUser Clerk 用户事件数据
+type clerkWebhookUser struct {
+	ID                    string                 `json:"id"`
+	Username              *string                `json:"username"`
+	FirstName             *string                `json:"first_name"`
+	LastName              *string                `json:"last_name"`
+	ImageURL              string                 `json:"image_url"`
+	PrimaryEmailAddressID *string                `json:"primary_email_address_id"`
+	PrimaryPhoneNumberID  *string                `json:"primary_phone_number_id"`
+	EmailAddresses        []clerkEmailAddress    `json:"email_addresses"`
+	PhoneNumbers          []clerkPhoneNumber     `json:"phone_numbers"`
+	ExternalAccounts      []clerkExternalAccount `json:"external_accounts"`
+	LastSignInAt          *int64                 `json:"last_sign_in_at"` // 毫秒时间戳
+	Deleted               bool                   `json:"deleted"`
+}
+
+// toModel 将 Clerk 用户数据转换为本地用户模型和社交账号列表
+func (u *clerkWebhookUser) toModel() (*model.User, []model.SocialAccount) {
+	user := &model.User{
+		ClerkID:   u.ID,
+		Username:  auth.DerefString(u.Username),
+		FirstName: auth.DerefString(u.FirstName),
+		LastName:  auth.DerefString(u.LastName),
+		ImageURL:  u.ImageURL,
+	}
+
+	for _, email := range u.EmailAddresses {
+		if u.PrimaryEmailAddressID != nil && email.ID != *u.PrimaryEmailAddressID {
+			continue
+		}
+		user.Email = email.EmailAddress
+		user.EmailVerified = email.Verification != nil && email.Verification.Status == "verified"
+		break
+	}
+
+	for _, phone := range u.PhoneNumbers {
+		if u.PrimaryPhoneNumberID != nil && phone.ID != *u.PrimaryPhoneNumberID {
+			continue
+		}
+		user.PhoneNumber = phone.PhoneNumber
+		user.PhoneVerified = phone.Verification != nil && phone.Verification.Status == "verified"
+		break
+	}
+
+	if u.LastSignInAt != nil {
+		user.LastSignInAt = time.UnixMilli(*u.LastSignInAt)
+	}
+
+	accounts := make([]model.SocialAccount, 0, len(u.ExternalAccounts))
+	for _, external := range u.ExternalAccounts {
+		avatar := external.ImageURL
+		if avatar == "" {
+			avatar = external.AvatarURL
+		}
+		accounts = append(accounts, model.SocialAccount{
+			Provider:  strings.TrimPrefix(external.Provider, "oauth_"),
+			AccountID: external.ProviderUserID,
+			Email:     external.EmailAddress,
+			Username:  external.Username,
+			AvatarURL: avatar,
+			IsActive:  true,
+		})
+	}
+
+	return user, accounts
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/yszaryszar/NicheFlow/backend/migrations"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/cache"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/database"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/migrate"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/response"
+	"gorm.io/gorm"
+)
+
+// readinessTimeout 就绪检查中每项依赖检查的超时时间
+// 各项检查并行执行，整个请求不超过该时间，需要小于负载均衡健康检查的超时时间
+const readinessTimeout = 2 * time.Second
+
+// 依赖检查结果
+const (
+	CheckOK       = "ok"       // 检查通过
+	CheckFailed   = "failed"   // 检查失败
+	CheckDisabled = "disabled" // 未配置该依赖
+)
+
+// DependencyStatus 单项依赖的检查结果
+type DependencyStatus struct {
+	Status    string  `json:"status"`          // 检查结果（ok/failed/disabled）
+	LatencyMS float64 `json:"latency_ms"`      // 检查耗时（毫秒）
+	Error     string  `json:"error,omitempty"` // 检查失败的原因
+}
+
+// MigrationStatus 数据库迁移的检查结果
+type MigrationStatus struct {
+	DependencyStatus
+	Version int64    `json:"version"`           // 已执行的最新迁移版本
+	Pending []string `json:"pending,omitempty"` // 未执行的迁移
+}
+
+// ReadinessResponse 就绪检查响应
+type ReadinessResponse struct {
+	Status     string                   `json:"status"`             // 整体结果（ok/unavailable/draining）
+	Version    string                   `json:"version"`            // 应用版本
+	Database   DependencyStatus         `json:"database"`           // 数据库连接
+	Redis      DependencyStatus         `json:"redis"`              // Redis 连接
+	Migrations MigrationStatus          `json:"migrations"`         // 数据库迁移
+	Replicas   []database.ReplicaStatus `json:"replicas,omitempty"` // 只读副本，不健康的副本不影响整体结果
+}
+
+// PoolStatsResponse 连接池统计响应
+type PoolStatsResponse struct {
+	Database *database.Stats  `json:"database"` // 数据库连接池统计
+	Redis    *cache.PoolStats `json:"redis"`    // Redis 连接池统计，未配置 Redis 时为 null
+}
+
+// HealthHandler 处理存活检查、就绪检查和连接池统计请求
+type HealthHandler struct {
+	version  string
+	db       *gorm.DB
+	rdb      *redis.Client
+	migrator *migrate.Migrator
+	draining func() bool
+}
+
+// NewHealthHandler 创建一个新的健康检查处理器实例
+//
+// 参数:
+//   - version: 应用版本
+//   - db: 数据库连接
+//   - rdb: Redis 客户端，为 nil 时就绪检查跳过 Redis
+//   - draining: 返回实例是否正在关闭，关闭期间就绪检查直接返回 503
+//
+// 返回:
+//   - *HealthHandler: 健康检查处理器
+//   - error: 加载迁移文件失败时返回错误
+func NewHealthHandler(version string, db *gorm.DB, rdb *redis.Client, draining func() bool) (*HealthHandler, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取 SQL DB 失败: %w", err)
+	}
+	migrator, err := migrations.NewMigrator(sqlDB, db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &HealthHandler{
+		version:  version,
+		db:       db,
+		rdb:      rdb,
+		migrator: migrator,
+		draining: draining,
+	}, nil
+}
+
+// Livez godoc
+// @Summary 存活检查
+// @Description 只表示进程可以处理请求，不检查任何依赖，依赖故障时不应重启实例
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"version": h.version,
+	})
+}
+
+// Readyz godoc
+// @Summary 就绪检查
+// @Description 并行检查数据库、Redis 和迁移状态，任一项失败时返回 503，负载均衡据此停止向该实例转发流量。
+// @Description 只读副本故障时查询会回退到主库，因此只报告副本状态，不影响整体结果。
+// @Description 实例收到停止信号后不再检查依赖，直接返回 503 和 draining 状态。
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} ReadinessResponse
+// @Failure 503 {object} ReadinessResponse
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	if h.draining() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":  "draining",
+			"version": h.version,
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	resp := ReadinessResponse{
+		Status:   "ok",
+		Version:  h.version,
+		Replicas: database.ReplicasOf(h.db).Status(),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		resp.Database = timeCheck(func() error {
+			sqlDB, err := h.db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		if h.rdb == nil {
+			resp.Redis = DependencyStatus{Status: CheckDisabled}
+			return
+		}
+		resp.Redis = timeCheck(func() error {
+			return h.rdb.Ping(ctx).Err()
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		resp.Migrations = h.checkMigrations(ctx)
+	}()
+	wg.Wait()
+
+	status := http.StatusOK
+	for _, check := range []string{resp.Database.Status, resp.Redis.Status, resp.Migrations.Status} {
+		if check == CheckFailed {
+			resp.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	c.JSON(status, resp)
+}
+
+// checkMigrations 检查数据库结构是否为最新
+func (h *HealthHandler) checkMigrations(ctx context.Context) MigrationStatus {
+	var result MigrationStatus
+	result.DependencyStatus = timeCheck(func() error {
+		statuses, err := h.migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		var modified []string
+		for _, status := range statuses {
+			name := fmt.Sprintf("%d_%s", status.Version, status.Name)
+			switch status.State {
+			case migrate.StateApplied, migrate.StateUnknown:
+				result.Version = max(result.Version, status.Version)
+			case migrate.StateModified:
+				result.Version = max(result.Version, status.Version)
+				modified = append(modified, name)
+			case migrate.StatePending:
+				result.Pending = append(result.Pending, name)
+			}
+		}
+		if len(modified) > 0 {
+			return fmt.Errorf("%w: %v", migrate.ErrChecksumMismatch, modified)
+		}
+		if len(result.Pending) > 0 {
+			return migrate.ErrSchemaBehind
+		}
+		return nil
+	})
+	return result
+}
+
+// timeCheck 执行检查并记录耗时
+func timeCheck(check func() error) DependencyStatus {
+	start := time.Now()
+	err := check()
+	result := DependencyStatus{
+		Status:    CheckOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = CheckFailed
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// GetPoolStats godoc
+// @Summary 查看连接池统计
+// @Description 返回当前实例的数据库（包括只读副本）和 Redis 连接池统计
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Security ClerkAuth
+// @Success 200 {object} response.Response{data=PoolStatsResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /v1/admin/pools [get]
+func (h *HealthHandler) GetPoolStats(c *gin.Context) {
+	stats, err := database.StatsOf(h.db)
+	if err != nil {
+		response.ServerError(c, err)
+		return
+	}
+	response.Success(c, PoolStatsResponse{
+		Database: stats,
+		Redis:    cache.StatsOf(h.rdb),
+	})
+}
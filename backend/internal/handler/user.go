@@ -3,23 +3,43 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yszaryszar/NicheFlow/backend/internal/auth"
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
 	"github.com/yszaryszar/NicheFlow/backend/internal/model"
 	"github.com/yszaryszar/NicheFlow/backend/internal/service"
 	"github.com/yszaryszar/NicheFlow/backend/pkg/response"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/webhook"
 )
 
+// maxWebhookBodySize Webhook 请求体的最大字节数
+const maxWebhookBodySize = 1 << 20
+
 // UserHandler 处理用户相关的 HTTP 请求
 type UserHandler struct {
-	userService *service.UserService
+	userService     *service.UserService
+	webhookVerifier *webhook.SvixVerifier // Clerk Webhook 签名校验器，未配置密钥时为 nil
 }
 
 // NewUserHandler 创建一个新的用户处理器实例
-func NewUserHandler() *UserHandler {
+//
+// 参数:
+//   - clerkCfg: Clerk 配置，用于初始化 Webhook 签名校验
+//   - userService: 用户服务
+func NewUserHandler(clerkCfg *config.ClerkConfig, userService *service.UserService) *UserHandler {
+	verifier, err := webhook.NewSvixVerifier(clerkCfg.WebhookKey, webhook.DefaultTolerance)
+	if err != nil {
+		log.Printf("Clerk Webhook 密钥无效，Webhook 将被拒绝: %v", err)
+	}
+
 	return &UserHandler{
-		userService: service.NewUserService(),
+		userService:     userService,
+		webhookVerifier: verifier,
 	}
 }
 
@@ -35,7 +55,7 @@ func NewUserHandler() *UserHandler {
 // @Failure 500 {object} response.Response
 // @Router /v1/user/profile [get]
 func (h *UserHandler) GetProfile(c *gin.Context) {
-	clerkID := c.GetString("clerk_user_id")
+	clerkID := auth.GetClerkID(c)
 	if clerkID == "" {
 		response.UnauthorizedError(c, "未授权访问")
 		return
@@ -64,21 +84,25 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 // @Failure 500 {object} response.Response
 // @Router /v1/user/profile [put]
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
-	clerkID := c.GetString("clerk_user_id")
-	if clerkID == "" {
+	current, ok := auth.GetUser(c)
+	if !ok {
 		response.UnauthorizedError(c, "未授权访问")
 		return
 	}
 
-	var user model.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var input model.User
+	if err := c.ShouldBindJSON(&input); err != nil {
 		response.ValidationError(c, "无效的请求数据")
 		return
 	}
 
-	// 确保不能修改关键字段
-	user.ClerkID = clerkID
-	if err := h.userService.UpdateUser(c.Request.Context(), &user); err != nil {
+	// 只更新资料字段，ID、角色、状态和使用限制保持不变
+	if err := h.userService.UpdateUserProfile(c.Request.Context(), current, &model.User{
+		Username:  input.Username,
+		FirstName: input.FirstName,
+		LastName:  input.LastName,
+		ImageURL:  input.ImageURL,
+	}); err != nil {
 		response.ServerError(c, err)
 		return
 	}
@@ -100,7 +124,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 // @Failure 500 {object} response.Response
 // @Router /v1/user/preferences [put]
 func (h *UserHandler) UpdatePreferences(c *gin.Context) {
-	clerkID := c.GetString("clerk_user_id")
+	clerkID := auth.GetClerkID(c)
 	if clerkID == "" {
 		response.UnauthorizedError(c, "未授权访问")
 		return
@@ -132,7 +156,7 @@ func (h *UserHandler) UpdatePreferences(c *gin.Context) {
 // @Failure 500 {object} response.Response
 // @Router /v1/user/preferences [get]
 func (h *UserHandler) GetPreferences(c *gin.Context) {
-	clerkID := c.GetString("clerk_user_id")
+	clerkID := auth.GetClerkID(c)
 	if clerkID == "" {
 		response.UnauthorizedError(c, "未授权访问")
 		return
@@ -161,7 +185,7 @@ func (h *UserHandler) GetPreferences(c *gin.Context) {
 // @Failure 500 {object} response.Response
 // @Router /v1/user/social-accounts [post]
 func (h *UserHandler) LinkSocialAccount(c *gin.Context) {
-	clerkID := c.GetString("clerk_user_id")
+	clerkID := auth.GetClerkID(c)
 	if clerkID == "" {
 		response.UnauthorizedError(c, "未授权访问")
 		return
@@ -196,7 +220,7 @@ func (h *UserHandler) LinkSocialAccount(c *gin.Context) {
 // @Failure 500 {object} response.Response
 // @Router /v1/user/social-accounts/{provider}/{accountId} [delete]
 func (h *UserHandler) UnlinkSocialAccount(c *gin.Context) {
-	clerkID := c.GetString("clerk_user_id")
+	clerkID := auth.GetClerkID(c)
 	if clerkID == "" {
 		response.UnauthorizedError(c, "未授权访问")
 		return
@@ -229,7 +253,7 @@ func (h *UserHandler) UnlinkSocialAccount(c *gin.Context) {
 // @Failure 500 {object} response.Response
 // @Router /v1/user/social-accounts [get]
 func (h *UserHandler) GetSocialAccounts(c *gin.Context) {
-	clerkID := c.GetString("clerk_user_id")
+	clerkID := auth.GetClerkID(c)
 	if clerkID == "" {
 		response.UnauthorizedError(c, "未授权访问")
 		return
@@ -256,7 +280,7 @@ func (h *UserHandler) GetSocialAccounts(c *gin.Context) {
 // @Failure 500 {object} Response "服务器错误"
 // @Router /api/user/usage [get]
 func (h *UserHandler) GetUsage(c *gin.Context) {
-	clerkID := c.GetHeader("X-Clerk-User-Id")
+	clerkID := auth.GetClerkID(c)
 	if clerkID == "" {
 		response.Error(c, http.StatusUnauthorized, "未认证", nil)
 		return
@@ -283,7 +307,7 @@ func (h *UserHandler) GetUsage(c *gin.Context) {
 // @Failure 500 {object} Response "服务器错误"
 // @Router /api/user/subscription [get]
 func (h *UserHandler) GetSubscription(c *gin.Context) {
-	clerkID := c.GetHeader("X-Clerk-User-Id")
+	clerkID := auth.GetClerkID(c)
 	if clerkID == "" {
 		response.Error(c, http.StatusUnauthorized, "未认证", nil)
 		return
@@ -300,40 +324,70 @@ func (h *UserHandler) GetSubscription(c *gin.Context) {
 
 // WebhookHandler godoc
 // @Summary 处理 Clerk Webhook
-// @Description 处理来自 Clerk 的 Webhook 事件
+// @Description 校验 Svix 签名后处理来自 Clerk 的用户事件，同一事件重复投递只处理一次
 // @Tags Webhook
 // @Accept json
 // @Produce json
 // @Param event body interface{} true "Webhook 事件数据"
 // @Success 200 {object} response.Response
 // @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
 // @Router /v1/webhook/clerk [post]
 func (h *UserHandler) WebhookHandler(c *gin.Context) {
-	var event map[string]interface{}
-	if err := json.NewDecoder(c.Request.Body).Decode(&event); err != nil {
-		response.ValidationError(c, "无效的 Webhook 数据")
+	if h.webhookVerifier == nil {
+		response.Error(c, http.StatusInternalServerError, "Webhook 未配置", nil)
 		return
 	}
 
-	// TODO: 验证 Webhook 签名
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodySize))
+	if err != nil {
+		response.ValidationError(c, "读取 Webhook 数据失败")
+		return
+	}
 
-	// 处理不同类型的事件
-	eventType, ok := event["type"].(string)
-	if !ok {
+	// 验证 Webhook 签名
+	if err := h.webhookVerifier.Verify(c.Request.Header, payload); err != nil {
+		response.Error(c, http.StatusUnauthorized, "Webhook 签名验证失败", err)
+		return
+	}
+
+	var event clerkWebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		response.ValidationError(c, "无效的 Webhook 数据")
+		return
+	}
+	if event.Type == "" {
 		response.ValidationError(c, "无效的事件类型")
 		return
 	}
 
-	switch eventType {
-	case "user.created":
-		// 处理用户创建事件
-		// TODO: 实现用户创建逻辑
-	case "user.updated":
-		// 处理用户更新事件
-		// TODO: 实现用户更新逻辑
-	case "user.deleted":
-		// 处理用户删除事件
-		// TODO: 实现用户删除逻辑
+	// svix-id 在重投时保持不变，作为幂等键
+	eventID := c.GetHeader(webhook.HeaderSvixID)
+
+	switch event.Type {
+	case clerkEventUserCreated, clerkEventUserUpdated:
+		var data clerkWebhookUser
+		if err := json.Unmarshal(event.Data, &data); err != nil || data.ID == "" {
+			response.ValidationError(c, "无效的用户数据")
+			return
+		}
+		user, accounts := data.toModel()
+		err = h.userService.SyncClerkUser(c.Request.Context(), eventID, event.Type, user, accounts)
+	case clerkEventUserDeleted:
+		var data clerkWebhookUser
+		if err := json.Unmarshal(event.Data, &data); err != nil || data.ID == "" {
+			response.ValidationError(c, "无效的用户数据")
+			return
+		}
+		err = h.userService.DeleteClerkUser(c.Request.Context(), eventID, data.ID)
+	default:
+		// 未订阅的事件类型直接确认，避免 Clerk 重试
+	}
+
+	if err != nil && !errors.Is(err, service.ErrWebhookEventProcessed) {
+		response.ServerError(c, err)
+		return
 	}
 
 	response.Success(c, nil)
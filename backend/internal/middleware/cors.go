@@ -1,40 +1,293 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+)
+
+// 生产环境的前端域名
+var productionOrigins = []string{
+	"https://getnicheflow.com",
+	"https://www.getnicheflow.com",
+}
+
+// Vercel 预览部署的域名为 https://nicheflow-<哈希>-<团队>.vercel.app，哈希固定为 9 位小写字母和数字。
+// 哈希中不含 -，因此其他团队无法构造出以 -<团队>.vercel.app 结尾且匹配的域名
+const (
+	previewOriginPrefix     = "https://nicheflow-"
+	previewOriginSuffix     = "-%s.vercel.app" // %s 为团队标识
+	previewDeploymentLength = 9
+)
+
+// 本地开发的前端地址
+var developmentOrigins = []string{
+	"http://localhost:3000",
+	"http://127.0.0.1:3000",
+}
+
+// CORS 默认值，配置中对应字段为空时使用
+var (
+	defaultCORSMethods       = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	defaultCORSHeaders       = []string{"Authorization", "Content-Type", apiKeyHeader}
+	defaultCORSExposeHeaders = []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"}
 )
 
-// CORS 跨域中间件
-func CORS() gin.HandlerFunc {
+// defaultCORSMaxAge 默认的预检请求缓存时间（秒）
+const defaultCORSMaxAge = 600
+
+// defaultCORSOrigins 返回各运行环境默认允许的源
+//
+// 说明:
+//
+//	production 只允许正式域名；staging 额外允许 Vercel 预览部署；
+//	其他环境（包括 development）同时允许预览部署和本地开发地址。
+//	未配置 Vercel 团队标识时不允许任何预览部署。
+func defaultCORSOrigins(env, vercelTeam string) []originPattern {
+	origins := exactOrigins(productionOrigins)
+	if env == "production" {
+		return origins
+	}
+	if vercelTeam != "" {
+		origins = append(origins, originPattern{
+			prefix:     previewOriginPrefix,
+			suffix:     fmt.Sprintf(previewOriginSuffix, vercelTeam),
+			deployment: true,
+		})
+	}
+	if env == "staging" {
+		return origins
+	}
+	return append(origins, exactOrigins(developmentOrigins)...)
+}
+
+// exactOrigins 把源列表转换为精确匹配的模式
+func exactOrigins(origins []string) []originPattern {
+	patterns := make([]originPattern, 0, len(origins))
+	for _, origin := range origins {
+		patterns = append(patterns, originPattern{exact: origin})
+	}
+	return patterns
+}
+
+// originPattern 允许的源，可以在主机名中包含一个 *
+type originPattern struct {
+	exact      string // 不含通配符时的完整源
+	prefix     string // * 之前的部分
+	suffix     string // * 之后的部分
+	subdomain  bool   // 形如 https://*.example.com，* 可以匹配多级子域名
+	deployment bool   // Vercel 预览部署，* 只能匹配部署哈希
+}
+
+// match 判断源是否匹配
+func (p originPattern) match(origin string) bool {
+	if p.exact != "" {
+		return origin == p.exact
+	}
+	if len(origin) <= len(p.prefix)+len(p.suffix) ||
+		!strings.HasPrefix(origin, p.prefix) || !strings.HasSuffix(origin, p.suffix) {
+		return false
+	}
+
+	wildcard := origin[len(p.prefix) : len(origin)-len(p.suffix)]
+	if p.deployment {
+		return len(wildcard) == previewDeploymentLength &&
+			strings.Trim(wildcard, "abcdefghijklmnopqrstuvwxyz0123456789") == ""
+	}
+	if strings.ContainsAny(wildcard, "/:@") {
+		return false
+	}
+	if p.subdomain {
+		return !strings.HasPrefix(wildcard, ".") && !strings.HasSuffix(wildcard, ".") && !strings.Contains(wildcard, "..")
+	}
+	return !strings.Contains(wildcard, ".")
+}
+
+// corsPolicy 由配置编译得到的 CORS 策略
+type corsPolicy struct {
+	allowAll           bool
+	origins            []originPattern
+	allowMethods       string
+	allowHeaders       string
+	exposeHeaders      string
+	allowCredentials   bool
+	echoRequestHeaders bool
+	maxAge             string
+}
+
+// newCORSPolicy 编译并校验 CORS 配置
+//
+// 参数:
+//   - env: 运行环境，用于选择默认允许的源
+//   - cfg: CORS 配置
+//
+// 返回:
+//   - *corsPolicy: CORS 策略
+//   - error: 源格式无效，或在允许凭证时配置了 * 或通配符源时返回错误
+//
+// 说明:
+//
+//	允许凭证时只接受精确的源。Vercel 预览部署只能通过 vercel_team 显式开启，
+//	生成的模式与 Vercel 的域名结构完全对应，不受此限制。
+func newCORSPolicy(env string, cfg *config.CORSConfig) (*corsPolicy, error) {
+	policy := &corsPolicy{
+		allowMethods:       strings.Join(orDefault(cfg.AllowMethods, defaultCORSMethods), ", "),
+		allowHeaders:       strings.Join(orDefault(cfg.AllowHeaders, defaultCORSHeaders), ", "),
+		exposeHeaders:      strings.Join(orDefault(cfg.ExposeHeaders, defaultCORSExposeHeaders), ", "),
+		allowCredentials:   cfg.AllowCredentials,
+		echoRequestHeaders: cfg.EchoRequestHeaders,
+		maxAge:             strconv.Itoa(defaultCORSMaxAge),
+	}
+	if cfg.MaxAge > 0 {
+		policy.maxAge = strconv.Itoa(cfg.MaxAge)
+	}
+
+	if len(cfg.AllowOrigins) == 0 {
+		policy.origins = defaultCORSOrigins(env, cfg.VercelTeam)
+		return policy, nil
+	}
+
+	for _, origin := range cfg.AllowOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin == "" {
+			continue
+		}
+		if origin == "*" {
+			if cfg.AllowCredentials {
+				return nil, errors.New("启用 allow_credentials 时不能允许所有源 *")
+			}
+			policy.allowAll = true
+			continue
+		}
+
+		pattern, err := parseOriginPattern(origin)
+		if err != nil {
+			return nil, err
+		}
+		if pattern.exact == "" && cfg.AllowCredentials {
+			return nil, fmt.Errorf("启用 allow_credentials 时不能使用通配符源 %q，请列出具体的源", origin)
+		}
+		policy.origins = append(policy.origins, pattern)
+	}
+
+	return policy, nil
+}
+
+// parseOriginPattern 解析允许的源
+func parseOriginPattern(origin string) (originPattern, error) {
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok || scheme == "" || host == "" || strings.Contains(host, "/") {
+		return originPattern{}, fmt.Errorf("无效的 CORS 源 %q，格式应为 scheme://host[:port]", origin)
+	}
+
+	switch strings.Count(host, "*") {
+	case 0:
+		return originPattern{exact: origin}, nil
+	case 1:
+	default:
+		return originPattern{}, fmt.Errorf("无效的 CORS 源 %q，只能包含一个 *", origin)
+	}
+
+	prefix, suffix, _ := strings.Cut(origin, "*")
+	hostname, _, _ := strings.Cut(host, ":")
+	if idx := strings.Index(hostname, "*"); idx < 0 || !strings.Contains(hostname[idx:], ".") {
+		return originPattern{}, fmt.Errorf("无效的 CORS 源 %q，* 只能出现在主机名中且之后必须包含域名", origin)
+	}
+	return originPattern{
+		prefix:    prefix,
+		suffix:    suffix,
+		subdomain: strings.HasPrefix(hostname, "*."),
+	}, nil
+}
+
+// allowOrigin 判断源是否被允许
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	if p.allowAll {
+		return true
+	}
+	for _, pattern := range p.origins {
+		if pattern.match(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// orDefault 列表为空时返回默认值
+func orDefault(values, defaults []string) []string {
+	if len(values) == 0 {
+		return defaults
+	}
+	return values
+}
+
+// CORS 返回 CORS 中间件
+//
+// 返回:
+//   - gin.HandlerFunc: CORS 中间件函数
+//
+// 说明:
+//
+//	允许的源支持精确匹配和通配符：https://*.example.com 匹配任意级子域名，
+//	https://app-*.example.com 匹配单个标签内的任意字符，* 表示允许所有源，
+//	通配符和 * 都不能与 allow_credentials 同时使用。未配置允许的源时按运行环境使用默认值。
+//	响应始终带有 Vary: Origin，允许的源会被原样回显。
+//	不被允许的预检请求返回 403，不被允许的普通请求不带 CORS 头，由浏览器拦截。
+func (m *Manager) CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		origin := c.Request.Header.Get("Origin")
-		// 允许的域名列表
-		allowedOrigins := []string{
-			"https://getnicheflow.com",
-			"https://www.getnicheflow.com",
-			"http://localhost:3000", // 开发环境
-		}
-
-		// 检查请求的 Origin 是否在允许列表中
-		for _, allowed := range allowedOrigins {
-			if origin == allowed {
-				c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-				break
+		policy := m.cors.Load()
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if origin == "" {
+			c.Next()
+			return
+		}
+		if !policy.allowOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
 			}
+			c.Next()
+			return
 		}
 
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Accept, Origin, Cache-Control, X-Requested-With, X-Clerk-User-Id")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
-		c.Writer.Header().Set("Access-Control-Max-Age", "86400") // 24小时
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length")
+		if policy.allowAll {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if policy.allowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
 
-		// 处理预检请求
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if !preflight {
+			if policy.exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", policy.exposeHeaders)
+			}
+			c.Next()
 			return
 		}
 
-		c.Next()
+		header.Set("Access-Control-Allow-Methods", policy.allowMethods)
+		if requested := c.GetHeader("Access-Control-Request-Headers"); policy.echoRequestHeaders && requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		} else {
+			header.Set("Access-Control-Allow-Headers", policy.allowHeaders)
+		}
+		header.Set("Access-Control-Max-Age", policy.maxAge)
+		c.AbortWithStatus(http.StatusNoContent)
 	}
 }
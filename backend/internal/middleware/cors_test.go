@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+)
+
+// TestCORSAllowOrigin 校验精确源、通配符源和 Vercel 预览部署的匹配
+func TestCORSAllowOrigin(t *testing.T) {
+	cases := []struct {
+		name   string
+		env    string
+		cfg    config.CORSConfig
+		origin string
+		want   bool
+	}{
+		{name: "精确匹配", cfg: corsOrigins("https://app.example.com"), origin: "https://app.example.com", want: true},
+		{name: "精确匹配忽略配置末尾的斜杠", cfg: corsOrigins("https://app.example.com/"), origin: "https://app.example.com", want: true},
+		{name: "协议不同", cfg: corsOrigins("https://app.example.com"), origin: "http://app.example.com", want: false},
+		{name: "端口不同", cfg: corsOrigins("https://app.example.com"), origin: "https://app.example.com:8443", want: false},
+
+		{name: "子域名通配符匹配一级子域名", cfg: corsOrigins("https://*.example.com"), origin: "https://app.example.com", want: true},
+		{name: "子域名通配符匹配多级子域名", cfg: corsOrigins("https://*.example.com"), origin: "https://a.b.example.com", want: true},
+		{name: "子域名通配符不匹配主域名", cfg: corsOrigins("https://*.example.com"), origin: "https://example.com", want: false},
+		{name: "子域名通配符不匹配后缀相同的其他域名", cfg: corsOrigins("https://*.example.com"), origin: "https://evilexample.com", want: false},
+		{name: "子域名通配符不匹配其他域名下的同名子域名", cfg: corsOrigins("https://*.example.com"), origin: "https://example.com.evil.com", want: false},
+		{name: "子域名通配符不匹配携带用户信息的源", cfg: corsOrigins("https://*.example.com"), origin: "https://evil.com@x.example.com", want: false},
+		{name: "子域名通配符不匹配空标签", cfg: corsOrigins("https://*.example.com"), origin: "https://a..example.com", want: false},
+
+		{name: "标签内通配符", cfg: corsOrigins("https://app-*.example.com"), origin: "https://app-pr-12.example.com", want: true},
+		{name: "标签内通配符不跨越标签", cfg: corsOrigins("https://app-*.example.com"), origin: "https://app-x.evil.example.com", want: false},
+		{name: "标签内通配符不匹配空字符串", cfg: corsOrigins("https://app-*.example.com"), origin: "https://app-.example.com", want: false},
+		{name: "标签内通配符不匹配端口", cfg: corsOrigins("https://app-*.example.com"), origin: "https://app-x:1.example.com", want: false},
+
+		{name: "允许所有源", cfg: corsOrigins("*"), origin: "https://anything.example.org", want: true},
+
+		{name: "预览部署", env: "staging", cfg: corsTeam("acme"), origin: "https://nicheflow-abc123xyz-acme.vercel.app", want: true},
+		{name: "预览部署不匹配后缀相同的其他团队", env: "staging", cfg: corsTeam("acme"), origin: "https://nicheflow-abc123xyz-evil-acme.vercel.app", want: false},
+		{name: "预览部署不匹配短哈希加其他团队", env: "staging", cfg: corsTeam("acme"), origin: "https://nicheflow-abc-evil-acme.vercel.app", want: false},
+		{name: "预览部署不匹配分支域名", env: "staging", cfg: corsTeam("acme"), origin: "https://nicheflow-git-main-acme.vercel.app", want: false},
+		{name: "预览部署不匹配大写哈希", env: "staging", cfg: corsTeam("acme"), origin: "https://nicheflow-ABC123XYZ-acme.vercel.app", want: false},
+		{name: "预览部署不匹配其他项目", env: "staging", cfg: corsTeam("acme"), origin: "https://evilflow-abc123xyz-acme.vercel.app", want: false},
+		{name: "未配置团队时不允许预览部署", env: "staging", cfg: corsTeam(""), origin: "https://nicheflow-abc123xyz-acme.vercel.app", want: false},
+		{name: "生产环境不允许预览部署", env: "production", cfg: corsTeam("acme"), origin: "https://nicheflow-abc123xyz-acme.vercel.app", want: false},
+		{name: "生产环境允许正式域名", env: "production", cfg: corsTeam(""), origin: "https://getnicheflow.com", want: true},
+		{name: "生产环境不允许本地开发地址", env: "production", cfg: corsTeam(""), origin: "http://localhost:3000", want: false},
+		{name: "开发环境允许本地开发地址", env: "development", cfg: corsTeam(""), origin: "http://localhost:3000", want: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := newCORSPolicy(tc.env, &tc.cfg)
+			if err != nil {
+				t.Fatalf("newCORSPolicy 返回 %v", err)
+			}
+			if got := policy.allowOrigin(tc.origin); got != tc.want {
+				t.Fatalf("allowOrigin(%q) 为 %v，期望 %v", tc.origin, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestNewCORSPolicy 校验无效的源和允许凭证时的限制
+func TestNewCORSPolicy(t *testing.T) {
+	cases := []struct {
+		name        string
+		origins     []string
+		team        string
+		credentials bool
+		wantErr     bool
+	}{
+		{name: "精确源允许凭证", origins: []string{"https://app.example.com"}, credentials: true},
+		{name: "所有源不允许凭证", origins: []string{"*"}, credentials: true, wantErr: true},
+		{name: "子域名通配符不允许凭证", origins: []string{"https://app.example.com", "https://*.example.com"}, credentials: true, wantErr: true},
+		{name: "标签内通配符不允许凭证", origins: []string{"https://nicheflow-*-acme.vercel.app"}, credentials: true, wantErr: true},
+		{name: "不允许凭证时可以使用通配符", origins: []string{"*", "https://*.example.com"}},
+		{name: "预览部署由团队标识显式开启", team: "acme", credentials: true},
+		{name: "缺少协议", origins: []string{"app.example.com"}, wantErr: true},
+		{name: "包含路径", origins: []string{"https://app.example.com/path"}, wantErr: true},
+		{name: "多个通配符", origins: []string{"https://*.*.example.com"}, wantErr: true},
+		{name: "通配符不在主机名中", origins: []string{"https://example.com:*"}, wantErr: true},
+		{name: "通配符之后没有域名", origins: []string{"https://example.*"}, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.CORSConfig{AllowOrigins: tc.origins, VercelTeam: tc.team, AllowCredentials: tc.credentials}
+			_, err := newCORSPolicy("staging", &cfg)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("newCORSPolicy 返回 %v，期望出错: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// TestCORSMiddleware 校验预检请求和普通请求的响应头
+func TestCORSMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.CORSConfig{AllowOrigins: []string{"https://app.example.com"}, AllowCredentials: true}
+	policy, err := newCORSPolicy("production", &cfg)
+	if err != nil {
+		t.Fatalf("newCORSPolicy 返回 %v", err)
+	}
+	m := &Manager{}
+	m.cors.Store(policy)
+
+	cases := []struct {
+		name            string
+		method          string
+		origin          string
+		wantStatus      int
+		wantAllowOrigin string
+	}{
+		{name: "允许的预检请求", method: http.MethodOptions, origin: "https://app.example.com", wantStatus: http.StatusNoContent, wantAllowOrigin: "https://app.example.com"},
+		{name: "不允许的预检请求", method: http.MethodOptions, origin: "https://evil.example.com", wantStatus: http.StatusForbidden},
+		{name: "允许的普通请求", method: http.MethodGet, origin: "https://app.example.com", wantStatus: http.StatusOK, wantAllowOrigin: "https://app.example.com"},
+		{name: "不允许的普通请求不带 CORS 头", method: http.MethodGet, origin: "https://evil.example.com", wantStatus: http.StatusOK},
+		{name: "同源请求", method: http.MethodGet, wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(m.CORS())
+			r.GET("/v1/user/profile", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tc.method, "/v1/user/profile", nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			if tc.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.wantStatus {
+				t.Fatalf("返回 %d，期望 %d", w.Code, tc.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tc.wantAllowOrigin {
+				t.Fatalf("Access-Control-Allow-Origin 为 %q，期望 %q", got, tc.wantAllowOrigin)
+			}
+			wantCredentials := ""
+			if tc.wantAllowOrigin != "" {
+				wantCredentials = "true"
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != wantCredentials {
+				t.Fatalf("Access-Control-Allow-Credentials 为 %q，期望 %q", got, wantCredentials)
+			}
+			if w.Header().Get("Vary") == "" {
+				t.Fatalf("响应缺少 Vary: Origin")
+			}
+		})
+	}
+}
+
+// corsOrigins 返回只配置了允许的源的 CORS 配置
+func corsOrigins(origins ...string) config.CORSConfig {
+	return config.CORSConfig{AllowOrigins: origins}
+}
+
+// corsTeam 返回使用默认源和指定 Vercel 团队的 CORS 配置
+func corsTeam(team string) config.CORSConfig {
+	return config.CORSConfig{VercelTeam: team, AllowCredentials: true}
+}
@@ -4,66 +4,135 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
-	"github.com/clerk/clerk-sdk-go/v2"
-	clerkhttp "github.com/clerk/clerk-sdk-go/v2/http"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+	"github.com/yszaryszar/NicheFlow/backend/internal/auth"
 	"github.com/yszaryszar/NicheFlow/backend/internal/config"
 	"github.com/yszaryszar/NicheFlow/backend/internal/model"
 	"github.com/yszaryszar/NicheFlow/backend/internal/service"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/database"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/ratelimit"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
 )
 
 // Manager 中间件管理器
 // 负责管理和配置所有中间件，提供统一的中间件访问接口
 type Manager struct {
-	cfg    *config.Config // 应用配置
-	logger *zap.Logger    // 日志记录器
-	rdb    *redis.Client  // Redis 客户端
+	cfg      *config.Config                  // 启动时的应用配置
+	logger   *zap.Logger                     // 日志记录器
+	limiter  ratelimit.Limiter               // 限流器
+	policy   atomic.Pointer[rateLimitPolicy] // 限流策略，配置重新加载时替换
+	cors     atomic.Pointer[corsPolicy]      // CORS 策略，配置重新加载时替换
+	identity auth.IdentityProvider           // 身份提供方
+	services *service.Services               // 业务服务
+
+	provisionGroup singleflight.Group // 合并同一身份的并发自动创建
 }
 
 // NewManager 创建一个新的中间件管理器实例
 //
 // 参数:
 //   - cfg: 应用配置对象，包含中间件相关的配置信息
+//   - services: 业务服务，用于认证和权限校验
+//   - rdb: Redis 客户端，用于跨实例限流，为 nil 时使用进程内限流
 //
 // 返回:
 //   - *Manager: 中间件管理器实例
+//   - error: 初始化日志或身份提供方失败时返回错误
 //
 // 说明:
 //
-//	该函数初始化中间件管理器，设置日志记录器和配置信息。
-//	管理器用于统一管理和配置所有中间件。
-func NewManager(cfg *config.Config) *Manager {
+//	该函数初始化中间件管理器，设置日志记录器、身份提供方和配置信息。
+//	管理器用于统一管理和配置所有中间件，不负责关闭 rdb。
+func NewManager(cfg *config.Config, services *service.Services, rdb *redis.Client) (*Manager, error) {
 	// 初始化日志
 	logger, err := zap.NewProduction()
 	if err != nil {
-		panic(fmt.Sprintf("初始化日志失败: %v", err))
+		return nil, fmt.Errorf("初始化日志失败: %w", err)
 	}
 
-	// 初始化 Redis 客户端
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
-	})
+	// 初始化限流策略和限流器，复用应用的 Redis 连接，Redis 出错时回退到进程内限流
+	policy, err := newRateLimitPolicy(&cfg.Middleware.RateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("速率限制配置无效: %w", err)
+	}
+	// 初始化 CORS 策略
+	cors, err := newCORSPolicy(cfg.App.Env, &cfg.Middleware.CORS)
+	if err != nil {
+		return nil, fmt.Errorf("CORS 配置无效: %w", err)
+	}
 
-	// 初始化 Clerk SDK
-	if cfg.Clerk.APIKey == "" {
-		panic("未设置 Clerk API Key")
+	var limiter ratelimit.Limiter = ratelimit.NewMemoryLimiter()
+	if rdb != nil {
+		limiter = ratelimit.NewFallbackLimiter(ratelimit.NewRedisLimiter(rdb, "rate_limit:"), limiter, 0, func(err error) {
+			logger.Warn("Redis 限流失败，暂时使用进程内限流", zap.Error(err))
+		})
+	} else {
+		logger.Warn("Redis 未初始化，使用进程内限流")
 	}
-	clerk.SetKey(cfg.Clerk.APIKey)
 
-	return &Manager{
-		cfg:    cfg,
-		logger: logger,
-		rdb:    rdb,
+	// 初始化身份提供方
+	identity, err := auth.NewIdentityProvider(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("初始化身份提供方失败: %w", err)
+	}
+	logger.Info("身份提供方已启用", zap.String("provider", identity.Name()))
+
+	m := &Manager{
+		cfg:      cfg,
+		logger:   logger,
+		limiter:  limiter,
+		identity: identity,
+		services: services,
 	}
+	m.policy.Store(policy)
+	m.cors.Store(cors)
+	return m, nil
+}
+
+// Watch 订阅运行时配置变更
+//
+// 参数:
+//   - w: 配置监视器
+//
+// 返回:
+//   - func(): 取消订阅
+//
+// 说明:
+//
+//	配置变更后重新构建限流策略和 CORS 策略，两者都构建成功后才会替换，
+//	任一失败时拒绝本次配置变更，继续使用原有策略。身份提供方等依赖密钥的组件不会重新加载。
+func (m *Manager) Watch(w *config.Watcher) func() {
+	return w.Subscribe(func(cfg *config.Config) (func(), error) {
+		policy, err := newRateLimitPolicy(&cfg.Middleware.RateLimit)
+		if err != nil {
+			return nil, fmt.Errorf("速率限制配置无效: %w", err)
+		}
+		cors, err := newCORSPolicy(cfg.App.Env, &cfg.Middleware.CORS)
+		if err != nil {
+			return nil, fmt.Errorf("CORS 配置无效: %w", err)
+		}
+
+		return func() {
+			m.policy.Store(policy)
+			m.cors.Store(cors)
+			m.logger.Info("中间件配置已更新")
+		}, nil
+	})
+}
+
+// IdentityProvider 返回当前启用的身份提供方
+func (m *Manager) IdentityProvider() auth.IdentityProvider {
+	return m.identity
 }
 
 // SetupMiddlewares 设置全局中间件
@@ -76,7 +145,9 @@ func NewManager(cfg *config.Config) *Manager {
 //	该方法为 Gin 引擎设置全局中间件，包括：
 //	1. 基础中间件（Recovery 和 Logger）
 //	2. CORS 中间件
-//	3. 速率限制中间件（如果启用）
+//	3. 按客户端 IP 的速率限制（IPRateLimit）
+//
+//	按用户和订阅计划的速率限制需要已认证主体，由路由在认证中间件之后通过 RateLimit 添加。
 func (m *Manager) SetupMiddlewares(r *gin.Engine) {
 	// 基础中间件
 	r.Use(gin.Recovery())
@@ -87,151 +158,448 @@ func (m *Manager) SetupMiddlewares(r *gin.Engine) {
 	// CORS 中间件
 	r.Use(m.CORS())
 
-	// 速率限制中间件（如果启用）
-	if m.cfg.Middleware.RateLimit.Enabled {
-		r.Use(m.RateLimit(
-			m.cfg.Middleware.RateLimit.Limit,
-			m.cfg.Middleware.RateLimit.Duration,
-		))
-	}
+	// 认证之前按客户端 IP 限流，覆盖全部路由，包括认证失败的请求
+	r.Use(m.IPRateLimit())
 }
 
-// CORS 返回 CORS 中间件
+// apiKeyHeader 携带 API 密钥的请求头，也可以通过 Authorization: Bearer 传入
+const apiKeyHeader = "X-API-Key"
+
+// 账号相关错误码，在 403 或 409 响应的 code 字段中返回，供客户端区分处理
+const (
+	ErrCodeAccountSuspended       = "account_suspended"        // 账号已暂停，可能带有 suspended_until
+	ErrCodeAccountBanned          = "account_banned"           // 账号已封禁
+	ErrCodeAccountPendingDeletion = "account_pending_deletion" // 账号等待删除
+	ErrCodeAccountInactive        = "account_inactive"         // 其他非正常状态
+
+	ErrCodeImpersonationForbidden = "impersonation_forbidden" // 模拟登录不能执行的操作
+	ErrCodeSessionRequired        = "session_required"        // 只能使用登录会话访问的操作
+	ErrCodeEmailConflict          = "email_conflict"          // 首次登录时邮箱已被其他账号使用
+)
+
+// GetAuthMiddleware 获取认证中间件
 //
 // 返回:
-//   - gin.HandlerFunc: CORS 中间件函数
+//   - gin.HandlerFunc: 认证中间件函数
 //
 // 说明:
 //
-//	该方法返回一个配置好的 CORS 中间件，用于处理跨域请求。
-//	中间件根据配置文件中的 CORS 设置来配置允许的源、方法、头部等。
-func (m *Manager) CORS() gin.HandlerFunc {
+//	该方法返回一个配置好的认证中间件，用于验证用户身份。
+//	请求可以携带身份提供方签发的会话令牌，也可以携带用户个人 API 密钥，
+//	两种方式都会得到同一用户的已认证主体（auth.Principal）并写入请求上下文，
+//	处理器应通过 auth.GetPrincipal 读取，而不是信任客户端传入的任何用户标识。
+//	只读 API 密钥只能访问 GET/HEAD/OPTIONS 请求。
+//	管理员签发的模拟登录令牌得到被模拟用户的主体，主体同时记录实际操作者，
+//	模拟期间的每个请求都会写入审计日志。
+//	暂停、封禁或等待删除的用户返回 403，并在 code 字段中给出对应的错误码。
+func (m *Manager) GetAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		corsConfig := m.cfg.Middleware.CORS
+		path := c.Request.URL.Path
+		m.logger.Info("处理请求",
+			zap.String("path", path),
+			zap.String("method", c.Request.Method))
 
-		// 设置 CORS 头
-		c.Writer.Header().Set("Access-Control-Allow-Origin", corsConfig.AllowOrigins[0])
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", corsConfig.AllowHeaders[0])
-		c.Writer.Header().Set("Access-Control-Allow-Methods", corsConfig.AllowMethods[0])
-		c.Writer.Header().Set("Access-Control-Max-Age", fmt.Sprintf("%d", corsConfig.MaxAge))
+		token := auth.BearerToken(c.GetHeader("Authorization"))
+		if apiKey := c.GetHeader(apiKeyHeader); apiKey != "" {
+			token = apiKey
+		}
 
-		if len(corsConfig.ExposeHeaders) > 0 {
-			c.Writer.Header().Set("Access-Control-Expose-Headers", corsConfig.ExposeHeaders[0])
+		var principal *auth.Principal
+		switch {
+		case service.IsImpersonationToken(token):
+			principal = m.authenticateImpersonation(c, token)
+		case service.IsAPIKey(token):
+			principal = m.authenticateAPIKey(c, token)
+		default:
+			principal = m.authenticateSession(c, token)
+		}
+		if principal == nil {
+			return
+		}
+		// 同一用户写入后的短时间内从主库读取，避免读到副本上的旧数据
+		c.Request = c.Request.WithContext(database.WithSession(c.Request.Context(), principal.User.ClerkID))
+		if principal.IsImpersonated() {
+			// 模拟期间的每个请求都写入审计日志，包括被拒绝的请求
+			defer m.recordImpersonatedRequest(c, principal)
+		}
+		if !m.checkUserStatus(c, principal.User) {
+			return
 		}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if !isSafeMethod(c.Request.Method) && !principal.HasScope(model.APIKeyScopeWrite) {
+			message := "API 密钥没有写权限"
+			if principal.IsImpersonated() {
+				message = "模拟登录会话没有写权限"
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": message,
+			})
 			return
 		}
 
+		// 将已认证主体存储到上下文
+		auth.SetPrincipal(c, principal)
 		c.Next()
 	}
 }
 
-// RateLimit 创建速率限制中间件
-func (m *Manager) RateLimit(limit int, duration time.Duration) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		key := fmt.Sprintf("rate_limit:%s", c.ClientIP())
-		ctx := context.Background()
-
-		// 获取当前计数
-		count, err := m.rdb.Get(ctx, key).Int()
-		if err != nil && err != redis.Nil {
-			m.logger.Error("获取速率限制计数失败", zap.Error(err))
-			c.Next()
-			return
+// authenticateImpersonation 验证模拟登录令牌，失败时终止请求并返回 nil
+//
+// 说明:
+//
+//	除会话本身有效外，发起模拟的管理员必须仍处于正常状态并拥有模拟权限，
+//	管理员被降级或封禁后其签发的会话立即失效。
+func (m *Manager) authenticateImpersonation(c *gin.Context, token string) *auth.Principal {
+	ctx := c.Request.Context()
+	path := c.Request.URL.Path
+
+	session, err := m.services.Impersonation.AuthenticateImpersonation(ctx, token)
+	if errors.Is(err, service.ErrInvalidImpersonation) || errors.Is(err, service.ErrImpersonationExpired) {
+		m.logger.Debug("模拟登录令牌验证失败",
+			zap.String("path", path),
+			zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return nil
+	}
+	if err != nil {
+		m.logger.Error("验证模拟登录令牌失败",
+			zap.String("path", path),
+			zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "验证模拟登录令牌失败",
+		})
+		return nil
+	}
+
+	actor, err := m.services.Users.GetCachedUserByID(ctx, session.ActorID)
+	if err == nil && actor.EffectiveStatus(time.Now()) != model.UserStatusActive {
+		err = fmt.Errorf("发起模拟的管理员状态为 %s", actor.Status)
+	}
+	if err == nil {
+		var granted bool
+		granted, err = m.services.RBAC.HasPermission(ctx, actor.Role, model.PermissionImpersonate)
+		if err == nil && !granted {
+			err = errors.New("发起模拟的管理员已没有模拟权限")
 		}
+	}
+	if err != nil {
+		m.logger.Warn("模拟登录会话的发起者不再有效",
+			zap.String("path", path),
+			zap.Uint("impersonationID", session.ID),
+			zap.Uint("actorID", session.ActorID),
+			zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return nil
+	}
 
-		if count >= limit {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error": "请求过于频繁，请稍后再试",
+	subject, err := m.services.Users.GetCachedUserByID(ctx, session.SubjectID)
+	if err != nil {
+		m.logger.Error("获取被模拟用户信息失败",
+			zap.String("path", path),
+			zap.Uint("impersonationID", session.ID),
+			zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return nil
+	}
+
+	m.logger.Info("模拟登录请求",
+		zap.String("path", path),
+		zap.Uint("impersonationID", session.ID),
+		zap.Uint("actorID", session.ActorID),
+		zap.Uint("subjectID", session.SubjectID))
+	return auth.NewImpersonationPrincipal(subject, session)
+}
+
+// recordImpersonatedRequest 在请求结束后记录模拟期间的请求
+func (m *Manager) recordImpersonatedRequest(c *gin.Context, principal *auth.Principal) {
+	ctx := context.WithoutCancel(c.Request.Context())
+	err := m.services.Impersonation.RecordImpersonatedRequest(ctx, principal.Impersonation,
+		c.ClientIP(), c.Request.Method, c.Request.URL.Path, c.Writer.Status())
+	if err != nil {
+		m.logger.Error("记录模拟登录请求失败",
+			zap.Uint("impersonationID", principal.ImpersonationID),
+			zap.Error(err))
+	}
+}
+
+// DenyImpersonation 获取禁止模拟登录访问的中间件
+//
+// 返回:
+//   - gin.HandlerFunc: 中间件函数
+//
+// 说明:
+//
+//	用于账单、删除账号、管理凭证等破坏性操作的路由，必须在认证中间件之后使用。
+//	模拟登录的主体访问这些路由时返回 403 和 impersonation_forbidden 错误码。
+func (m *Manager) DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, exists := auth.GetPrincipal(c)
+		if exists && principal.IsImpersonated() {
+			m.logger.Warn("拒绝模拟登录访问受保护操作",
+				zap.String("path", c.Request.URL.Path),
+				zap.Uint("impersonationID", principal.ImpersonationID),
+				zap.Uint("actorID", principal.ImpersonatorID))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "模拟登录不能执行此操作",
+				"code":  ErrCodeImpersonationForbidden,
 			})
 			return
 		}
-
-		// 增加计数
-		pipe := m.rdb.Pipeline()
-		pipe.Incr(ctx, key)
-		if count == 0 {
-			pipe.Expire(ctx, key, duration)
-		}
-		_, err = pipe.Exec(ctx)
-		if err != nil {
-			m.logger.Error("更新速率限制计数失败", zap.Error(err))
-		}
-
 		c.Next()
 	}
 }
 
-// GetAuthMiddleware 获取认证中间件
+// RequireSession 获取只允许登录会话访问的中间件
 //
 // 返回:
-//   - gin.HandlerFunc: 认证中间件函数
+//   - gin.HandlerFunc: 中间件函数
 //
 // 说明:
 //
-//	该方法返回一个配置好的认证中间件，用于验证用户身份。
-//	中间件会验证请求中的认证令牌，并将用户信息添加到请求上下文中。
-func (m *Manager) GetAuthMiddleware() gin.HandlerFunc {
+//	用于管理后台等高权限路由，必须在认证中间件之后使用。
+//	个人 API 密钥和模拟登录会话访问时返回 403 和 session_required 错误码，
+//	与处理器中管理 API 密钥的校验一致。
+func (m *Manager) RequireSession() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		path := c.Request.URL.Path
-		m.logger.Info("处理请求",
+		principal, exists := auth.GetPrincipal(c)
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "未授权访问",
+			})
+			return
+		}
+		if principal.Method != auth.MethodSession {
+			m.logger.Warn("拒绝非会话认证访问受保护操作",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("method", string(principal.Method)),
+				zap.Uint("userID", principal.UserID))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "此操作需要使用登录会话",
+				"code":  ErrCodeSessionRequired,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// authenticateSession 使用身份提供方验证会话令牌，失败时终止请求并返回 nil
+func (m *Manager) authenticateSession(c *gin.Context, token string) *auth.Principal {
+	path := c.Request.URL.Path
+
+	identity, err := m.identity.Authenticate(c.Request.Context(), token)
+	if err != nil {
+		m.logger.Debug("令牌验证失败",
 			zap.String("path", path),
-			zap.String("method", c.Request.Method))
+			zap.String("provider", m.identity.Name()),
+			zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return nil
+	}
 
-		// 使用 Clerk HTTP 中间件验证请求
-		handler := clerkhttp.RequireHeaderAuthorization()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// 从上下文获取会话声明
-			sessionClaims, ok := clerk.SessionClaimsFromContext(r.Context())
-			if !ok {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-					"error": "未授权访问",
-				})
-				return
-			}
+	// 获取用户信息，首次访问时自动创建
+	// 提前写入会话标识，刚更新过资料的用户在缓存失效后从主库加载
+	c.Request = c.Request.WithContext(database.WithSession(c.Request.Context(), identity.Subject))
+	user, err := m.services.Users.GetCachedUserByClerkID(c.Request.Context(), identity.Subject)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err = m.provisionUser(c.Request.Context(), identity)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// 本地用户已被删除，不为其重新创建账号
+			m.logger.Warn("已删除的用户尝试访问",
+				zap.String("path", path),
+				zap.String("clerkID", identity.Subject))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "未授权访问",
+			})
+			return nil
+		case errors.Is(err, service.ErrEmailInUse):
+			m.logger.Warn("自动创建用户失败，邮箱已被其他用户使用",
+				zap.String("path", path),
+				zap.String("clerkID", identity.Subject))
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+				"error": "邮箱已被其他账号使用",
+				"code":  ErrCodeEmailConflict,
+			})
+			return nil
+		}
+	}
+	if err != nil {
+		m.logger.Error("获取用户信息失败",
+			zap.String("path", path),
+			zap.String("clerkID", identity.Subject),
+			zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "获取用户信息失败",
+		})
+		return nil
+	}
 
-			// 获取用户服务
-			userService := service.NewUserService()
+	return auth.NewPrincipal(user, identity.SessionID, auth.MethodSession)
+}
 
-			// 获取用户信息
-			user, err := userService.GetUserByClerkID(r.Context(), sessionClaims.Subject)
+// provisionUser 根据已验证的身份自动创建本地用户
+//
+// 说明:
+//
+//	新用户可能在 /v1/auth/sync 执行前就访问受保护接口，此时根据会话声明创建用户；
+//	身份提供方支持 auth.ProfileFetcher 时会拉取完整资料补全邮箱、姓名等信息。
+//	同一进程内的并发请求通过 singleflight 合并，跨实例的并发由数据库唯一约束兜底。
+func (m *Manager) provisionUser(ctx context.Context, identity *auth.Identity) (*model.User, error) {
+	value, err, _ := m.provisionGroup.Do(identity.Subject, func() (interface{}, error) {
+		ctx := context.WithoutCancel(ctx)
+
+		profile := identity
+		if fetcher, ok := m.identity.(auth.ProfileFetcher); ok {
+			fetched, err := fetcher.FetchProfile(ctx, identity.Subject)
 			if err != nil {
-				m.logger.Error("获取用户信息失败",
-					zap.String("path", path),
-					zap.String("clerkID", sessionClaims.Subject),
+				m.logger.Warn("获取用户资料失败，使用令牌声明创建用户",
+					zap.String("clerkID", identity.Subject),
 					zap.Error(err))
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-					"error": "获取用户信息失败",
-				})
-				return
+			} else {
+				profile = fetched
 			}
+		}
 
-			// 将用户信息存储到上下文
-			c.Set("user", user)
-			c.Next()
-		}))
+		newUser := profile.Profile()
+		newUser.ClerkID = identity.Subject
+		newUser.LastSignInAt = time.Now()
+		user, err := m.services.Users.ProvisionUser(ctx, newUser)
+		if err != nil {
+			return nil, err
+		}
 
-		handler.ServeHTTP(c.Writer, c.Request)
+		m.logger.Info("自动创建用户",
+			zap.String("clerkID", identity.Subject),
+			zap.Uint("userID", user.ID))
+		return user, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return value.(*model.User), nil
 }
 
-// GetRequireRoles 获取角色验证中间件
+// authenticateAPIKey 验证用户个人 API 密钥，失败时终止请求并返回 nil
+func (m *Manager) authenticateAPIKey(c *gin.Context, token string) *auth.Principal {
+	path := c.Request.URL.Path
+
+	key, err := m.services.APIKeys.AuthenticateAPIKey(c.Request.Context(), token)
+	if errors.Is(err, service.ErrInvalidAPIKey) || errors.Is(err, service.ErrAPIKeyExpired) {
+		m.logger.Debug("API 密钥验证失败",
+			zap.String("path", path),
+			zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return nil
+	}
+	if err != nil {
+		m.logger.Error("验证 API 密钥失败",
+			zap.String("path", path),
+			zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "验证 API 密钥失败",
+		})
+		return nil
+	}
+
+	user, err := m.services.Users.GetCachedUserByID(c.Request.Context(), key.UserID)
+	if err != nil {
+		m.logger.Error("获取用户信息失败",
+			zap.String("path", path),
+			zap.Uint("apiKeyID", key.ID),
+			zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "未授权访问",
+		})
+		return nil
+	}
+
+	return auth.NewAPIKeyPrincipal(user, key)
+}
+
+// checkUserStatus 检查用户状态是否允许访问，不允许时终止请求并返回 false
+//
+// 说明:
+//
+//	不同状态返回不同的错误码，客户端可据此展示对应的提示。
+//	暂停已到期的用户会在这里恢复为正常状态并继续处理请求。
+func (m *Manager) checkUserStatus(c *gin.Context, user *model.User) bool {
+	now := time.Now()
+	status := user.EffectiveStatus(now)
+	if status != user.Status {
+		if _, err := m.services.Admin.RestoreExpiredSuspension(c.Request.Context(), user); err != nil {
+			m.logger.Error("恢复到期暂停用户失败",
+				zap.Uint("userID", user.ID),
+				zap.Error(err))
+		}
+	}
+
+	if status == model.UserStatusActive {
+		return true
+	}
+
+	body := gin.H{
+		"error": "账号不可用",
+		"code":  ErrCodeAccountInactive,
+	}
+	switch status {
+	case model.UserStatusSuspended:
+		body["error"] = "账号已被暂停"
+		body["code"] = ErrCodeAccountSuspended
+		if user.SuspendedUntil != nil {
+			body["suspended_until"] = user.SuspendedUntil.UTC()
+		}
+	case model.UserStatusBanned:
+		body["error"] = "账号已被封禁"
+		body["code"] = ErrCodeAccountBanned
+	case model.UserStatusPendingDeletion:
+		body["error"] = "账号正在等待删除"
+		body["code"] = ErrCodeAccountPendingDeletion
+	}
+
+	m.logger.Info("拒绝非正常状态用户的请求",
+		zap.String("path", c.Request.URL.Path),
+		zap.Uint("userID", user.ID),
+		zap.String("status", status))
+	c.AbortWithStatusJSON(http.StatusForbidden, body)
+	return false
+}
+
+// isSafeMethod 判断请求方法是否为只读方法
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// RequirePermission 获取权限验证中间件
 //
 // 参数:
-//   - roles: 允许访问的角色列表
+//   - permissions: 访问所需的权限列表，必须全部拥有
 //
 // 返回:
-//   - gin.HandlerFunc: 角色验证中间件函数
+//   - gin.HandlerFunc: 权限验证中间件函数
 //
 // 说明:
 //
-//	该方法返回一个配置好的角色验证中间件，用于检查用户权限。
-//	中间件会验证用户是否具有所需的角色权限。
-func (m *Manager) GetRequireRoles(roles ...string) gin.HandlerFunc {
+//	该方法返回一个权限验证中间件，根据用户角色在数据库中的权限配置进行校验，
+//	例如 RequirePermission("users:write")。必须在认证中间件之后使用。
+func (m *Manager) RequirePermission(permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		user, exists := c.Get("user")
+		principal, exists := auth.GetPrincipal(c)
 		if !exists {
 			m.logger.Warn("未找到用户信息",
 				zap.String("path", c.Request.URL.Path),
@@ -242,26 +610,31 @@ func (m *Manager) GetRequireRoles(roles ...string) gin.HandlerFunc {
 			return
 		}
 
-		userModel := user.(*model.User)
-		hasRole := false
-		for _, role := range roles {
-			if userModel.Role == role {
-				hasRole = true
-				break
+		for _, permission := range permissions {
+			granted, err := m.services.RBAC.HasPermission(c.Request.Context(), principal.Role, permission)
+			if err != nil {
+				m.logger.Error("查询角色权限失败",
+					zap.String("path", c.Request.URL.Path),
+					zap.String("role", principal.Role),
+					zap.Error(err),
+				)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"error": "查询权限失败",
+				})
+				return
+			}
+			if !granted {
+				m.logger.Warn("用户权限不足",
+					zap.String("path", c.Request.URL.Path),
+					zap.String("user_id", strconv.FormatUint(uint64(principal.UserID), 10)),
+					zap.String("required_permission", permission),
+					zap.String("user_role", principal.Role),
+				)
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error": "权限不足",
+				})
+				return
 			}
-		}
-
-		if !hasRole {
-			m.logger.Warn("用户权限不足",
-				zap.String("path", c.Request.URL.Path),
-				zap.String("user_id", strconv.FormatUint(uint64(userModel.ID), 10)),
-				zap.String("required_roles", fmt.Sprintf("%v", roles)),
-				zap.String("user_role", userModel.Role),
-			)
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"error": "权限不足",
-			})
-			return
 		}
 
 		c.Next()
@@ -272,14 +645,10 @@ func (m *Manager) GetRequireRoles(roles ...string) gin.HandlerFunc {
 //
 // 说明:
 //
-//	该方法执行中间件管理器的清理工作，包括：
-//	1. 同步日志记录器
-//	2. 关闭其他可能的资源连接
+//	该方法同步日志记录器。限流器使用的 Redis 连接由创建管理器的调用方持有，
+//	在应用关闭时统一释放，这里不会关闭。
 func (m *Manager) Close() {
 	if m.logger != nil {
 		m.logger.Sync()
 	}
-	if m.rdb != nil {
-		m.rdb.Close()
-	}
 }
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yszaryszar/NicheFlow/backend/internal/auth"
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"go.uber.org/zap"
+)
+
+// TestRequireSession 校验管理路由只接受登录会话
+func TestRequireSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := &Manager{logger: zap.NewNop()}
+	user := &model.User{ID: 1, ClerkID: "user_1"}
+
+	cases := []struct {
+		name      string
+		principal *auth.Principal
+		want      int
+	}{
+		{name: "登录会话", principal: auth.NewPrincipal(user, "sess_1", auth.MethodSession), want: http.StatusOK},
+		{name: "个人 API 密钥", principal: auth.NewAPIKeyPrincipal(user, &model.APIKey{ID: 1, Scopes: "read,write"}), want: http.StatusForbidden},
+		{name: "模拟登录会话", principal: auth.NewImpersonationPrincipal(user, &model.ImpersonationSession{ID: 1, ActorID: 2}), want: http.StatusForbidden},
+		{name: "未认证", principal: nil, want: http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/v1/admin/users", func(c *gin.Context) {
+				if tc.principal != nil {
+					auth.SetPrincipal(c, tc.principal)
+				}
+			}, m.RequireSession(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/admin/users", nil))
+			if w.Code != tc.want {
+				t.Fatalf("返回 %d，期望 %d", w.Code, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yszaryszar/NicheFlow/backend/internal/auth"
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/ratelimit"
+	"go.uber.org/zap"
+)
+
+// 限流策略常量
+const (
+	freePlan            = "free"    // 未订阅用户使用的计划名称
+	defaultRateLimitTag = "default" // 未匹配路由组时的计数名称
+	perIPRateLimitTag   = "per_ip"  // 认证之前按 IP 限制的计数名称
+)
+
+// rateLimitPolicy 由配置编译得到的限流策略
+type rateLimitPolicy struct {
+	enabled bool
+	base    ratelimit.Limit            // 默认规则
+	plans   map[string]ratelimit.Limit // 按订阅计划覆盖的规则
+	routes  []routeRateLimit           // 路由组规则
+	perIP   *ratelimit.Limit           // 认证之前按 IP 的规则，为 nil 时不限制
+}
+
+// routeRateLimit 编译后的路由组规则
+type routeRateLimit struct {
+	name    string
+	prefix  string
+	methods map[string]bool
+	base    ratelimit.Limit
+	plans   map[string]ratelimit.Limit
+}
+
+// newRateLimitPolicy 编译并校验限流配置
+//
+// 说明:
+//
+//	规则按 默认规则 → 订阅计划 和 默认规则 → 路由组 → 路由组内订阅计划 逐级合并，
+//	下一级中为零值的字段沿用上一级的值。所有规则在启动时校验，配置错误时拒绝启动。
+func newRateLimitPolicy(cfg *config.RateLimitConfig) (*rateLimitPolicy, error) {
+	policy := &rateLimitPolicy{
+		enabled: cfg.Enabled,
+		base: ratelimit.Limit{
+			Algorithm: ratelimit.Algorithm(cfg.Algorithm),
+			Rate:      cfg.Limit,
+			Period:    cfg.Duration,
+			Burst:     cfg.Burst,
+		},
+	}
+	if !policy.enabled {
+		return policy, nil
+	}
+	if err := policy.base.Validate(); err != nil {
+		return nil, fmt.Errorf("默认规则: %w", err)
+	}
+
+	plans, err := compilePlanLimits(policy.base, cfg.Plans)
+	if err != nil {
+		return nil, err
+	}
+	policy.plans = plans
+
+	if cfg.PerIP.Limit > 0 {
+		perIP := mergeRateLimitRule(ratelimit.Limit{Algorithm: policy.base.Algorithm, Period: policy.base.Period}, cfg.PerIP)
+		if err := perIP.Validate(); err != nil {
+			return nil, fmt.Errorf("per_ip 规则: %w", err)
+		}
+		policy.perIP = &perIP
+	}
+
+	names := make(map[string]bool, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		if route.Name == "" || route.Prefix == "" {
+			return nil, fmt.Errorf("路由组规则必须设置 name 和 prefix")
+		}
+		if names[route.Name] || route.Name == defaultRateLimitTag {
+			return nil, fmt.Errorf("路由组名称重复: %s", route.Name)
+		}
+		names[route.Name] = true
+
+		compiled := routeRateLimit{
+			name:   route.Name,
+			prefix: route.Prefix,
+			base:   mergeRateLimitRule(policy.base, route.RateLimitRule),
+		}
+		if err := compiled.base.Validate(); err != nil {
+			return nil, fmt.Errorf("路由组 %s: %w", route.Name, err)
+		}
+		if compiled.plans, err = compilePlanLimits(compiled.base, route.Plans); err != nil {
+			return nil, fmt.Errorf("路由组 %s: %w", route.Name, err)
+		}
+		if len(route.Methods) > 0 {
+			compiled.methods = make(map[string]bool, len(route.Methods))
+			for _, method := range route.Methods {
+				compiled.methods[strings.ToUpper(method)] = true
+			}
+		}
+		policy.routes = append(policy.routes, compiled)
+	}
+
+	return policy, nil
+}
+
+// compilePlanLimits 将订阅计划规则与上一级规则合并并校验
+func compilePlanLimits(parent ratelimit.Limit, rules map[string]config.RateLimitRule) (map[string]ratelimit.Limit, error) {
+	plans := make(map[string]ratelimit.Limit, len(rules))
+	for plan, rule := range rules {
+		limit := mergeRateLimitRule(parent, rule)
+		if err := limit.Validate(); err != nil {
+			return nil, fmt.Errorf("订阅计划 %s: %w", plan, err)
+		}
+		plans[strings.ToLower(plan)] = limit
+	}
+	return plans, nil
+}
+
+// mergeRateLimitRule 用规则中的非零字段覆盖上一级规则
+func mergeRateLimitRule(parent ratelimit.Limit, rule config.RateLimitRule) ratelimit.Limit {
+	if rule.Algorithm != "" {
+		parent.Algorithm = ratelimit.Algorithm(rule.Algorithm)
+	}
+	if rule.Limit > 0 {
+		parent.Rate = rule.Limit
+	}
+	if rule.Duration > 0 {
+		parent.Period = rule.Duration
+	}
+	if rule.Burst > 0 {
+		parent.Burst = rule.Burst
+	}
+	return parent
+}
+
+// resolve 根据请求和已认证主体选择限流规则
+//
+// 返回:
+//   - string: 计数名称，匹配路由组时为路由组名称，否则为 default
+//   - ratelimit.Limit: 适用的限流规则
+func (p *rateLimitPolicy) resolve(c *gin.Context, principal *auth.Principal) (string, ratelimit.Limit) {
+	plan := ""
+	if principal != nil {
+		plan = strings.ToLower(principal.Plan)
+		if plan == "" {
+			plan = freePlan
+		}
+	}
+
+	path := c.FullPath()
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+
+	var matched *routeRateLimit
+	for i := range p.routes {
+		route := &p.routes[i]
+		if !strings.HasPrefix(path, route.prefix) {
+			continue
+		}
+		if route.methods != nil && !route.methods[c.Request.Method] {
+			continue
+		}
+		if matched == nil || len(route.prefix) > len(matched.prefix) {
+			matched = route
+		}
+	}
+
+	if matched != nil {
+		if limit, ok := matched.plans[plan]; ok {
+			return matched.name, limit
+		}
+		return matched.name, matched.base
+	}
+	if limit, ok := p.plans[plan]; ok {
+		return defaultRateLimitTag, limit
+	}
+	return defaultRateLimitTag, p.base
+}
+
+// rateLimitSubject 返回计数主体：API 密钥请求按密钥，其他已认证请求按用户，未认证请求按 IP
+func rateLimitSubject(c *gin.Context, principal *auth.Principal) string {
+	switch {
+	case principal == nil:
+		return "ip:" + c.ClientIP()
+	case principal.Method == auth.MethodAPIKey:
+		return "key:" + strconv.FormatUint(uint64(principal.APIKeyID), 10)
+	default:
+		return "user:" + strconv.FormatUint(uint64(principal.UserID), 10)
+	}
+}
+
+// IPRateLimit 获取认证之前按客户端 IP 的速率限制中间件
+//
+// 返回:
+//   - gin.HandlerFunc: 速率限制中间件函数
+//
+// 说明:
+//
+//	在 SetupMiddlewares 中全局注册，在认证之前对所有请求按 IP 计数，
+//	限制无效令牌、API 密钥猜测以及 Webhook、健康检查等不需要认证的路由。
+//	该限制应明显高于单个用户的限制，只用于兜底；按用户和订阅计划的限制由 RateLimit 在认证之后执行。
+func (m *Manager) IPRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy := m.policy.Load()
+		if !policy.enabled || policy.perIP == nil {
+			c.Next()
+			return
+		}
+		if m.allow(c, perIPRateLimitTag+":ip:"+c.ClientIP(), *policy.perIP) {
+			c.Next()
+		}
+	}
+}
+
+// RateLimit 获取速率限制中间件
+//
+// 返回:
+//   - gin.HandlerFunc: 速率限制中间件函数
+//
+// 说明:
+//
+//	应放在认证中间件之后，已认证请求按用户或 API 密钥计数，共用 NAT 的用户互不影响；
+//	认证失败的请求不会到达这里，由 IPRateLimit 在认证之前限制。
+//	未认证的路由也可以使用，此时按客户端 IP 计数。规则按路由组和订阅计划从配置中选择。
+//	每个响应都带有 X-RateLimit-Limit、X-RateLimit-Remaining
+//	和 X-RateLimit-Reset（距离额度完全恢复的秒数），超出限制时返回 429 和 Retry-After。
+//	Redis 不可用时由进程内限流器继续限流，而不是直接放行。
+func (m *Manager) RateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy := m.policy.Load()
+		if !policy.enabled {
+			c.Next()
+			return
+		}
+
+		principal, _ := auth.GetPrincipal(c)
+		bucket, limit := policy.resolve(c, principal)
+		if m.allow(c, bucket+":"+rateLimitSubject(c, principal), limit) {
+			c.Next()
+		}
+	}
+}
+
+// allow 按规则计数并写入响应头，超出限制时终止请求并返回 false
+func (m *Manager) allow(c *gin.Context, key string, limit ratelimit.Limit) bool {
+	result, err := m.limiter.Allow(c.Request.Context(), key, limit)
+	if err != nil {
+		// 只有限流规则无效时才会出错，规则在启动时已经校验
+		m.logger.Error("速率限制判断失败", zap.String("key", key), zap.Error(err))
+		return true
+	}
+
+	setRateLimitHeaders(c, result)
+	if !result.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error": "请求过于频繁，请稍后再试",
+		})
+		return false
+	}
+	return true
+}
+
+// setRateLimitHeaders 写入速率限制响应头
+func setRateLimitHeaders(c *gin.Context, result *ratelimit.Result) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+// ceilSeconds 将时长向上取整为秒
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// API 密钥权限范围
+const (
+	APIKeyScopeRead  = "read"  // 只读访问
+	APIKeyScopeWrite = "write" // 读写访问
+)
+
+// APIKey 用户个人 API 密钥
+// 只保存密钥的哈希值，明文仅在创建时返回一次
+type APIKey struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	UserID     uint       `gorm:"index" json:"user_id"`                       // 关联的用户ID
+	Name       string     `gorm:"type:varchar(100)" json:"name"`              // 密钥名称
+	Prefix     string     `gorm:"type:varchar(20);uniqueIndex" json:"prefix"` // 密钥前缀，用于查找和展示
+	SecretHash string     `gorm:"type:varchar(64)" json:"-"`                  // 完整密钥的 SHA-256 哈希
+	Scopes     string     `gorm:"type:varchar(255)" json:"scopes"`            // 权限范围，逗号分隔
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`                       // 过期时间，为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`                     // 最后使用时间
+}
+
+// TableName 指定 API 密钥表名
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList 返回权限范围列表
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return nil
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// IsExpired 判断密钥在指定时间是否已过期
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
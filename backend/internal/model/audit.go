@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// AuditLog 审计日志
+// 记录管理员对用户的操作，包括操作者、被操作用户、操作原因和变更详情
+type AuditLog struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	ActorID   uint   `gorm:"index" json:"actor_id"`                // 操作者用户 ID
+	SubjectID uint   `gorm:"index" json:"subject_id"`              // 被操作的用户 ID
+	Action    string `gorm:"type:varchar(50);index" json:"action"` // 操作类型
+	Reason    string `gorm:"type:varchar(255)" json:"reason"`      // 操作原因
+	Detail    string `gorm:"type:text" json:"detail,omitempty"`    // 变更详情（JSON）
+	IP        string `gorm:"type:varchar(64)" json:"ip,omitempty"` // 操作者 IP
+}
+
+// TableName 指定审计日志表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
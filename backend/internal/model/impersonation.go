@@ -0,0 +1,40 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// ImpersonationSession 模拟登录会话
+// 客服或管理员以目标用户身份访问系统时使用，只保存令牌的哈希值
+type ImpersonationSession struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ActorID   uint       `gorm:"index" json:"actor_id"`                 // 发起模拟的管理员用户 ID
+	SubjectID uint       `gorm:"index" json:"subject_id"`               // 被模拟的用户 ID
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex" json:"-"` // 令牌的 SHA-256 哈希
+	Scopes    string     `gorm:"type:varchar(255)" json:"scopes"`       // 权限范围，逗号分隔，取值同 API 密钥
+	Reason    string     `gorm:"type:varchar(255)" json:"reason"`       // 发起原因，例如工单编号
+	ExpiresAt time.Time  `gorm:"index" json:"expires_at"`               // 过期时间
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`                  // 提前结束的时间
+}
+
+// TableName 指定模拟登录会话表名
+func (ImpersonationSession) TableName() string {
+	return "impersonation_sessions"
+}
+
+// ScopeList 返回权限范围列表
+func (s *ImpersonationSession) ScopeList() []string {
+	if s.Scopes == "" {
+		return nil
+	}
+	return strings.Split(s.Scopes, ",")
+}
+
+// IsActive 判断会话在指定时间是否仍然有效
+func (s *ImpersonationSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,90 @@
+package model
+
+import "time"
+
+// 内置角色
+const (
+	RoleUser         = "user"          // 普通用户
+	RoleSupport      = "support"       // 客服，只读访问用户记录，可以模拟用户排查问题
+	RoleBillingAdmin = "billing-admin" // 账单管理员
+	RoleSuperAdmin   = "super-admin"   // 超级管理员，拥有全部权限
+)
+
+// 内置权限
+const (
+	PermissionUsersRead   = "users:read"        // 查看用户记录
+	PermissionUsersWrite  = "users:write"       // 修改用户记录
+	PermissionRolesRead   = "roles:read"        // 查看角色和权限
+	PermissionRolesWrite  = "roles:write"       // 分配用户角色
+	PermissionBillingRead = "billing:read"      // 查看订阅和账单
+	PermissionBillingEdit = "billing:write"     // 修改订阅和使用限制
+	PermissionAuditRead   = "audit:read"        // 查看审计日志
+	PermissionImpersonate = "users:impersonate" // 以用户身份模拟登录
+	PermissionConfigRead  = "config:read"       // 查看运行配置
+)
+
+// Role 角色
+type Role struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name        string       `gorm:"type:varchar(50);uniqueIndex" json:"name"`       // 角色名称，对应 User.Role
+	Description string       `gorm:"type:varchar(255)" json:"description"`           // 角色描述
+	BuiltIn     bool         `gorm:"default:false" json:"built_in"`                  // 是否为内置角色
+	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions"` // 角色拥有的权限
+}
+
+// Permission 权限
+type Permission struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Name        string `gorm:"type:varchar(50);uniqueIndex" json:"name"` // 权限名称，格式为 资源:操作
+	Description string `gorm:"type:varchar(255)" json:"description"`     // 权限描述
+}
+
+// RolePermission 角色与权限的关联
+type RolePermission struct {
+	RoleID       uint `gorm:"primaryKey" json:"role_id"`
+	PermissionID uint `gorm:"primaryKey" json:"permission_id"`
+}
+
+// TableName 指定角色表名
+func (Role) TableName() string {
+	return "roles"
+}
+
+// TableName 指定权限表名
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// TableName 指定角色权限关联表名
+func (RolePermission) TableName() string {
+	return "role_permissions"
+}
+
+// BuiltInPermissions 内置权限及其描述
+var BuiltInPermissions = map[string]string{
+	PermissionUsersRead:   "查看用户记录",
+	PermissionUsersWrite:  "修改用户记录",
+	PermissionRolesRead:   "查看角色和权限",
+	PermissionRolesWrite:  "分配用户角色",
+	PermissionBillingRead: "查看订阅和账单",
+	PermissionBillingEdit: "修改订阅和使用限制",
+	PermissionAuditRead:   "查看审计日志",
+	PermissionImpersonate: "以用户身份模拟登录",
+	PermissionConfigRead:  "查看运行配置",
+}
+
+// BuiltInRoles 内置角色及其权限，超级管理员拥有全部内置权限
+var BuiltInRoles = map[string]struct {
+	Description string
+	Permissions []string
+}{
+	RoleUser:         {Description: "普通用户", Permissions: nil},
+	RoleSupport:      {Description: "客服，只读访问用户记录", Permissions: []string{PermissionUsersRead, PermissionAuditRead, PermissionImpersonate}},
+	RoleBillingAdmin: {Description: "账单管理员", Permissions: []string{PermissionUsersRead, PermissionBillingRead, PermissionBillingEdit}},
+	RoleSuperAdmin:   {Description: "超级管理员", Permissions: nil},
+}
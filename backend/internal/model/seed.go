@@ -0,0 +1,62 @@
+// Package model 提供了应用的数据模型定义
+package model
+
+import "gorm.io/gorm"
+
+// SeedRBAC 同步内置角色和权限
+//
+// 参数:
+//   - db: 数据库连接
+//
+// 返回:
+//   - error: 同步过程中的错误，如果成功则为 nil
+//
+// 说明:
+//
+//	该函数可重复执行：缺失的权限和角色会被创建，内置角色的权限会被重置为代码中的定义。
+//	旧版本使用的 admin 角色会迁移为 super-admin。表结构由 migrations 目录中的迁移维护，
+//	该函数只同步数据，在每次启动时执行。
+func SeedRBAC(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		permissions := make(map[string]Permission, len(BuiltInPermissions))
+		for name, description := range BuiltInPermissions {
+			perm := Permission{Name: name, Description: description}
+			if err := tx.Where("name = ?", name).Assign(Permission{Description: description}).FirstOrCreate(&perm).Error; err != nil {
+				return err
+			}
+			permissions[name] = perm
+		}
+
+		for name, def := range BuiltInRoles {
+			role := Role{Name: name}
+			if err := tx.Where("name = ?", name).
+				Assign(Role{Description: def.Description, BuiltIn: true}).
+				FirstOrCreate(&role).Error; err != nil {
+				return err
+			}
+
+			var granted []Permission
+			if name == RoleSuperAdmin {
+				for _, perm := range permissions {
+					granted = append(granted, perm)
+				}
+			} else {
+				for _, permName := range def.Permissions {
+					granted = append(granted, permissions[permName])
+				}
+			}
+			association := tx.Model(&role).Association("Permissions")
+			if len(granted) == 0 {
+				if err := association.Clear(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := association.Replace(granted); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&User{}).Where("role = ?", "admin").Update("role", RoleSuperAdmin).Error
+	})
+}
@@ -37,6 +37,14 @@ type UserPreference struct {
 	Value  string `gorm:"type:varchar(255)" json:"value"` // 偏好设置值
 }
 
+// 用户状态
+const (
+	UserStatusActive          = "active"           // 正常
+	UserStatusSuspended       = "suspended"        // 暂停使用
+	UserStatusBanned          = "banned"           // 封禁
+	UserStatusPendingDeletion = "pending_deletion" // 等待删除
+)
+
 // User 用户模型
 // 存储用户的基本信息、认证状态、订阅信息和使用限制等
 // 该模型与 Clerk 认证服务集成，同时支持本地用户管理
@@ -49,7 +57,7 @@ type User struct {
 
 	// Clerk 用户基础信息
 	ClerkID       string    `gorm:"type:varchar(100);uniqueIndex" json:"clerk_id"`
-	Email         string    `gorm:"type:varchar(100);uniqueIndex" json:"email"`
+	Email         string    `gorm:"type:varchar(100);uniqueIndex:idx_users_email,where:email <> '' AND deleted_at IS NULL" json:"email"`
 	Username      string    `gorm:"type:varchar(50)" json:"username"`
 	FirstName     string    `gorm:"type:varchar(50)" json:"first_name"`
 	LastName      string    `gorm:"type:varchar(50)" json:"last_name"`
@@ -70,8 +78,10 @@ type User struct {
 	NotificationWeb    bool   `gorm:"default:true" json:"notification_web"`
 
 	// 用户状态
-	Role   string `gorm:"type:varchar(20);default:'user'" json:"role"`
-	Status string `gorm:"type:varchar(20);default:'active'" json:"status"`
+	Role           string     `gorm:"type:varchar(20);default:'user'" json:"role"`
+	Status         string     `gorm:"type:varchar(20);default:'active'" json:"status"`
+	StatusReason   string     `gorm:"type:varchar(255)" json:"status_reason,omitempty"` // 最近一次状态变更的原因
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`                        // 暂停截止时间，为空表示无限期暂停
 
 	// 订阅相关
 	SubscriptionID     string     `gorm:"type:varchar(100)" json:"subscription_id"`
@@ -98,6 +108,15 @@ func (User) TableName() string {
 	return "users"
 }
 
+// EffectiveStatus 返回用户在指定时间的实际状态
+// 暂停已到期的用户视为正常状态，即使数据库中的状态尚未恢复
+func (u *User) EffectiveStatus(now time.Time) string {
+	if u.Status == UserStatusSuspended && u.SuspendedUntil != nil && !now.Before(*u.SuspendedUntil) {
+		return UserStatusActive
+	}
+	return u.Status
+}
+
 // TableName 指定社交账号表名
 func (SocialAccount) TableName() string {
 	return "social_accounts"
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// WebhookEvent 已处理的 Webhook 事件
+// 以事件 ID 作为唯一键，保证同一事件重复投递时只处理一次
+type WebhookEvent struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	EventID string `gorm:"type:varchar(100);uniqueIndex" json:"event_id"` // 事件唯一标识（svix-id）
+	Source  string `gorm:"type:varchar(20)" json:"source"`                // 事件来源(clerk)
+	Type    string `gorm:"type:varchar(50)" json:"type"`                  // 事件类型
+}
+
+// TableName 指定 Webhook 事件表名
+func (WebhookEvent) TableName() string {
+	return "webhook_events"
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dialect 不同数据库实现之间的差异
+type dialect struct {
+	rowLocks bool // 是否支持 SELECT ... FOR UPDATE
+}
+
+// gormStore 基于 GORM 的通用实现，PostgreSQL 和 SQLite 实现只在 dialect 上不同
+type gormStore struct {
+	db      *gorm.DB
+	dialect dialect
+}
+
+// Users 返回用户存取接口
+func (s *gormStore) Users() UserRepository {
+	return gormUsers{s}
+}
+
+// SocialAccounts 返回社交账号存取接口
+func (s *gormStore) SocialAccounts() SocialAccountRepository {
+	return gormSocialAccounts{s}
+}
+
+// Preferences 返回自定义偏好设置存取接口
+func (s *gormStore) Preferences() PreferenceRepository {
+	return gormPreferences{s}
+}
+
+// WebhookEvents 返回 Webhook 事件存取接口
+func (s *gormStore) WebhookEvents() WebhookEventRepository {
+	return gormWebhookEvents{s}
+}
+
+// Transaction 在事务中执行 fn
+func (s *gormStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx, dialect: s.dialect})
+	})
+}
+
+// conn 返回绑定了上下文的数据库连接
+func (s *gormStore) conn(ctx context.Context) *gorm.DB {
+	return s.db.WithContext(ctx)
+}
+
+// userWriteError 将用户表邮箱索引的唯一约束冲突转换为 ErrDuplicateEmail
+// 驱动只报告唯一约束冲突，需要根据错误信息中的索引或列名区分邮箱和 Clerk ID：
+// PostgreSQL 报告索引名 idx_users_email，SQLite 报告列名 users.email
+func (s *gormStore) userWriteError(err error) error {
+	if err == nil {
+		return nil
+	}
+	translator, ok := s.db.Dialector.(gorm.ErrorTranslator)
+	if ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) &&
+		(strings.Contains(err.Error(), "idx_users_email") || strings.Contains(err.Error(), "users.email")) {
+		return ErrDuplicateEmail
+	}
+	return err
+}
+
+// gormUsers 用户存取的 GORM 实现
+type gormUsers struct {
+	*gormStore
+}
+
+// FindByID 按本地 ID 查询用户
+func (r gormUsers) FindByID(ctx context.Context, id uint) (*model.User, error) {
+	var user model.User
+	err := r.conn(ctx).
+		Preload("SocialAccounts").
+		Preload("Preferences").
+		First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByClerkID 按 Clerk ID 查询用户
+func (r gormUsers) FindByClerkID(ctx context.Context, clerkID string) (*model.User, error) {
+	var user model.User
+	err := r.conn(ctx).
+		Preload("SocialAccounts").
+		Preload("Preferences").
+		Where("clerk_id = ?", clerkID).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByClerkIDForUpdate 按 Clerk ID 查询并锁定用户
+func (r gormUsers) FindByClerkIDForUpdate(ctx context.Context, clerkID string) (*model.User, error) {
+	return r.findForUpdate(r.conn(ctx), clerkID)
+}
+
+// FindByClerkIDUnscopedForUpdate 按 Clerk ID 查询并锁定用户，包括已软删除的用户
+func (r gormUsers) FindByClerkIDUnscopedForUpdate(ctx context.Context, clerkID string) (*model.User, error) {
+	return r.findForUpdate(r.conn(ctx).Unscoped(), clerkID)
+}
+
+// findForUpdate 在给定查询上按 Clerk ID 查询并锁定用户
+func (r gormUsers) findForUpdate(query *gorm.DB, clerkID string) (*model.User, error) {
+	if r.dialect.rowLocks {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var user model.User
+	if err := query.Where("clerk_id = ?", clerkID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindColumns 按 Clerk ID 查询用户的部分字段
+func (r gormUsers) FindColumns(ctx context.Context, clerkID string, columns ...string) (*model.User, error) {
+	var user model.User
+	if err := r.conn(ctx).Select(columns).Where("clerk_id = ?", clerkID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Create 创建用户
+func (r gormUsers) Create(ctx context.Context, user *model.User) error {
+	return r.userWriteError(r.conn(ctx).Create(user).Error)
+}
+
+// CreateIfNotExists 使用 ON CONFLICT (clerk_id) DO NOTHING 创建用户
+// 冲突目标只包含 clerk_id，邮箱冲突不会被忽略，而是返回 ErrDuplicateEmail
+func (r gormUsers) CreateIfNotExists(ctx context.Context, user *model.User) (bool, error) {
+	result := r.conn(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "clerk_id"}},
+		DoNothing: true,
+	}).Create(user)
+	if result.Error != nil {
+		return false, r.userWriteError(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Save 保存用户的全部字段
+func (r gormUsers) Save(ctx context.Context, user *model.User) error {
+	return r.userWriteError(r.conn(ctx).Save(user).Error)
+}
+
+// Update 更新用户的部分字段
+func (r gormUsers) Update(ctx context.Context, id uint, fields map[string]interface{}) error {
+	return r.userWriteError(r.conn(ctx).Model(&model.User{ID: id}).Updates(fields).Error)
+}
+
+// IncrementUsage 原子地增加使用次数
+func (r gormUsers) IncrementUsage(ctx context.Context, id uint, lastResetTime time.Time) error {
+	return r.conn(ctx).Model(&model.User{ID: id}).
+		Updates(map[string]interface{}{
+			"usage_count":     gorm.Expr("usage_count + 1"),
+			"monthly_count":   gorm.Expr("monthly_count + 1"),
+			"last_reset_time": lastResetTime,
+		}).Error
+}
+
+// Delete 软删除用户
+func (r gormUsers) Delete(ctx context.Context, id uint) error {
+	return r.conn(ctx).Delete(&model.User{ID: id}).Error
+}
+
+// Restore 清除用户的软删除标记并更新部分字段
+func (r gormUsers) Restore(ctx context.Context, id uint, fields map[string]interface{}) error {
+	updates := make(map[string]interface{}, len(fields)+1)
+	for column, value := range fields {
+		updates[column] = value
+	}
+	updates["deleted_at"] = nil
+
+	return r.userWriteError(r.conn(ctx).Unscoped().Model(&model.User{ID: id}).
+		Where("deleted_at IS NOT NULL").
+		Updates(updates).Error)
+}
+
+// gormSocialAccounts 社交账号存取的 GORM 实现
+type gormSocialAccounts struct {
+	*gormStore
+}
+
+// ListByUser 查询用户的全部社交账号
+func (r gormSocialAccounts) ListByUser(ctx context.Context, userID uint) ([]model.SocialAccount, error) {
+	var accounts []model.SocialAccount
+	err := r.conn(ctx).Where("user_id = ?", userID).Order("id").Find(&accounts).Error
+	return accounts, err
+}
+
+// ListByClerkID 按用户的 Clerk ID 查询全部社交账号
+func (r gormSocialAccounts) ListByClerkID(ctx context.Context, clerkID string) ([]model.SocialAccount, error) {
+	var accounts []model.SocialAccount
+	err := r.conn(ctx).
+		Joins("JOIN users ON users.id = social_accounts.user_id AND users.deleted_at IS NULL").
+		Where("users.clerk_id = ?", clerkID).
+		Order("social_accounts.id").
+		Find(&accounts).Error
+	return accounts, err
+}
+
+// CountByUser 统计用户的社交账号数量
+func (r gormSocialAccounts) CountByUser(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.conn(ctx).Model(&model.SocialAccount{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// LinkedToOtherUser 判断第三方账号是否已被其他用户绑定
+func (r gormSocialAccounts) LinkedToOtherUser(ctx context.Context, userID uint, provider, accountID string) (bool, error) {
+	var count int64
+	err := r.conn(ctx).Model(&model.SocialAccount{}).
+		Where("user_id != ? AND provider = ? AND account_id = ?", userID, provider, accountID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Create 创建社交账号
+func (r gormSocialAccounts) Create(ctx context.Context, account *model.SocialAccount) error {
+	return r.conn(ctx).Create(account).Error
+}
+
+// Update 更新社交账号的部分字段
+func (r gormSocialAccounts) Update(ctx context.Context, id uint, fields map[string]interface{}) error {
+	return r.conn(ctx).Model(&model.SocialAccount{ID: id}).Updates(fields).Error
+}
+
+// Delete 按 ID 软删除社交账号
+func (r gormSocialAccounts) Delete(ctx context.Context, id uint) error {
+	return r.conn(ctx).Delete(&model.SocialAccount{ID: id}).Error
+}
+
+// DeleteByAccount 软删除用户绑定的指定第三方账号
+func (r gormSocialAccounts) DeleteByAccount(ctx context.Context, userID uint, provider, accountID string) (int64, error) {
+	result := r.conn(ctx).
+		Where("user_id = ? AND provider = ? AND account_id = ?", userID, provider, accountID).
+		Delete(&model.SocialAccount{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteByUser 软删除用户的全部社交账号
+func (r gormSocialAccounts) DeleteByUser(ctx context.Context, userID uint) error {
+	return r.conn(ctx).Where("user_id = ?", userID).Delete(&model.SocialAccount{}).Error
+}
+
+// gormPreferences 自定义偏好设置存取的 GORM 实现
+type gormPreferences struct {
+	*gormStore
+}
+
+// ListByUser 查询用户的全部自定义偏好设置
+func (r gormPreferences) ListByUser(ctx context.Context, userID uint) ([]model.UserPreference, error) {
+	var preferences []model.UserPreference
+	err := r.conn(ctx).Where("user_id = ?", userID).Order("id").Find(&preferences).Error
+	return preferences, err
+}
+
+// Set 设置用户的自定义偏好
+func (r gormPreferences) Set(ctx context.Context, userID uint, key, value string) error {
+	preference := model.UserPreference{UserID: userID, Key: key, Value: value}
+	return r.conn(ctx).
+		Where("user_id = ? AND key = ?", userID, key).
+		Assign(model.UserPreference{Value: value}).
+		FirstOrCreate(&preference).Error
+}
+
+// gormWebhookEvents Webhook 事件存取的 GORM 实现
+type gormWebhookEvents struct {
+	*gormStore
+}
+
+// Record 使用 ON CONFLICT DO NOTHING 记录 Webhook 事件
+func (r gormWebhookEvents) Record(ctx context.Context, event *model.WebhookEvent) (bool, error) {
+	result := r.conn(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(event)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteBefore 删除指定时间之前记录的事件
+func (r gormWebhookEvents) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.conn(ctx).Where("created_at < ?", before).Delete(&model.WebhookEvent{})
+	return result.RowsAffected, result.Error
+}
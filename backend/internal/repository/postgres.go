@@ -0,0 +1,22 @@
+package repository
+
+import "gorm.io/gorm"
+
+// NewPostgresStore 创建 PostgreSQL 实现
+//
+// 参数:
+//   - db: PostgreSQL 数据库连接
+//
+// 返回:
+//   - Store: 数据存取入口
+//
+// 说明:
+//
+//	FindByClerkIDForUpdate 使用 SELECT ... FOR UPDATE 锁定用户行，
+//	同一用户的并发修改（例如绑定社交账号）在事务中依次执行
+func NewPostgresStore(db *gorm.DB) Store {
+	return &gormStore{
+		db:      db,
+		dialect: dialect{rowLocks: true},
+	}
+}
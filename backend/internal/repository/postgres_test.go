@@ -0,0 +1,51 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/repository"
+	"github.com/yszaryszar/NicheFlow/backend/internal/repository/repotest"
+	"github.com/yszaryszar/NicheFlow/backend/migrations"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// postgresDSNEnv 测试使用的 PostgreSQL 连接字符串，未设置时跳过测试
+// 检查会写入测试用户，应指向专门的测试数据库，例如：
+//
+//	NICHEFLOW_TEST_POSTGRES_DSN="host=localhost user=postgres password=postgres dbname=nicheflow_test sslmode=disable" go test ./internal/repository/
+const postgresDSNEnv = "NICHEFLOW_TEST_POSTGRES_DSN"
+
+// TestPostgresStore 对 PostgreSQL 执行全部迁移后执行一致性检查
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("未设置 %s，跳过 PostgreSQL 一致性检查", postgresDSNEnv)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("连接 PostgreSQL 失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取 SQL DB 失败: %v", err)
+	}
+	defer sqlDB.Close()
+
+	ctx := context.Background()
+	migrator, err := migrations.NewMigrator(sqlDB, "postgres")
+	if err != nil {
+		t.Fatalf("加载数据库迁移失败: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("迁移数据库失败: %v", err)
+	}
+
+	if err := repotest.TestStore(ctx, repository.NewPostgresStore(db)); err != nil {
+		t.Fatal(err)
+	}
+}
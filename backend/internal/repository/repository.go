@@ -0,0 +1,124 @@
+// Package repository 提供用户相关数据的存取接口
+// 业务服务通过这些接口读写用户、社交账号和偏好设置，不直接构建 GORM 查询。
+// 接口有 PostgreSQL 和 SQLite 两个实现，两者都需要通过 repotest 中的一致性测试，
+// SQLite 的检查随 go test 执行，PostgreSQL 的检查需要设置 NICHEFLOW_TEST_POSTGRES_DSN。
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound 记录不存在
+// 与 gorm.ErrRecordNotFound 是同一个错误，现有的 errors.Is 判断无需修改
+var ErrNotFound = gorm.ErrRecordNotFound
+
+// ErrDuplicateEmail 邮箱已被其他未删除的用户使用
+var ErrDuplicateEmail = errors.New("邮箱已被其他用户使用")
+
+// Store 数据存取入口
+type Store interface {
+	// Users 返回用户存取接口
+	Users() UserRepository
+	// SocialAccounts 返回社交账号存取接口
+	SocialAccounts() SocialAccountRepository
+	// Preferences 返回自定义偏好设置存取接口
+	Preferences() PreferenceRepository
+	// WebhookEvents 返回 Webhook 事件存取接口
+	WebhookEvents() WebhookEventRepository
+
+	// Transaction 在事务中执行 fn
+	// fn 中必须使用参数 tx 访问数据，fn 返回错误或发生 panic 时回滚
+	Transaction(ctx context.Context, fn func(tx Store) error) error
+}
+
+// UserRepository 用户存取接口
+// 查询方法在记录不存在时返回 ErrNotFound，已软删除的用户视为不存在
+type UserRepository interface {
+	// FindByID 按本地 ID 查询用户，同时加载社交账号和自定义偏好设置
+	FindByID(ctx context.Context, id uint) (*model.User, error)
+	// FindByClerkID 按 Clerk ID 查询用户，同时加载社交账号和自定义偏好设置
+	FindByClerkID(ctx context.Context, clerkID string) (*model.User, error)
+	// FindByClerkIDForUpdate 在事务中按 Clerk ID 查询并锁定用户，不加载关联数据
+	// 数据库不支持行锁时退化为普通查询
+	FindByClerkIDForUpdate(ctx context.Context, clerkID string) (*model.User, error)
+	// FindByClerkIDUnscopedForUpdate 与 FindByClerkIDForUpdate 相同，但包括已软删除的用户
+	FindByClerkIDUnscopedForUpdate(ctx context.Context, clerkID string) (*model.User, error)
+	// FindColumns 按 Clerk ID 查询用户的部分字段，columns 为数据库列名
+	FindColumns(ctx context.Context, clerkID string, columns ...string) (*model.User, error)
+
+	// Create 创建用户，成功后回填 ID；邮箱已被其他用户使用时返回 ErrDuplicateEmail
+	Create(ctx context.Context, user *model.User) error
+	// CreateIfNotExists 创建用户，Clerk ID 已存在时（包括已软删除的用户）不做任何修改
+	// 返回是否创建了新用户，并发调用是安全的；邮箱已被其他用户使用时返回 ErrDuplicateEmail
+	CreateIfNotExists(ctx context.Context, user *model.User) (bool, error)
+	// Save 保存用户的全部字段，邮箱已被其他用户使用时返回 ErrDuplicateEmail
+	Save(ctx context.Context, user *model.User) error
+	// Update 按数据库列名更新用户的部分字段，邮箱已被其他用户使用时返回 ErrDuplicateEmail
+	Update(ctx context.Context, id uint, fields map[string]interface{}) error
+	// IncrementUsage 原子地增加总使用次数和月度使用次数，并写入月度统计的重置时间
+	IncrementUsage(ctx context.Context, id uint, lastResetTime time.Time) error
+	// Delete 软删除用户
+	Delete(ctx context.Context, id uint) error
+	// Restore 恢复已软删除的用户并按数据库列名更新部分字段，用户未被删除时不做任何修改
+	// 邮箱已被其他用户使用时返回 ErrDuplicateEmail
+	Restore(ctx context.Context, id uint, fields map[string]interface{}) error
+}
+
+// SocialAccountRepository 社交账号存取接口
+type SocialAccountRepository interface {
+	// ListByUser 查询用户的全部社交账号
+	ListByUser(ctx context.Context, userID uint) ([]model.SocialAccount, error)
+	// ListByClerkID 按用户的 Clerk ID 查询全部社交账号
+	ListByClerkID(ctx context.Context, clerkID string) ([]model.SocialAccount, error)
+	// CountByUser 统计用户的社交账号数量
+	CountByUser(ctx context.Context, userID uint) (int64, error)
+	// LinkedToOtherUser 判断第三方账号是否已被其他用户绑定
+	LinkedToOtherUser(ctx context.Context, userID uint, provider, accountID string) (bool, error)
+
+	// Create 创建社交账号，成功后回填 ID
+	Create(ctx context.Context, account *model.SocialAccount) error
+	// Update 按数据库列名更新社交账号的部分字段
+	Update(ctx context.Context, id uint, fields map[string]interface{}) error
+	// Delete 按 ID 软删除社交账号
+	Delete(ctx context.Context, id uint) error
+	// DeleteByAccount 软删除用户绑定的指定第三方账号，返回删除的数量
+	DeleteByAccount(ctx context.Context, userID uint, provider, accountID string) (int64, error)
+	// DeleteByUser 软删除用户的全部社交账号
+	DeleteByUser(ctx context.Context, userID uint) error
+}
+
+// PreferenceRepository 自定义偏好设置存取接口
+// 内置偏好设置（语言、主题等）保存在用户表中，通过 UserRepository 修改
+type PreferenceRepository interface {
+	// ListByUser 查询用户的全部自定义偏好设置
+	ListByUser(ctx context.Context, userID uint) ([]model.UserPreference, error)
+	// Set 设置用户的自定义偏好，键已存在时覆盖原值
+	Set(ctx context.Context, userID uint, key, value string) error
+}
+
+// WebhookEventRepository Webhook 事件存取接口，用于幂等处理
+type WebhookEventRepository interface {
+	// Record 记录 Webhook 事件，返回是否为首次记录，事件 ID 已存在时返回 false
+	Record(ctx context.Context, event *model.WebhookEvent) (bool, error)
+	// DeleteBefore 删除指定时间之前记录的事件，返回删除的数量
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// New 根据数据库连接的驱动创建对应的实现
+//
+// 参数:
+//   - db: 数据库连接
+//
+// 返回:
+//   - Store: SQLite 连接返回 SQLite 实现，其余返回 PostgreSQL 实现
+func New(db *gorm.DB) Store {
+	if db.Dialector.Name() == "sqlite" {
+		return NewSQLiteStore(db)
+	}
+	return NewPostgresStore(db)
+}
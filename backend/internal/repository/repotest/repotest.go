@@ -0,0 +1,591 @@
+// Package repotest 提供 repository.Store 实现的一致性测试
+// PostgreSQL 和 SQLite 实现都必须通过同一组检查，新增实现时也应使用它验证：
+//
+//	if err := repotest.TestStore(ctx, store); err != nil {
+//		t.Fatal(err)
+//	}
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"github.com/yszaryszar/NicheFlow/backend/internal/repository"
+)
+
+// check 单项检查，使用 newUser 创建互不冲突的测试用户
+type check struct {
+	name string
+	run  func(ctx context.Context, store repository.Store, newUser func() *model.User) error
+}
+
+// checks 全部检查，按顺序执行
+var checks = []check{
+	{"用户创建和查询", checkUserCreateAndFind},
+	{"用户不存在", checkUserNotFound},
+	{"重复创建用户", checkUserCreateIfNotExists},
+	{"邮箱冲突", checkUserEmailConflict},
+	{"更新用户", checkUserUpdate},
+	{"增加使用次数", checkUserIncrementUsage},
+	{"查询部分字段", checkUserFindColumns},
+	{"软删除用户", checkUserDelete},
+	{"恢复软删除用户", checkUserRestore},
+	{"社交账号", checkSocialAccounts},
+	{"偏好设置", checkPreferences},
+	{"Webhook 事件", checkWebhookEvents},
+	{"事务提交", checkTransactionCommit},
+	{"事务回滚", checkTransactionRollback},
+}
+
+// seq 测试数据序号，与启动时间一起保证重复执行时不会与已有数据冲突
+var seq atomic.Int64
+
+// TestStore 对数据存取实现执行一致性检查
+//
+// 参数:
+//   - ctx: 上下文
+//   - store: 待检查的实现，数据库需要已执行全部迁移
+//
+// 返回:
+//   - error: 所有未通过的检查合并后的错误，全部通过时为 nil
+//
+// 说明:
+//
+//	检查会写入以 repotest- 开头的测试用户，不会修改或删除其他数据，
+//	但仍应使用专门的测试数据库。
+func TestStore(ctx context.Context, store repository.Store) error {
+	run := time.Now().UnixNano()
+	newUser := func() *model.User {
+		n := seq.Add(1)
+		return &model.User{
+			ClerkID:       fmt.Sprintf("repotest-%d-%d", run, n),
+			Email:         fmt.Sprintf("repotest-%d-%d@example.com", run, n),
+			Username:      "repotest",
+			Role:          "user",
+			Status:        model.UserStatusActive,
+			UsageLimit:    5,
+			MonthlyLimit:  3,
+			LastResetTime: time.Now().UTC().Truncate(time.Second),
+		}
+	}
+
+	var errs []error
+	for _, c := range checks {
+		if err := c.run(ctx, store, newUser); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// createUser 创建测试用户
+func createUser(ctx context.Context, store repository.Store, newUser func() *model.User) (*model.User, error) {
+	user := newUser()
+	if err := store.Users().Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("Create: %w", err)
+	}
+	if user.ID == 0 {
+		return nil, errors.New("Create 没有回填 ID")
+	}
+	return user, nil
+}
+
+func checkUserCreateAndFind(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	user, err := createUser(ctx, store, newUser)
+	if err != nil {
+		return err
+	}
+
+	byID, err := store.Users().FindByID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("FindByID: %w", err)
+	}
+	if byID.ClerkID != user.ClerkID || byID.Email != user.Email || byID.UsageLimit != user.UsageLimit {
+		return fmt.Errorf("FindByID 返回 %s/%s，期望 %s/%s", byID.ClerkID, byID.Email, user.ClerkID, user.Email)
+	}
+	if !byID.LastResetTime.Equal(user.LastResetTime) {
+		return fmt.Errorf("LastResetTime 为 %v，期望 %v", byID.LastResetTime, user.LastResetTime)
+	}
+
+	byClerkID, err := store.Users().FindByClerkID(ctx, user.ClerkID)
+	if err != nil {
+		return fmt.Errorf("FindByClerkID: %w", err)
+	}
+	if byClerkID.ID != user.ID {
+		return fmt.Errorf("FindByClerkID 返回 ID %d，期望 %d", byClerkID.ID, user.ID)
+	}
+	return nil
+}
+
+func checkUserNotFound(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	missing := newUser()
+	if _, err := store.Users().FindByClerkID(ctx, missing.ClerkID); !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("FindByClerkID 返回 %v，期望 ErrNotFound", err)
+	}
+	if _, err := store.Users().FindByClerkIDForUpdate(ctx, missing.ClerkID); !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("FindByClerkIDForUpdate 返回 %v，期望 ErrNotFound", err)
+	}
+	if _, err := store.Users().FindColumns(ctx, missing.ClerkID, "id"); !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("FindColumns 返回 %v，期望 ErrNotFound", err)
+	}
+	if _, err := store.Users().FindByID(ctx, 1<<31-1); !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("FindByID 返回 %v，期望 ErrNotFound", err)
+	}
+	return nil
+}
+
+func checkUserCreateIfNotExists(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	user := newUser()
+	created, err := store.Users().CreateIfNotExists(ctx, user)
+	if err != nil {
+		return fmt.Errorf("首次 CreateIfNotExists: %w", err)
+	}
+	if !created {
+		return errors.New("首次 CreateIfNotExists 返回未创建")
+	}
+
+	duplicate := *user
+	duplicate.ID = 0
+	duplicate.Username = "changed"
+	created, err = store.Users().CreateIfNotExists(ctx, &duplicate)
+	if err != nil {
+		return fmt.Errorf("重复 CreateIfNotExists: %w", err)
+	}
+	if created {
+		return errors.New("重复 CreateIfNotExists 返回已创建")
+	}
+
+	found, err := store.Users().FindByClerkID(ctx, user.ClerkID)
+	if err != nil {
+		return fmt.Errorf("FindByClerkID: %w", err)
+	}
+	if found.Username != user.Username {
+		return fmt.Errorf("重复创建覆盖了已有用户，Username 为 %q", found.Username)
+	}
+	return nil
+}
+
+func checkUserEmailConflict(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	// 没有邮箱的用户可以有多个
+	for i := 0; i < 2; i++ {
+		user := newUser()
+		user.Email = ""
+		created, err := store.Users().CreateIfNotExists(ctx, user)
+		if err != nil {
+			return fmt.Errorf("创建第 %d 个空邮箱用户: %w", i+1, err)
+		}
+		if !created {
+			return fmt.Errorf("第 %d 个空邮箱用户没有被创建", i+1)
+		}
+	}
+
+	user, err := createUser(ctx, store, newUser)
+	if err != nil {
+		return err
+	}
+	clash := newUser()
+	clash.Email = user.Email
+	if _, err := store.Users().CreateIfNotExists(ctx, clash); !errors.Is(err, repository.ErrDuplicateEmail) {
+		return fmt.Errorf("CreateIfNotExists 使用已有邮箱返回 %v，期望 ErrDuplicateEmail", err)
+	}
+	if err := store.Users().Create(ctx, clash); !errors.Is(err, repository.ErrDuplicateEmail) {
+		return fmt.Errorf("Create 使用已有邮箱返回 %v，期望 ErrDuplicateEmail", err)
+	}
+	// Clerk ID 冲突不是邮箱冲突
+	sameClerk := newUser()
+	sameClerk.ClerkID = user.ClerkID
+	if err := store.Users().Create(ctx, sameClerk); err == nil || errors.Is(err, repository.ErrDuplicateEmail) {
+		return fmt.Errorf("Create 使用已有 Clerk ID 返回 %v，期望非 ErrDuplicateEmail 的错误", err)
+	}
+
+	other, err := createUser(ctx, store, newUser)
+	if err != nil {
+		return err
+	}
+	if err := store.Users().Update(ctx, other.ID, map[string]interface{}{"email": user.Email}); !errors.Is(err, repository.ErrDuplicateEmail) {
+		return fmt.Errorf("Update 使用已有邮箱返回 %v，期望 ErrDuplicateEmail", err)
+	}
+
+	// 已删除用户的邮箱可以重新使用
+	if err := store.Users().Delete(ctx, user.ID); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	if created, err := store.Users().CreateIfNotExists(ctx, clash); err != nil || !created {
+		return fmt.Errorf("使用已删除用户的邮箱创建用户返回 %v/%v，期望成功", created, err)
+	}
+	return nil
+}
+
+func checkUserUpdate(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	user, err := createUser(ctx, store, newUser)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Users().Update(ctx, user.ID, map[string]interface{}{"theme": "dark", "usage_limit": 9}); err != nil {
+		return fmt.Errorf("Update: %w", err)
+	}
+	found, err := store.Users().FindByID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("FindByID: %w", err)
+	}
+	if found.Theme != "dark" || found.UsageLimit != 9 || found.Username != user.Username {
+		return fmt.Errorf("Update 后为 theme=%q usage_limit=%d username=%q", found.Theme, found.UsageLimit, found.Username)
+	}
+
+	found.FirstName = "Saved"
+	if err := store.Users().Save(ctx, found); err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	saved, err := store.Users().FindByID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("FindByID: %w", err)
+	}
+	if saved.FirstName != "Saved" || saved.Theme != "dark" {
+		return fmt.Errorf("Save 后为 first_name=%q theme=%q", saved.FirstName, saved.Theme)
+	}
+	return nil
+}
+
+func checkUserIncrementUsage(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	user, err := createUser(ctx, store, newUser)
+	if err != nil {
+		return err
+	}
+
+	resetAt := user.LastResetTime.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if err := store.Users().IncrementUsage(ctx, user.ID, resetAt); err != nil {
+			return fmt.Errorf("IncrementUsage: %w", err)
+		}
+	}
+	found, err := store.Users().FindByID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("FindByID: %w", err)
+	}
+	if found.UsageCount != 2 || found.MonthlyCount != 2 {
+		return fmt.Errorf("使用次数为 %d/%d，期望 2/2", found.UsageCount, found.MonthlyCount)
+	}
+	if !found.LastResetTime.Equal(resetAt) {
+		return fmt.Errorf("LastResetTime 为 %v，期望 %v", found.LastResetTime, resetAt)
+	}
+	return nil
+}
+
+func checkUserFindColumns(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	user, err := createUser(ctx, store, newUser)
+	if err != nil {
+		return err
+	}
+
+	found, err := store.Users().FindColumns(ctx, user.ClerkID, "id", "usage_limit")
+	if err != nil {
+		return fmt.Errorf("FindColumns: %w", err)
+	}
+	if found.ID != user.ID || found.UsageLimit != user.UsageLimit {
+		return fmt.Errorf("FindColumns 返回 id=%d usage_limit=%d", found.ID, found.UsageLimit)
+	}
+	if found.Email != "" {
+		return fmt.Errorf("FindColumns 加载了未请求的字段 email=%q", found.Email)
+	}
+	return nil
+}
+
+func checkUserDelete(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	user, err := createUser(ctx, store, newUser)
+	if err != nil {
+		return err
+	}
+	if err := store.Users().Delete(ctx, user.ID); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	if _, err := store.Users().FindByID(ctx, user.ID); !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("删除后 FindByID 返回 %v，期望 ErrNotFound", err)
+	}
+	if _, err := store.Users().FindByClerkID(ctx, user.ClerkID); !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("删除后 FindByClerkID 返回 %v，期望 ErrNotFound", err)
+	}
+	return nil
+}
+
+func checkUserRestore(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	user, err := createUser(ctx, store, newUser)
+	if err != nil {
+		return err
+	}
+	if err := store.Users().Delete(ctx, user.ID); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+
+	found, err := store.Users().FindByClerkIDUnscopedForUpdate(ctx, user.ClerkID)
+	if err != nil {
+		return fmt.Errorf("FindByClerkIDUnscopedForUpdate: %w", err)
+	}
+	if found.ID != user.ID || !found.DeletedAt.Valid {
+		return fmt.Errorf("FindByClerkIDUnscopedForUpdate 返回 id=%d deleted=%v", found.ID, found.DeletedAt.Valid)
+	}
+
+	// 删除期间邮箱被其他用户占用时不能恢复
+	other := newUser()
+	other.Email = user.Email
+	if err := store.Users().Create(ctx, other); err != nil {
+		return fmt.Errorf("Create 复用邮箱: %w", err)
+	}
+	if err := store.Users().Restore(ctx, user.ID, nil); !errors.Is(err, repository.ErrDuplicateEmail) {
+		return fmt.Errorf("邮箱冲突时 Restore 返回 %v，期望 ErrDuplicateEmail", err)
+	}
+
+	if err := store.Users().Restore(ctx, user.ID, map[string]interface{}{"email": "", "username": "restored"}); err != nil {
+		return fmt.Errorf("Restore: %w", err)
+	}
+	restored, err := store.Users().FindByClerkID(ctx, user.ClerkID)
+	if err != nil {
+		return fmt.Errorf("恢复后 FindByClerkID: %w", err)
+	}
+	if restored.Email != "" || restored.Username != "restored" {
+		return fmt.Errorf("恢复后 email=%q username=%q", restored.Email, restored.Username)
+	}
+
+	// 用户未被删除时不做任何修改
+	if err := store.Users().Restore(ctx, user.ID, map[string]interface{}{"username": "again"}); err != nil {
+		return fmt.Errorf("重复 Restore: %w", err)
+	}
+	if found, err := store.Users().FindByID(ctx, user.ID); err != nil || found.Username != "restored" {
+		return fmt.Errorf("重复 Restore 修改了未删除的用户: %v", err)
+	}
+	return nil
+}
+
+func checkSocialAccounts(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	owner, err := createUser(ctx, store, newUser)
+	if err != nil {
+		return err
+	}
+	other, err := createUser(ctx, store, newUser)
+	if err != nil {
+		return err
+	}
+
+	accounts := store.SocialAccounts()
+	google := &model.SocialAccount{UserID: owner.ID, Provider: "google", AccountID: owner.ClerkID, IsActive: true}
+	github := &model.SocialAccount{UserID: owner.ID, Provider: "github", AccountID: owner.ClerkID, IsActive: true}
+	for _, account := range []*model.SocialAccount{google, github} {
+		if err := accounts.Create(ctx, account); err != nil {
+			return fmt.Errorf("Create: %w", err)
+		}
+	}
+
+	listed, err := accounts.ListByUser(ctx, owner.ID)
+	if err != nil {
+		return fmt.Errorf("ListByUser: %w", err)
+	}
+	if len(listed) != 2 || listed[0].ID != google.ID || listed[1].ID != github.ID {
+		return fmt.Errorf("ListByUser 返回 %d 个账号，期望按创建顺序返回 2 个", len(listed))
+	}
+	byClerkID, err := accounts.ListByClerkID(ctx, owner.ClerkID)
+	if err != nil {
+		return fmt.Errorf("ListByClerkID: %w", err)
+	}
+	if len(byClerkID) != 2 {
+		return fmt.Errorf("ListByClerkID 返回 %d 个账号，期望 2 个", len(byClerkID))
+	}
+	user, err := store.Users().FindByID(ctx, owner.ID)
+	if err != nil {
+		return fmt.Errorf("FindByID: %w", err)
+	}
+	if len(user.SocialAccounts) != 2 {
+		return fmt.Errorf("FindByID 加载了 %d 个社交账号，期望 2 个", len(user.SocialAccounts))
+	}
+
+	linked, err := accounts.LinkedToOtherUser(ctx, other.ID, "google", owner.ClerkID)
+	if err != nil {
+		return fmt.Errorf("LinkedToOtherUser: %w", err)
+	}
+	if !linked {
+		return errors.New("LinkedToOtherUser 没有发现其他用户绑定的账号")
+	}
+	linked, err = accounts.LinkedToOtherUser(ctx, owner.ID, "google", owner.ClerkID)
+	if err != nil {
+		return fmt.Errorf("LinkedToOtherUser: %w", err)
+	}
+	if linked {
+		return errors.New("LinkedToOtherUser 把用户自己的账号视为其他用户绑定")
+	}
+
+	if err := accounts.Update(ctx, google.ID, map[string]interface{}{"username": "updated", "is_active": false}); err != nil {
+		return fmt.Errorf("Update: %w", err)
+	}
+	listed, err = accounts.ListByUser(ctx, owner.ID)
+	if err != nil {
+		return fmt.Errorf("ListByUser: %w", err)
+	}
+	if listed[0].Username != "updated" || listed[0].IsActive {
+		return fmt.Errorf("Update 后为 username=%q is_active=%v", listed[0].Username, listed[0].IsActive)
+	}
+
+	deleted, err := accounts.DeleteByAccount(ctx, owner.ID, "github", owner.ClerkID)
+	if err != nil {
+		return fmt.Errorf("DeleteByAccount: %w", err)
+	}
+	if deleted != 1 {
+		return fmt.Errorf("DeleteByAccount 删除了 %d 个账号，期望 1 个", deleted)
+	}
+	if deleted, err = accounts.DeleteByAccount(ctx, other.ID, "google", owner.ClerkID); err != nil || deleted != 0 {
+		return fmt.Errorf("DeleteByAccount 删除了其他用户的账号: %d, %v", deleted, err)
+	}
+	count, err := accounts.CountByUser(ctx, owner.ID)
+	if err != nil {
+		return fmt.Errorf("CountByUser: %w", err)
+	}
+	if count != 1 {
+		return fmt.Errorf("CountByUser 返回 %d，期望 1", count)
+	}
+
+	if err := accounts.Delete(ctx, google.ID); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	if err := accounts.Create(ctx, &model.SocialAccount{UserID: owner.ID, Provider: "x", AccountID: owner.ClerkID}); err != nil {
+		return fmt.Errorf("Create: %w", err)
+	}
+	if err := accounts.DeleteByUser(ctx, owner.ID); err != nil {
+		return fmt.Errorf("DeleteByUser: %w", err)
+	}
+	if count, err = accounts.CountByUser(ctx, owner.ID); err != nil || count != 0 {
+		return fmt.Errorf("DeleteByUser 后 CountByUser 返回 %d, %v，期望 0", count, err)
+	}
+	return nil
+}
+
+func checkPreferences(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	user, err := createUser(ctx, store, newUser)
+	if err != nil {
+		return err
+	}
+
+	preferences := store.Preferences()
+	for _, kv := range [][2]string{{"editor", "vim"}, {"layout", "grid"}, {"editor", "emacs"}} {
+		if err := preferences.Set(ctx, user.ID, kv[0], kv[1]); err != nil {
+			return fmt.Errorf("Set(%s): %w", kv[0], err)
+		}
+	}
+
+	listed, err := preferences.ListByUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("ListByUser: %w", err)
+	}
+	values := make(map[string]string, len(listed))
+	for _, preference := range listed {
+		values[preference.Key] = preference.Value
+	}
+	if len(listed) != 2 || values["editor"] != "emacs" || values["layout"] != "grid" {
+		return fmt.Errorf("ListByUser 返回 %v，期望 editor=emacs layout=grid", values)
+	}
+
+	found, err := store.Users().FindByClerkID(ctx, user.ClerkID)
+	if err != nil {
+		return fmt.Errorf("FindByClerkID: %w", err)
+	}
+	if len(found.Preferences) != 2 {
+		return fmt.Errorf("FindByClerkID 加载了 %d 个偏好设置，期望 2 个", len(found.Preferences))
+	}
+	return nil
+}
+
+func checkWebhookEvents(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	eventID := newUser().ClerkID
+	for i, want := range []bool{true, false} {
+		recorded, err := store.WebhookEvents().Record(ctx, &model.WebhookEvent{EventID: eventID, Source: "repotest", Type: "test"})
+		if err != nil {
+			return fmt.Errorf("第 %d 次 Record: %w", i+1, err)
+		}
+		if recorded != want {
+			return fmt.Errorf("第 %d 次 Record 返回 %v，期望 %v", i+1, recorded, want)
+		}
+	}
+
+	// 使用远早于真实数据的时间，避免在共享数据库上删除其他事件
+	old := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	oldEventID := eventID + "-old"
+	if _, err := store.WebhookEvents().Record(ctx, &model.WebhookEvent{EventID: oldEventID, Source: "repotest", Type: "test", CreatedAt: old}); err != nil {
+		return fmt.Errorf("Record 旧事件: %w", err)
+	}
+	deleted, err := store.WebhookEvents().DeleteBefore(ctx, old.Add(time.Second))
+	if err != nil {
+		return fmt.Errorf("DeleteBefore: %w", err)
+	}
+	if deleted < 1 {
+		return fmt.Errorf("DeleteBefore 删除了 %d 个事件，期望至少 1 个", deleted)
+	}
+	for id, want := range map[string]bool{oldEventID: true, eventID: false} {
+		recorded, err := store.WebhookEvents().Record(ctx, &model.WebhookEvent{EventID: id, Source: "repotest", Type: "test"})
+		if err != nil {
+			return fmt.Errorf("DeleteBefore 之后 Record %s: %w", id, err)
+		}
+		if recorded != want {
+			return fmt.Errorf("DeleteBefore 之后 Record %s 返回 %v，期望 %v", id, recorded, want)
+		}
+	}
+	return nil
+}
+
+func checkTransactionCommit(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	user := newUser()
+	err := store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Users().Create(ctx, user); err != nil {
+			return err
+		}
+		locked, err := tx.Users().FindByClerkIDForUpdate(ctx, user.ClerkID)
+		if err != nil {
+			return fmt.Errorf("FindByClerkIDForUpdate: %w", err)
+		}
+		if locked.ID != user.ID {
+			return fmt.Errorf("FindByClerkIDForUpdate 返回 ID %d，期望 %d", locked.ID, user.ID)
+		}
+		return tx.SocialAccounts().Create(ctx, &model.SocialAccount{UserID: user.ID, Provider: "google", AccountID: user.ClerkID})
+	})
+	if err != nil {
+		return fmt.Errorf("Transaction: %w", err)
+	}
+
+	found, err := store.Users().FindByID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("提交后 FindByID: %w", err)
+	}
+	if len(found.SocialAccounts) != 1 {
+		return fmt.Errorf("提交后有 %d 个社交账号，期望 1 个", len(found.SocialAccounts))
+	}
+	return nil
+}
+
+func checkTransactionRollback(ctx context.Context, store repository.Store, newUser func() *model.User) error {
+	user := newUser()
+	errRollback := errors.New("rollback")
+	err := store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Users().Create(ctx, user); err != nil {
+			return err
+		}
+		if err := tx.Preferences().Set(ctx, user.ID, "editor", "vim"); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		return fmt.Errorf("Transaction 返回 %v，期望原样返回 fn 的错误", err)
+	}
+
+	if _, err := store.Users().FindByClerkID(ctx, user.ClerkID); !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("回滚后 FindByClerkID 返回 %v，期望 ErrNotFound", err)
+	}
+	preferences, err := store.Preferences().ListByUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("ListByUser: %w", err)
+	}
+	if len(preferences) != 0 {
+		return fmt.Errorf("回滚后仍有 %d 个偏好设置", len(preferences))
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package repository
+
+import "gorm.io/gorm"
+
+// NewSQLiteStore 创建 SQLite 实现
+//
+// 参数:
+//   - db: SQLite 数据库连接
+//
+// 返回:
+//   - Store: 数据存取入口
+//
+// 说明:
+//
+//	SQLite 不支持行锁，FindByClerkIDForUpdate 退化为普通查询，
+//	写事务之间由 SQLite 的数据库级写锁串行化。只用于本地开发和测试。
+func NewSQLiteStore(db *gorm.DB) Store {
+	return &gormStore{
+		db:      db,
+		dialect: dialect{rowLocks: false},
+	}
+}
@@ -0,0 +1,20 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/repository"
+	"github.com/yszaryszar/NicheFlow/backend/internal/repository/repotest"
+	"github.com/yszaryszar/NicheFlow/backend/internal/testutil"
+)
+
+// TestSQLiteStore 使用内存 SQLite 数据库执行一致性检查
+func TestSQLiteStore(t *testing.T) {
+	db := testutil.SetupTestDB()
+	defer testutil.CleanupTestDB(db)
+
+	if err := repotest.TestStore(context.Background(), repository.NewSQLiteStore(db)); err != nil {
+		t.Fatal(err)
+	}
+}
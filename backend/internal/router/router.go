@@ -6,18 +6,21 @@ import (
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
-	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+	"github.com/yszaryszar/NicheFlow/backend/internal/container"
 	"github.com/yszaryszar/NicheFlow/backend/internal/handler"
 	"github.com/yszaryszar/NicheFlow/backend/internal/middleware"
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
 )
 
 // SetupRouter 设置并配置 HTTP 路由
 //
 // 参数:
-//   - cfg: 应用配置对象，包含路由和中间件相关的配置信息
+//   - deps: 依赖容器，提供配置监视器、Redis 客户端和业务服务，
+//     配置监视器在运行时配置变更时更新中间件
 //
 // 返回:
 //   - *gin.Engine: 配置好的 Gin 引擎实例
+//   - error: 初始化中间件失败时返回错误
 //
 // 说明:
 //
@@ -29,23 +32,31 @@ import (
 //	5. 设置用户相关路由
 //	6. 配置 Webhook 路由
 //	7. 设置管理员路由
-func SetupRouter(cfg *config.Config) *gin.Engine {
+func SetupRouter(deps *container.Container) (*gin.Engine, error) {
 	r := gin.New()
+	cfg := deps.Config.Current()
 
-	// 创建中间件管理器
-	middlewareManager := middleware.NewManager(cfg)
+	// 创建中间件管理器，并订阅运行时配置变更
+	middlewareManager, err := middleware.NewManager(cfg, deps.Services, deps.Redis)
+	if err != nil {
+		return nil, err
+	}
 	defer middlewareManager.Close()
+	middlewareManager.Watch(deps.Config)
 
 	// 设置全局中间件
 	middlewareManager.SetupMiddlewares(r)
 
 	// 健康检查路由
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "ok",
-			"version": cfg.App.Version,
-		})
-	})
+	// /livez 只检查进程本身，/readyz 检查数据库、Redis 和迁移状态
+	// /health 保留给尚未迁移的负载均衡配置，行为与 /readyz 相同
+	healthHandler, err := handler.NewHealthHandler(cfg.App.Version, deps.DB, deps.Redis, deps.Draining)
+	if err != nil {
+		return nil, err
+	}
+	r.GET("/livez", healthHandler.Livez)
+	r.GET("/readyz", healthHandler.Readyz)
+	r.GET("/health", healthHandler.Readyz)
 
 	// 注册 Swagger 路由
 	// @title NicheFlow API
@@ -69,21 +80,25 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// 创建处理器
-	userHandler := handler.NewUserHandler()
-	authHandler := handler.NewAuthHandler()
+	userHandler := handler.NewUserHandler(&cfg.Clerk, deps.Services.Users)
+	authHandler := handler.NewAuthHandler(deps.Services.Users)
+	apiKeyHandler := handler.NewAPIKeyHandler(deps.Services.APIKeys)
+	adminHandler := handler.NewAdminHandler(deps.Services, middlewareManager.IdentityProvider(), deps.Config)
 
 	// API 路由组
 	v1 := r.Group("/v1")
 	{
 		// 认证相关路由
+		// 需要认证，同步的用户由认证中间件确定，不能通过请求体指定
 		auth := v1.Group("/auth")
+		auth.Use(middlewareManager.GetAuthMiddleware(), middlewareManager.RateLimit())
 		{
 			auth.POST("/sync", authHandler.SyncUserData)
 		}
 
 		// 用户相关路由
 		userGroup := v1.Group("/user")
-		userGroup.Use(middlewareManager.GetAuthMiddleware())
+		userGroup.Use(middlewareManager.GetAuthMiddleware(), middlewareManager.RateLimit())
 		{
 			// @Summary 获取用户个人资料
 			// @Tags 用户
@@ -107,11 +122,11 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 
 			// @Summary 添加用户社交账号
 			// @Tags 用户
-			userGroup.POST("/social-accounts", userHandler.LinkSocialAccount)
+			userGroup.POST("/social-accounts", middlewareManager.DenyImpersonation(), userHandler.LinkSocialAccount)
 
 			// @Summary 删除用户社交账号
 			// @Tags 用户
-			userGroup.DELETE("/social-accounts/:provider/:accountId", userHandler.UnlinkSocialAccount)
+			userGroup.DELETE("/social-accounts/:provider/:accountId", middlewareManager.DenyImpersonation(), userHandler.UnlinkSocialAccount)
 
 			// @Summary 获取用户使用统计
 			// @Tags 用户
@@ -120,10 +135,22 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 			// @Summary 获取用户订阅信息
 			// @Tags 用户
 			userGroup.GET("/subscription", userHandler.GetSubscription)
+
+			// @Summary 获取用户 API 密钥
+			// @Tags 用户
+			userGroup.GET("/api-keys", apiKeyHandler.ListAPIKeys)
+
+			// @Summary 创建用户 API 密钥
+			// @Tags 用户
+			userGroup.POST("/api-keys", middlewareManager.DenyImpersonation(), apiKeyHandler.CreateAPIKey)
+
+			// @Summary 吊销用户 API 密钥
+			// @Tags 用户
+			userGroup.DELETE("/api-keys/:id", middlewareManager.DenyImpersonation(), apiKeyHandler.RevokeAPIKey)
 		}
 
 		// Webhook 路由
-		// 不需要认证，用于处理外部服务回调
+		// 不需要认证，用于处理外部服务回调，请求由签名校验保护，只受全局的按 IP 限制
 		webhook := v1.Group("/webhook")
 		{
 			// @Summary 处理 Clerk Webhook
@@ -132,16 +159,63 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 		}
 
 		// 管理员路由
-		// 需要认证和管理员角色
+		// 需要认证，每个路由按所需权限单独校验，只接受登录会话，个人 API 密钥和模拟登录会话不能访问
 		admin := v1.Group("/admin")
-		admin.Use(
-			middlewareManager.GetAuthMiddleware(),
-			middlewareManager.GetRequireRoles("admin"),
-		)
+		admin.Use(middlewareManager.GetAuthMiddleware(), middlewareManager.DenyImpersonation(), middlewareManager.RequireSession(), middlewareManager.RateLimit())
 		{
-			// TODO: 添加管理员路由
+			// @Summary 获取角色列表
+			// @Tags 管理员
+			admin.GET("/roles", middlewareManager.RequirePermission(model.PermissionRolesRead), adminHandler.ListRoles)
+
+			// @Summary 查询用户列表
+			// @Tags 管理员
+			admin.GET("/users", middlewareManager.RequirePermission(model.PermissionUsersRead), adminHandler.ListUsers)
+
+			// @Summary 获取用户记录
+			// @Tags 管理员
+			admin.GET("/users/:id", middlewareManager.RequirePermission(model.PermissionUsersRead), adminHandler.GetUser)
+
+			// @Summary 修改用户状态
+			// @Tags 管理员
+			admin.PUT("/users/:id/status", middlewareManager.RequirePermission(model.PermissionUsersWrite), adminHandler.UpdateUserStatus)
+
+			// @Summary 调整使用限制
+			// @Tags 管理员
+			admin.PUT("/users/:id/limits", middlewareManager.RequirePermission(model.PermissionBillingEdit), adminHandler.UpdateUsageLimits)
+
+			// @Summary 重置使用计数
+			// @Tags 管理员
+			admin.POST("/users/:id/usage/reset", middlewareManager.RequirePermission(model.PermissionBillingEdit), adminHandler.ResetUsage)
+
+			// @Summary 重新同步用户资料
+			// @Tags 管理员
+			admin.POST("/users/:id/resync", middlewareManager.RequirePermission(model.PermissionUsersWrite), adminHandler.ResyncUser)
+
+			// @Summary 分配用户角色
+			// @Tags 管理员
+			admin.PUT("/users/:id/role", middlewareManager.RequirePermission(model.PermissionRolesWrite), adminHandler.AssignRole)
+
+			// @Summary 发起模拟登录
+			// @Tags 管理员
+			admin.POST("/impersonations", middlewareManager.RequirePermission(model.PermissionImpersonate), adminHandler.StartImpersonation)
+
+			// @Summary 结束模拟登录
+			// @Tags 管理员
+			admin.DELETE("/impersonations/:id", middlewareManager.RequirePermission(model.PermissionImpersonate), adminHandler.RevokeImpersonation)
+
+			// @Summary 获取审计日志
+			// @Tags 管理员
+			admin.GET("/audit-logs", middlewareManager.RequirePermission(model.PermissionAuditRead), adminHandler.ListAuditLogs)
+
+			// @Summary 查看运行配置
+			// @Tags 管理员
+			admin.GET("/config", middlewareManager.RequirePermission(model.PermissionConfigRead), adminHandler.GetConfig)
+
+			// @Summary 查看连接池统计
+			// @Tags 管理员
+			admin.GET("/pools", middlewareManager.RequirePermission(model.PermissionConfigRead), healthHandler.GetPoolStats)
 		}
 	}
 
-	return r
+	return r, nil
 }
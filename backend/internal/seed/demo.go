@@ -0,0 +1,136 @@
+// Package seed 提供本地开发和演示环境使用的示例数据
+// 示例数据通过业务服务写入，与真实用户走相同的默认值和校验逻辑，可以重复执行
+package seed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"github.com/yszaryszar/NicheFlow/backend/internal/service"
+)
+
+// FreePlan 未订阅用户使用的计划名称，与限流配置中的 free 计划对应
+const FreePlan = "free"
+
+// demoClerkIDPrefix 示例用户 Clerk ID 的前缀，用于与真实用户区分
+const demoClerkIDPrefix = "demo_"
+
+// Plans 返回配置中出现的全部订阅计划
+//
+// 参数:
+//   - cfg: 应用配置，订阅计划取自限流配置
+//
+// 返回:
+//   - []string: 按名称排序的计划，总是包含 free
+func Plans(cfg *config.Config) []string {
+	seen := map[string]bool{FreePlan: true}
+	rateLimit := cfg.Middleware.RateLimit
+	for plan := range rateLimit.Plans {
+		seen[plan] = true
+	}
+	for _, route := range rateLimit.Routes {
+		for plan := range route.Plans {
+			seen[plan] = true
+		}
+	}
+
+	plans := make([]string, 0, len(seen))
+	for plan := range seen {
+		plans = append(plans, plan)
+	}
+	sort.Strings(plans)
+	return plans
+}
+
+// Demo 为每个订阅计划创建一个示例用户
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - users: 用户服务
+//   - plans: 订阅计划
+//
+// 返回:
+//   - []*model.User: 创建或已存在的示例用户，包含社交账号和偏好设置
+//   - error: 写入过程中的错误
+//
+// 说明:
+//
+//	示例用户的 Clerk ID 为 demo_<计划>，邮箱为 demo+<计划>@example.com。
+//	每个用户绑定一个 GitHub 示例账号并设置几项偏好，付费计划的订阅状态为 active。
+//	重复执行时更新订阅和偏好设置，不会创建重复的用户和社交账号。
+func Demo(ctx context.Context, users *service.UserService, plans []string) ([]*model.User, error) {
+	seeded := make([]*model.User, 0, len(plans))
+	for _, plan := range plans {
+		user, err := demoUser(ctx, users, plan)
+		if err != nil {
+			return seeded, fmt.Errorf("创建 %s 计划的示例用户失败: %w", plan, err)
+		}
+		seeded = append(seeded, user)
+	}
+	return seeded, nil
+}
+
+// demoUser 创建或更新单个示例用户
+func demoUser(ctx context.Context, users *service.UserService, plan string) (*model.User, error) {
+	clerkID := demoClerkIDPrefix + plan
+	user, err := users.ProvisionUser(ctx, &model.User{
+		ClerkID:       clerkID,
+		Email:         fmt.Sprintf("demo+%s@example.com", plan),
+		Username:      "demo-" + plan,
+		FirstName:     "Demo",
+		LastName:      plan,
+		EmailVerified: true,
+		LastSignInAt:  time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := &model.User{}
+	if plan != FreePlan {
+		start := time.Now()
+		end := start.AddDate(0, 1, 0)
+		subscription.SubscriptionID = "sub_" + clerkID
+		subscription.SubscriptionPlan = plan
+		subscription.SubscriptionStatus = "active"
+		subscription.SubscriptionStart = &start
+		subscription.SubscriptionEnd = &end
+	}
+	if err := users.UpdateUserSubscription(ctx, clerkID, subscription); err != nil {
+		return nil, err
+	}
+
+	account := model.SocialAccount{
+		Provider:  "github",
+		AccountID: clerkID,
+		Username:  "demo-" + plan,
+		IsActive:  true,
+	}
+	linked := false
+	for _, existing := range user.SocialAccounts {
+		if existing.Provider == account.Provider && existing.AccountID == account.AccountID {
+			linked = true
+			break
+		}
+	}
+	if !linked {
+		if err := users.LinkSocialAccount(ctx, clerkID, &account); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := users.UpdateUserPreferences(ctx, clerkID, map[string]interface{}{
+		"language":       "zh",
+		"theme":          "dark",
+		"editor":         "markdown",
+		"default_export": "pdf",
+	}); err != nil {
+		return nil, err
+	}
+
+	return users.GetUserByClerkID(ctx, clerkID)
+}
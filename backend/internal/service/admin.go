@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"github.com/yszaryszar/NicheFlow/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// 管理操作错误
+var (
+	ErrInvalidUserStatus     = errors.New("无效的用户状态")
+	ErrInvalidSuspendedUntil = errors.New("暂停截止时间只能用于暂停状态，且必须晚于当前时间")
+)
+
+// AuditReasonSuspensionExpired 暂停到期自动恢复时写入审计日志的原因
+const AuditReasonSuspensionExpired = "暂停期限已到，自动恢复"
+
+// UserFilter 管理员查询用户的条件
+type UserFilter struct {
+	Email       string     // 邮箱，模糊匹配
+	ClerkID     string     // Clerk ID，精确匹配
+	Plan        string     // 订阅计划
+	Status      string     // 用户状态
+	CreatedFrom *time.Time // 注册时间起
+	CreatedTo   *time.Time // 注册时间止
+	Page        int        // 页码，从 1 开始
+	PageSize    int        // 每页数量
+}
+
+// UsageLimits 使用限制调整参数，为 nil 的字段保持不变
+type UsageLimits struct {
+	UsageLimit   *int `json:"usage_limit" binding:"omitempty,min=0"`   // 总使用限制
+	MonthlyLimit *int `json:"monthly_limit" binding:"omitempty,min=0"` // 月度使用限制
+}
+
+// AdminService 提供管理员对用户的查询和管理服务
+// 所有修改操作都会记录操作者和原因到审计日志，并使用户缓存失效
+type AdminService struct {
+	db    *gorm.DB
+	users *UserService // 用于使用户缓存失效
+}
+
+// NewAdminService 创建一个新的管理员服务实例
+//
+// 参数:
+//   - db: 数据库连接
+//   - users: 用户服务，修改用户后通过它使用户缓存失效
+func NewAdminService(db *gorm.DB, users *UserService) *AdminService {
+	return &AdminService{
+		db:    db,
+		users: users,
+	}
+}
+
+// SearchUsers 按条件分页查询用户
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - filter: 查询条件
+//
+// 返回:
+//   - []model.User: 用户列表，按注册时间倒序
+//   - int64: 符合条件的总数
+//   - error: 查询过程中的错误信息，如果成功则为 nil
+func (s *AdminService) SearchUsers(ctx context.Context, filter UserFilter) ([]model.User, int64, error) {
+	query := s.db.WithContext(ctx).Model(&model.User{})
+	if filter.Email != "" {
+		query = query.Where(`LOWER(email) LIKE ? ESCAPE '\'`, "%"+escapeLike(strings.ToLower(filter.Email))+"%")
+	}
+	if filter.ClerkID != "" {
+		query = query.Where("clerk_id = ?", filter.ClerkID)
+	}
+	if filter.Plan != "" {
+		query = query.Where("subscription_plan = ?", filter.Plan)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", *filter.CreatedTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	page, pageSize := NormalizePage(filter.Page, filter.PageSize)
+	var users []model.User
+	err := query.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&users).Error
+	return users, total, err
+}
+
+// likeEscaper 转义 LIKE 模式中的通配符，配合 ESCAPE '\' 使用
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike 转义用户输入中的 %、_ 和 \，使其在 LIKE 模式中按字面匹配
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// UpdateUserStatus 修改用户状态
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - actor: 执行操作的管理员
+//   - userID: 用户 ID
+//   - status: 新状态（active/suspended/banned/pending_deletion）
+//   - until: 暂停截止时间，仅在暂停时有效，为 nil 表示无限期暂停
+//   - reason: 操作原因，同时保存到用户记录
+//
+// 返回:
+//   - error: 状态无效、截止时间无效或用户不存在时返回对应错误
+func (s *AdminService) UpdateUserStatus(ctx context.Context, actor Actor, userID uint, status string, until *time.Time, reason string) error {
+	switch status {
+	case model.UserStatusActive, model.UserStatusSuspended, model.UserStatusBanned, model.UserStatusPendingDeletion:
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidUserStatus, status)
+	}
+	if until != nil && (status != model.UserStatusSuspended || !until.After(time.Now())) {
+		return ErrInvalidSuspendedUntil
+	}
+
+	return s.updateUser(ctx, actor, userID, AuditActionStatus, reason, func(tx *gorm.DB, user *model.User) (interface{}, error) {
+		detail := map[string]interface{}{"from": user.Status, "to": status}
+		if until != nil {
+			detail["until"] = until.UTC()
+		}
+		return detail, tx.Model(user).Updates(map[string]interface{}{
+			"status":          status,
+			"status_reason":   reason,
+			"suspended_until": until,
+		}).Error
+	})
+}
+
+// RestoreExpiredSuspension 将暂停已到期的用户恢复为正常状态
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - user: 当前加载的用户
+//
+// 返回:
+//   - bool: 是否由本次调用完成了恢复
+//   - error: 更新过程中的错误信息
+//
+// 说明:
+//
+//	暂停到期后的首次请求会触发恢复。更新以状态和截止时间为条件，
+//	多个实例并发恢复时只有一个会成功并写入审计日志，审计日志的操作者为系统（ID 为 0）。
+func (s *AdminService) RestoreExpiredSuspension(ctx context.Context, user *model.User) (bool, error) {
+	if user.Status != model.UserStatusSuspended || user.EffectiveStatus(time.Now()) != model.UserStatusActive {
+		return false, nil
+	}
+
+	restored := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND status = ? AND suspended_until <= ?", user.ID, model.UserStatusSuspended, time.Now()).
+			Updates(map[string]interface{}{
+				"status":          model.UserStatusActive,
+				"status_reason":   AuditReasonSuspensionExpired,
+				"suspended_until": nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		restored = true
+		detail := map[string]interface{}{"from": model.UserStatusSuspended, "to": model.UserStatusActive, "until": user.SuspendedUntil.UTC()}
+		return recordAudit(tx, Actor{}, user.ID, AuditActionStatus, AuditReasonSuspensionExpired, detail)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	s.users.InvalidateUserCache(ctx, user.ID, user.ClerkID)
+	return restored, nil
+}
+
+// RestoreExpiredSuspensions 恢复全部暂停已到期的用户
+//
+// 参数:
+//   - ctx: 上下文对象
+//
+// 返回:
+//   - int: 本次恢复的用户数量
+//   - error: 查询或恢复过程中的错误，出错前已恢复的用户不会回滚
+//
+// 说明:
+//
+//	用户下次请求时也会自动恢复，该方法由后台任务定期调用，使状态及时反映在管理后台和审计日志中。
+func (s *AdminService) RestoreExpiredSuspensions(ctx context.Context) (int, error) {
+	var users []model.User
+	err := s.db.WithContext(ctx).
+		Where("status = ? AND suspended_until <= ?", model.UserStatusSuspended, time.Now()).
+		Order("id").
+		Find(&users).Error
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range users {
+		restored, err := s.RestoreExpiredSuspension(ctx, &users[i])
+		if err != nil {
+			return count, fmt.Errorf("恢复用户 %d 失败: %w", users[i].ID, err)
+		}
+		if restored {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// UpdateUsageLimits 调整用户的使用限制
+func (s *AdminService) UpdateUsageLimits(ctx context.Context, actor Actor, userID uint, limits UsageLimits, reason string) error {
+	return s.updateUser(ctx, actor, userID, AuditActionLimits, reason, func(tx *gorm.DB, user *model.User) (interface{}, error) {
+		updates := make(map[string]interface{})
+		detail := make(map[string]interface{})
+		if limits.UsageLimit != nil {
+			updates["usage_limit"] = *limits.UsageLimit
+			detail["usage_limit"] = map[string]int{"from": user.UsageLimit, "to": *limits.UsageLimit}
+		}
+		if limits.MonthlyLimit != nil {
+			updates["monthly_limit"] = *limits.MonthlyLimit
+			detail["monthly_limit"] = map[string]int{"from": user.MonthlyLimit, "to": *limits.MonthlyLimit}
+		}
+		if len(updates) == 0 {
+			return detail, nil
+		}
+		return detail, tx.Model(user).Updates(updates).Error
+	})
+}
+
+// ResetUsage 重置用户的总使用计数和月度使用计数
+func (s *AdminService) ResetUsage(ctx context.Context, actor Actor, userID uint, reason string) error {
+	return s.updateUser(ctx, actor, userID, AuditActionUsageReset, reason, func(tx *gorm.DB, user *model.User) (interface{}, error) {
+		detail := map[string]int{"usage_count": user.UsageCount, "monthly_count": user.MonthlyCount}
+		return detail, tx.Model(user).Updates(map[string]interface{}{
+			"usage_count":     0,
+			"monthly_count":   0,
+			"last_reset_time": time.Now(),
+		}).Error
+	})
+}
+
+// ResyncUser 使用身份提供方返回的最新资料覆盖用户的资料字段
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - actor: 执行操作的管理员
+//   - userID: 用户 ID
+//   - profile: 从身份提供方拉取的资料，只使用资料相关字段
+//   - reason: 操作原因
+//
+// 返回:
+//   - error: 用户不存在时返回 ErrUserNotFound，新邮箱已被其他用户使用时返回 ErrEmailInUse
+func (s *AdminService) ResyncUser(ctx context.Context, actor Actor, userID uint, profile *model.User, reason string) error {
+	return s.updateUser(ctx, actor, userID, AuditActionResync, reason, func(tx *gorm.DB, user *model.User) (interface{}, error) {
+		detail := map[string]string{"email_from": user.Email, "email_to": profile.Email}
+		// 通过仓储更新，邮箱已被其他用户使用时返回 ErrEmailInUse
+		return detail, repository.New(tx).Users().Update(ctx, user.ID, map[string]interface{}{
+			"email":          profile.Email,
+			"username":       profile.Username,
+			"first_name":     profile.FirstName,
+			"last_name":      profile.LastName,
+			"image_url":      profile.ImageURL,
+			"email_verified": profile.EmailVerified,
+			"phone_number":   profile.PhoneNumber,
+			"phone_verified": profile.PhoneVerified,
+		})
+	})
+}
+
+// updateUser 在事务中加载用户、执行修改并写入审计日志，完成后使用户缓存失效
+func (s *AdminService) updateUser(ctx context.Context, actor Actor, userID uint, action, reason string, apply func(tx *gorm.DB, user *model.User) (interface{}, error)) error {
+	var user model.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		detail, err := apply(tx, &user)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, user.ID, action, reason, detail)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.users.InvalidateUserCache(ctx, user.ID, user.ClerkID)
+	return nil
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// API 密钥格式：nf_<前缀>_<密钥>
+const (
+	apiKeyPrefix       = "nf_" // 固定前缀，便于识别和密钥扫描
+	apiKeyIDBytes      = 6     // 查找前缀的随机字节数
+	apiKeySecretBytes  = 24    // 密钥部分的随机字节数
+	maxAPIKeysPerUser  = 10    // 每个用户最多拥有的有效密钥数
+	apiKeyTouchMinimum = time.Minute
+)
+
+// API 密钥相关错误
+var (
+	ErrInvalidAPIKey      = errors.New("无效的 API 密钥")
+	ErrAPIKeyExpired      = errors.New("API 密钥已过期")
+	ErrAPIKeyNotFound     = errors.New("未找到指定的 API 密钥")
+	ErrAPIKeyLimitReached = errors.New("API 密钥数量已达上限")
+	ErrInvalidAPIKeyScope = errors.New("无效的 API 密钥权限范围")
+)
+
+// APIKeyService 提供用户 API 密钥的管理和校验服务
+type APIKeyService struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyService 创建一个新的 API 密钥服务实例
+//
+// 参数:
+//   - db: 数据库连接
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{
+		db: db,
+	}
+}
+
+// IsAPIKey 判断令牌是否为 API 密钥格式
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// CreateAPIKey 为用户创建 API 密钥
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - userID: 用户 ID
+//   - name: 密钥名称
+//   - scopes: 权限范围，为空时默认为只读
+//   - expiresAt: 过期时间，为 nil 表示永不过期
+//
+// 返回:
+//   - *model.APIKey: 创建的密钥记录
+//   - string: 密钥明文，仅在此时返回一次
+//   - error: 创建过程中的错误信息，如果成功则为 nil
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, userID uint, name string, scopes []string, expiresAt *time.Time) (*model.APIKey, string, error) {
+	normalized, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("user_id = ? AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Count(&count).Error; err != nil {
+		return nil, "", err
+	}
+	if count >= maxAPIKeysPerUser {
+		return nil, "", ErrAPIKeyLimitReached
+	}
+
+	idPart, err := randomHex(apiKeyIDBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	secretPart, err := randomHex(apiKeySecretBytes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	prefix := apiKeyPrefix + idPart
+	plaintext := prefix + "_" + secretPart
+	key := &model.APIKey{
+		UserID:     userID,
+		Name:       name,
+		Prefix:     prefix,
+		SecretHash: hashToken(plaintext),
+		Scopes:     strings.Join(normalized, ","),
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.db.WithContext(ctx).Create(key).Error; err != nil {
+		return nil, "", err
+	}
+
+	return key, plaintext, nil
+}
+
+// ListAPIKeys 获取用户的 API 密钥列表
+func (s *APIKeyService) ListAPIKeys(ctx context.Context, userID uint) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := s.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey 吊销用户的 API 密钥
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, userID, keyID uint) error {
+	result := s.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", keyID, userID).
+		Delete(&model.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey 校验 API 密钥明文
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - plaintext: 请求携带的密钥明文
+//
+// 返回:
+//   - *model.APIKey: 匹配的密钥记录
+//   - error: 密钥无效或已过期时返回 ErrInvalidAPIKey 或 ErrAPIKeyExpired
+//
+// 说明:
+//
+//	通过前缀定位密钥记录，再以常量时间比较哈希值。
+//	校验成功后更新最后使用时间，同一分钟内只更新一次以减少写入。
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, plaintext string) (*model.APIKey, error) {
+	idx := strings.LastIndex(plaintext, "_")
+	if !IsAPIKey(plaintext) || idx <= len(apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	prefix := plaintext[:idx]
+
+	var key model.APIKey
+	err := s.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashToken(plaintext)), []byte(key.SecretHash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.IsExpired(now) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchMinimum {
+		if err := s.db.WithContext(ctx).Model(&key).UpdateColumn("last_used_at", now).Error; err != nil {
+			return nil, err
+		}
+		key.LastUsedAt = &now
+	}
+
+	return &key, nil
+}
+
+// normalizeScopes 校验并去重权限范围，write 隐含 read
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return []string{model.APIKeyScopeRead}, nil
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		switch scope {
+		case model.APIKeyScopeRead, model.APIKeyScopeWrite:
+			seen[scope] = true
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInvalidAPIKeyScope, scope)
+		}
+	}
+
+	normalized := []string{model.APIKeyScopeRead}
+	if seen[model.APIKeyScopeWrite] {
+		normalized = append(normalized, model.APIKeyScopeWrite)
+	}
+	return normalized, nil
+}
+
+// hashToken 计算密钥或令牌明文的 SHA-256 哈希
+func hashToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex 生成指定字节数的随机十六进制字符串
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,178 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/auth"
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"github.com/yszaryszar/NicheFlow/backend/internal/service"
+	"github.com/yszaryszar/NicheFlow/backend/internal/testutil"
+	"gorm.io/gorm"
+)
+
+// TestCreateAPIKey 校验密钥格式、哈希存储和权限范围
+func TestCreateAPIKey(t *testing.T) {
+	db := testutil.SetupTestDB()
+	defer testutil.CleanupTestDB(db)
+	apiKeys := service.NewAPIKeyService(db)
+	user := createTestUser(t, db, "apikey-create")
+
+	cases := []struct {
+		name      string
+		scopes    []string
+		want      []string
+		wantWrite bool
+		wantErr   error
+	}{
+		{name: "默认只读", scopes: nil, want: []string{"read"}},
+		{name: "只读", scopes: []string{"read"}, want: []string{"read"}},
+		{name: "写权限隐含读权限", scopes: []string{"write"}, want: []string{"read", "write"}, wantWrite: true},
+		{name: "重复的权限范围", scopes: []string{"write", "read", "write"}, want: []string{"read", "write"}, wantWrite: true},
+		{name: "未知的权限范围", scopes: []string{"read", "admin"}, wantErr: service.ErrInvalidAPIKeyScope},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, plaintext, err := apiKeys.CreateAPIKey(context.Background(), user.ID, tc.name, tc.scopes, nil)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("CreateAPIKey 返回 %v，期望 %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateAPIKey 返回 %v", err)
+			}
+
+			if !service.IsAPIKey(plaintext) || !strings.HasPrefix(plaintext, key.Prefix+"_") {
+				t.Fatalf("密钥明文 %q 与前缀 %q 不匹配", plaintext, key.Prefix)
+			}
+			sum := sha256.Sum256([]byte(plaintext))
+			if key.SecretHash != hex.EncodeToString(sum[:]) {
+				t.Fatalf("存储的哈希不是密钥明文的 SHA-256")
+			}
+			var stored model.APIKey
+			if err := db.First(&stored, key.ID).Error; err != nil {
+				t.Fatalf("查询密钥记录失败: %v", err)
+			}
+			secret := strings.TrimPrefix(plaintext, key.Prefix+"_")
+			if strings.Contains(stored.SecretHash, secret) || strings.Contains(stored.Prefix, secret) {
+				t.Fatalf("数据库中保存了密钥明文")
+			}
+
+			if got := stored.ScopeList(); !slices.Equal(got, tc.want) {
+				t.Fatalf("权限范围为 %v，期望 %v", got, tc.want)
+			}
+			principal := auth.NewAPIKeyPrincipal(user, &stored)
+			if principal.HasScope(model.APIKeyScopeWrite) != tc.wantWrite {
+				t.Fatalf("HasScope(write) 为 %v，期望 %v", !tc.wantWrite, tc.wantWrite)
+			}
+			if !principal.HasScope(model.APIKeyScopeRead) {
+				t.Fatalf("API 密钥没有读权限")
+			}
+		})
+	}
+}
+
+// TestAuthenticateAPIKey 校验密钥明文、过期和吊销
+func TestAuthenticateAPIKey(t *testing.T) {
+	db := testutil.SetupTestDB()
+	defer testutil.CleanupTestDB(db)
+	apiKeys := service.NewAPIKeyService(db)
+	user := createTestUser(t, db, "apikey-auth")
+	other := createTestUser(t, db, "apikey-other")
+	ctx := context.Background()
+
+	newKey := func(expiresAt *time.Time) (*model.APIKey, string) {
+		key, plaintext, err := apiKeys.CreateAPIKey(ctx, user.ID, "测试密钥", nil, expiresAt)
+		if err != nil {
+			t.Fatalf("CreateAPIKey 返回 %v", err)
+		}
+		return key, plaintext
+	}
+
+	valid, validPlaintext := newKey(nil)
+	expiresAt := time.Now().Add(time.Hour)
+	_, expiringPlaintext := newKey(&expiresAt)
+	_, expiredPlaintext := newKey(nil)
+	if err := db.Model(&model.APIKey{}).Where("prefix = ?", prefixOf(expiredPlaintext)).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("设置过期时间失败: %v", err)
+	}
+	revoked, revokedPlaintext := newKey(nil)
+	if err := apiKeys.RevokeAPIKey(ctx, other.ID, revoked.ID); !errors.Is(err, service.ErrAPIKeyNotFound) {
+		t.Fatalf("吊销其他用户的密钥返回 %v，期望 ErrAPIKeyNotFound", err)
+	}
+	if err := apiKeys.RevokeAPIKey(ctx, user.ID, revoked.ID); err != nil {
+		t.Fatalf("RevokeAPIKey 返回 %v", err)
+	}
+	if err := apiKeys.RevokeAPIKey(ctx, user.ID, revoked.ID); !errors.Is(err, service.ErrAPIKeyNotFound) {
+		t.Fatalf("重复吊销返回 %v，期望 ErrAPIKeyNotFound", err)
+	}
+
+	cases := []struct {
+		name      string
+		plaintext string
+		want      error
+	}{
+		{name: "有效密钥", plaintext: validPlaintext},
+		{name: "未过期的密钥", plaintext: expiringPlaintext},
+		{name: "已过期的密钥", plaintext: expiredPlaintext, want: service.ErrAPIKeyExpired},
+		{name: "已吊销的密钥", plaintext: revokedPlaintext, want: service.ErrInvalidAPIKey},
+		{name: "密钥部分被篡改", plaintext: validPlaintext[:len(validPlaintext)-1] + flip(validPlaintext[len(validPlaintext)-1]), want: service.ErrInvalidAPIKey},
+		{name: "只有前缀", plaintext: prefixOf(validPlaintext) + "_", want: service.ErrInvalidAPIKey},
+		{name: "未知前缀", plaintext: "nf_000000000000_" + strings.Repeat("0", 48), want: service.ErrInvalidAPIKey},
+		{name: "不是 API 密钥格式", plaintext: "sk_live_abc", want: service.ErrInvalidAPIKey},
+		{name: "缺少密钥部分", plaintext: "nf_", want: service.ErrInvalidAPIKey},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := apiKeys.AuthenticateAPIKey(ctx, tc.plaintext)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("AuthenticateAPIKey 返回 %v，期望 %v", err, tc.want)
+			}
+			if err == nil && key.UserID != user.ID {
+				t.Fatalf("密钥属于用户 %d，期望 %d", key.UserID, user.ID)
+			}
+		})
+	}
+
+	var touched model.APIKey
+	if err := db.First(&touched, valid.ID).Error; err != nil {
+		t.Fatalf("查询密钥记录失败: %v", err)
+	}
+	if touched.LastUsedAt == nil {
+		t.Fatalf("校验成功后没有记录最后使用时间")
+	}
+}
+
+// createTestUser 创建测试用户
+func createTestUser(t *testing.T, db *gorm.DB, name string) *model.User {
+	t.Helper()
+	user := &model.User{ClerkID: "user_" + name, Email: name + "@example.com", Username: name}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("创建测试用户失败: %v", err)
+	}
+	return user
+}
+
+// prefixOf 返回密钥明文的查找前缀
+func prefixOf(plaintext string) string {
+	return plaintext[:strings.LastIndex(plaintext, "_")]
+}
+
+// flip 返回与 c 不同的十六进制字符
+func flip(c byte) string {
+	if c == '0' {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// 审计操作类型
+const (
+	AuditActionRoleAssign = "role.assign"      // 分配角色
+	AuditActionStatus     = "user.status"      // 修改用户状态
+	AuditActionLimits     = "user.limits"      // 调整使用限制
+	AuditActionUsageReset = "user.usage_reset" // 重置使用计数
+	AuditActionResync     = "user.resync"      // 从身份提供方重新同步
+)
+
+// Actor 执行管理操作的主体，用于写入审计日志
+type Actor struct {
+	UserID uint   // 操作者用户 ID
+	IP     string // 操作者 IP
+}
+
+// AuditLogFilter 审计日志查询条件
+type AuditLogFilter struct {
+	ActorID   uint   // 按操作者过滤
+	SubjectID uint   // 按被操作用户过滤
+	Action    string // 按操作类型过滤
+	Page      int    // 页码，从 1 开始
+	PageSize  int    // 每页数量
+}
+
+// AuditService 提供审计日志的写入和查询服务
+type AuditService struct {
+	db *gorm.DB
+}
+
+// NewAuditService 创建一个新的审计日志服务实例
+//
+// 参数:
+//   - db: 数据库连接
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{
+		db: db,
+	}
+}
+
+// ListAuditLogs 分页查询审计日志
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - filter: 查询条件
+//
+// 返回:
+//   - []model.AuditLog: 审计日志列表，按时间倒序
+//   - int64: 符合条件的总数
+//   - error: 查询过程中的错误信息，如果成功则为 nil
+func (s *AuditService) ListAuditLogs(ctx context.Context, filter AuditLogFilter) ([]model.AuditLog, int64, error) {
+	query := s.db.WithContext(ctx).Model(&model.AuditLog{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.SubjectID != 0 {
+		query = query.Where("subject_id = ?", filter.SubjectID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	page, pageSize := NormalizePage(filter.Page, filter.PageSize)
+	var logs []model.AuditLog
+	err := query.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&logs).Error
+	return logs, total, err
+}
+
+// recordAudit 在事务中写入审计日志，detail 会被序列化为 JSON
+func recordAudit(tx *gorm.DB, actor Actor, subjectID uint, action, reason string, detail interface{}) error {
+	entry := model.AuditLog{
+		ActorID:   actor.UserID,
+		SubjectID: subjectID,
+		Action:    action,
+		Reason:    reason,
+		IP:        actor.IP,
+	}
+	if detail != nil {
+		data, err := json.Marshal(detail)
+		if err != nil {
+			return err
+		}
+		entry.Detail = string(data)
+	}
+	return tx.Create(&entry).Error
+}
+
+// NormalizePage 规范化分页参数
+//
+// 参数:
+//   - page: 页码，小于 1 时使用第 1 页
+//   - pageSize: 每页数量，小于 1 时使用 20，最大为 100
+//
+// 返回:
+//   - int: 规范化后的页码
+//   - int: 规范化后的每页数量
+func NormalizePage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+	return page, pageSize
+}
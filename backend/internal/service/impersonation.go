@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// 模拟登录令牌格式：nfimp_<密钥>
+const (
+	impersonationTokenPrefix = "nfimp_"
+	impersonationTokenBytes  = 32
+
+	DefaultImpersonationTTL = 15 * time.Minute // 默认有效期
+	MaxImpersonationTTL     = time.Hour        // 最长有效期
+)
+
+// 模拟登录审计操作类型
+const (
+	AuditActionImpersonationStart   = "impersonation.start"   // 开始模拟
+	AuditActionImpersonationRevoke  = "impersonation.revoke"  // 结束模拟
+	AuditActionImpersonationRequest = "impersonation.request" // 模拟期间的请求
+)
+
+// 模拟登录相关错误
+var (
+	ErrInvalidImpersonation  = errors.New("无效的模拟登录令牌")
+	ErrImpersonationExpired  = errors.New("模拟登录会话已过期或已结束")
+	ErrImpersonationNotFound = errors.New("未找到指定的模拟登录会话")
+	ErrCannotImpersonate     = errors.New("不能模拟自己或拥有管理角色的用户")
+)
+
+// ImpersonationService 提供模拟登录会话的签发、校验和审计服务
+type ImpersonationService struct {
+	db *gorm.DB
+}
+
+// NewImpersonationService 创建一个新的模拟登录服务实例
+//
+// 参数:
+//   - db: 数据库连接
+func NewImpersonationService(db *gorm.DB) *ImpersonationService {
+	return &ImpersonationService{
+		db: db,
+	}
+}
+
+// IsImpersonationToken 判断令牌是否为模拟登录令牌格式
+func IsImpersonationToken(token string) bool {
+	return strings.HasPrefix(token, impersonationTokenPrefix)
+}
+
+// StartImpersonation 签发以目标用户身份访问的短期会话
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - actor: 发起模拟的管理员
+//   - subjectID: 被模拟的用户 ID
+//   - scopes: 权限范围，为空时默认为只读
+//   - ttl: 有效期，为 0 时使用默认值，不能超过 MaxImpersonationTTL
+//   - reason: 发起原因
+//
+// 返回:
+//   - *model.ImpersonationSession: 创建的会话记录
+//   - string: 令牌明文，仅在此时返回一次
+//   - error: 目标用户不存在或不允许模拟时返回对应错误
+//
+// 说明:
+//
+//	只能模拟普通用户，避免借助模拟获得其他管理员的权限。
+func (s *ImpersonationService) StartImpersonation(ctx context.Context, actor Actor, subjectID uint, scopes []string, ttl time.Duration, reason string) (*model.ImpersonationSession, string, error) {
+	normalized, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if ttl <= 0 {
+		ttl = DefaultImpersonationTTL
+	}
+	if ttl > MaxImpersonationTTL {
+		ttl = MaxImpersonationTTL
+	}
+
+	secret, err := randomHex(impersonationTokenBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	token := impersonationTokenPrefix + secret
+
+	session := &model.ImpersonationSession{
+		ActorID:   actor.UserID,
+		SubjectID: subjectID,
+		TokenHash: hashToken(token),
+		Scopes:    strings.Join(normalized, ","),
+		Reason:    reason,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var subject model.User
+		if err := tx.First(&subject, subjectID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		if subject.ID == actor.UserID || subject.Role != model.RoleUser {
+			return ErrCannotImpersonate
+		}
+
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, subjectID, AuditActionImpersonationStart, reason, map[string]interface{}{
+			"session_id": session.ID,
+			"scopes":     normalized,
+			"expires_at": session.ExpiresAt.UTC(),
+		})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return session, token, nil
+}
+
+// RevokeImpersonation 提前结束模拟登录会话
+func (s *ImpersonationService) RevokeImpersonation(ctx context.Context, actor Actor, sessionID uint, reason string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var session model.ImpersonationSession
+		if err := tx.First(&session, sessionID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrImpersonationNotFound
+			}
+			return err
+		}
+		if !session.IsActive(time.Now()) {
+			return ErrImpersonationExpired
+		}
+
+		if err := tx.Model(&session).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, session.SubjectID, AuditActionImpersonationRevoke, reason, map[string]interface{}{
+			"session_id": session.ID,
+			"started_by": session.ActorID,
+		})
+	})
+}
+
+// AuthenticateImpersonation 校验模拟登录令牌
+//
+// 返回:
+//   - *model.ImpersonationSession: 有效的会话记录
+//   - error: 令牌无效时返回 ErrInvalidImpersonation，过期或已结束时返回 ErrImpersonationExpired
+func (s *ImpersonationService) AuthenticateImpersonation(ctx context.Context, token string) (*model.ImpersonationSession, error) {
+	if !IsImpersonationToken(token) {
+		return nil, ErrInvalidImpersonation
+	}
+
+	var session model.ImpersonationSession
+	err := s.db.WithContext(ctx).Where("token_hash = ?", hashToken(token)).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidImpersonation
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !session.IsActive(time.Now()) {
+		return nil, ErrImpersonationExpired
+	}
+	return &session, nil
+}
+
+// RecordImpersonatedRequest 将模拟期间的一次请求写入审计日志
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - session: 模拟登录会话
+//   - ip: 请求来源 IP
+//   - method: 请求方法
+//   - path: 请求路径
+//   - status: 响应状态码
+func (s *ImpersonationService) RecordImpersonatedRequest(ctx context.Context, session *model.ImpersonationSession, ip, method, path string, status int) error {
+	actor := Actor{UserID: session.ActorID, IP: ip}
+	return recordAudit(s.db.WithContext(ctx), actor, session.SubjectID, AuditActionImpersonationRequest, session.Reason, map[string]interface{}{
+		"session_id": session.ID,
+		"method":     method,
+		"path":       path,
+		"status":     status,
+	})
+}
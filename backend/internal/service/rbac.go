@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// rolePermissionCacheTTL 角色权限的进程内缓存时间
+const rolePermissionCacheTTL = time.Minute
+
+// 角色相关错误
+var (
+	ErrRoleNotFound     = errors.New("角色不存在")
+	ErrUserNotFound     = errors.New("用户不存在")
+	ErrCannotChangeSelf = errors.New("不能修改自己的角色")
+)
+
+// rolePermissionEntry 角色权限缓存项
+type rolePermissionEntry struct {
+	permissions map[string]bool
+	expiresAt   time.Time
+}
+
+// RBACService 提供基于角色的权限校验和角色分配服务
+type RBACService struct {
+	db    *gorm.DB
+	users *UserService // 用于使用户缓存失效
+
+	cacheMu sync.RWMutex
+	cache   map[string]rolePermissionEntry // 角色到权限集合的进程内缓存
+}
+
+// NewRBACService 创建一个新的 RBAC 服务实例
+//
+// 参数:
+//   - db: 数据库连接
+//   - users: 用户服务，分配角色后通过它使用户缓存失效
+//
+// 说明:
+//
+//	角色权限缓存属于服务实例，同一进程内应共享同一个实例
+func NewRBACService(db *gorm.DB, users *UserService) *RBACService {
+	return &RBACService{
+		db:    db,
+		users: users,
+		cache: make(map[string]rolePermissionEntry),
+	}
+}
+
+// HasPermission 判断角色是否拥有指定权限
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - role: 角色名称
+//   - permission: 权限名称
+//
+// 返回:
+//   - bool: 是否拥有权限
+//   - error: 查询过程中的错误信息，如果成功则为 nil
+//
+// 说明:
+//
+//	角色权限在进程内缓存一分钟，角色分配变化无需等待缓存过期，
+//	因为分配修改的是用户的角色而不是角色的权限。
+func (s *RBACService) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	permissions, err := s.rolePermissions(ctx, role)
+	if err != nil {
+		return false, err
+	}
+	return permissions[permission], nil
+}
+
+// ListRoles 获取所有角色及其权限
+func (s *RBACService) ListRoles(ctx context.Context) ([]model.Role, error) {
+	var roles []model.Role
+	err := s.db.WithContext(ctx).Preload("Permissions").Order("id").Find(&roles).Error
+	return roles, err
+}
+
+// AssignRole 为用户分配角色并写入审计日志
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - actor: 执行操作的管理员
+//   - userID: 被分配角色的用户 ID
+//   - role: 角色名称
+//   - reason: 操作原因
+//
+// 返回:
+//   - error: 角色或用户不存在、修改自己的角色时返回对应错误
+func (s *RBACService) AssignRole(ctx context.Context, actor Actor, userID uint, role, reason string) error {
+	if actor.UserID == userID {
+		return ErrCannotChangeSelf
+	}
+
+	var user model.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Role{}).Where("name = ?", role).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrRoleNotFound
+		}
+
+		if err := tx.First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		previous := user.Role
+		if err := tx.Model(&user).Update("role", role).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, user.ID, AuditActionRoleAssign, reason, map[string]string{
+			"from": previous,
+			"to":   role,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.users.InvalidateUserCache(ctx, user.ID, user.ClerkID)
+	return nil
+}
+
+// rolePermissions 获取角色的权限集合，优先读取进程内缓存
+func (s *RBACService) rolePermissions(ctx context.Context, role string) (map[string]bool, error) {
+	now := time.Now()
+
+	s.cacheMu.RLock()
+	entry, ok := s.cache[role]
+	s.cacheMu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.permissions, nil
+	}
+
+	var names []string
+	err := s.db.WithContext(ctx).
+		Table("permissions").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.name = ?", role).
+		Pluck("permissions.name", &names).Error
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make(map[string]bool, len(names))
+	for _, name := range names {
+		permissions[name] = true
+	}
+
+	s.cacheMu.Lock()
+	s.cache[role] = rolePermissionEntry{
+		permissions: permissions,
+		expiresAt:   now.Add(rolePermissionCacheTTL),
+	}
+	s.cacheMu.Unlock()
+
+	return permissions, nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+	"github.com/yszaryszar/NicheFlow/backend/internal/repository"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/cache"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/database"
+	"gorm.io/gorm"
+)
+
+// Services 应用使用的全部业务服务
+// 服务之间共享同一个数据库连接和用户缓存，进程内缓存（例如角色权限）保存在服务实例中，
+// 因此同一个应用实例应只创建一组服务并在处理器和中间件之间共享
+type Services struct {
+	Users         *UserService          // 用户服务
+	Admin         *AdminService         // 管理员服务
+	RBAC          *RBACService          // 角色权限服务
+	Audit         *AuditService         // 审计日志服务
+	APIKeys       *APIKeyService        // API 密钥服务
+	Impersonation *ImpersonationService // 模拟登录服务
+	Settings      *SettingsService      // AI 模型参数和功能开关
+}
+
+// NewServices 创建全部业务服务
+//
+// 参数:
+//   - cfg: 当前配置，AI 模型参数和功能开关取自该配置
+//   - db: 数据库连接，注册了只读副本时查询自动路由到副本
+//   - c: 用户缓存，为 nil 时不使用缓存
+//
+// 返回:
+//   - *Services: 业务服务集合
+func NewServices(cfg *config.Config, db *gorm.DB, c *cache.Cache) *Services {
+	users := NewUserService(repository.New(db), c)
+	users.replicas = database.ReplicasOf(db)
+	return &Services{
+		Users:         users,
+		Admin:         NewAdminService(db, users),
+		RBAC:          NewRBACService(db, users),
+		Audit:         NewAuditService(db),
+		APIKeys:       NewAPIKeyService(db),
+		Impersonation: NewImpersonationService(db),
+		Settings:      NewSettingsService(cfg),
+	}
+}
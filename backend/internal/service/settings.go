@@ -0,0 +1,71 @@
+package service
+
+import (
+	"maps"
+	"sync/atomic"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+)
+
+// settings 可在运行时替换的业务配置，替换后不再修改
+type settings struct {
+	openAI    config.OpenAIConfig
+	anthropic config.AnthropicConfig
+	features  map[string]bool
+}
+
+// SettingsService 提供 AI 模型参数和功能开关的读取
+// 配置重新加载后自动替换，调用方每次使用时读取，不应缓存返回值
+type SettingsService struct {
+	current atomic.Pointer[settings]
+}
+
+// NewSettingsService 创建一个新的业务配置服务实例
+//
+// 参数:
+//   - cfg: 初始配置
+func NewSettingsService(cfg *config.Config) *SettingsService {
+	s := &SettingsService{}
+	s.current.Store(newSettings(cfg))
+	return s
+}
+
+// Watch 订阅配置变更，重新加载后替换 AI 模型参数和功能开关
+//
+// 参数:
+//   - w: 运行时配置监视器
+//
+// 返回:
+//   - func(): 取消订阅
+func (s *SettingsService) Watch(w *config.Watcher) func() {
+	return w.Subscribe(func(cfg *config.Config) (func(), error) {
+		next := newSettings(cfg)
+		return func() {
+			s.current.Store(next)
+		}, nil
+	})
+}
+
+// OpenAI 返回当前的 OpenAI 配置
+func (s *SettingsService) OpenAI() config.OpenAIConfig {
+	return s.current.Load().openAI
+}
+
+// Anthropic 返回当前的 Anthropic 配置
+func (s *SettingsService) Anthropic() config.AnthropicConfig {
+	return s.current.Load().anthropic
+}
+
+// FeatureEnabled 判断功能开关是否开启，未配置的功能视为关闭
+func (s *SettingsService) FeatureEnabled(name string) bool {
+	return s.current.Load().features[name]
+}
+
+// newSettings 从完整配置中取出业务配置
+func newSettings(cfg *config.Config) *settings {
+	return &settings{
+		openAI:    cfg.OpenAI,
+		anthropic: cfg.Anthropic,
+		features:  maps.Clone(cfg.Features),
+	}
+}
@@ -4,24 +4,59 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"github.com/yszaryszar/NicheFlow/backend/internal/repository"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/cache"
 	"github.com/yszaryszar/NicheFlow/backend/pkg/database"
-	"gorm.io/gorm"
+	"golang.org/x/sync/singleflight"
 )
 
+// 新用户默认值
+const (
+	DefaultUserRole     = "user"                 // 默认角色
+	DefaultUserStatus   = model.UserStatusActive // 默认状态
+	DefaultUsageLimit   = 5                      // 默认总使用限制
+	DefaultMonthlyLimit = 3                      // 默认月度使用限制
+)
+
+// ErrEmailInUse 邮箱已被其他用户使用
+// 与 repository.ErrDuplicateEmail 是同一个错误
+var ErrEmailInUse = repository.ErrDuplicateEmail
+
+// ApplyNewUserDefaults 为新用户设置默认的角色、状态和使用限制
+// 所有创建用户的入口（同步接口、Webhook、自动创建）都应使用此函数，保证默认值一致
+func ApplyNewUserDefaults(user *model.User) {
+	user.Role = DefaultUserRole
+	user.Status = DefaultUserStatus
+	user.UsageLimit = DefaultUsageLimit
+	user.MonthlyLimit = DefaultMonthlyLimit
+	user.LastResetTime = time.Now()
+}
+
 // UserService 提供用户相关的业务逻辑服务
 // 包括用户信息管理、使用统计、订阅管理等功能
 type UserService struct {
-	db *gorm.DB
+	store     repository.Store   // 用户、社交账号和偏好设置的存取接口
+	cache     *cache.Cache       // 用户缓存，为 nil 时直接查询数据库
+	loadGroup singleflight.Group // 合并同一用户的并发加载，避免缓存失效时击穿数据库
+	replicas  *database.Replicas // 只读副本，为 nil 时所有查询使用主库
 }
 
 // NewUserService 创建一个新的用户服务实例
-// 返回 UserService 实例，用于处理用户相关的业务逻辑
-func NewUserService() *UserService {
+//
+// 参数:
+//   - store: 数据存取接口
+//   - c: 用户缓存，为 nil 时不使用缓存
+//
+// 返回:
+//   - *UserService: 用户服务实例，用于处理用户相关的业务逻辑
+func NewUserService(store repository.Store, c *cache.Cache) *UserService {
 	return &UserService{
-		db: database.GetDB(),
+		store: store,
+		cache: c,
 	}
 }
 
@@ -35,16 +70,20 @@ func NewUserService() *UserService {
 //   - *model.User: 用户信息
 //   - error: 错误信息，如果没有错误则为 nil
 func (s *UserService) GetUserByClerkID(ctx context.Context, clerkID string) (*model.User, error) {
-	var user model.User
-	err := s.db.WithContext(ctx).
-		Preload("SocialAccounts").
-		Preload("Preferences").
-		Where("clerk_id = ?", clerkID).
-		First(&user).Error
-	if err != nil {
-		return nil, err
-	}
-	return &user, nil
+	return s.store.Users().FindByClerkID(ctx, clerkID)
+}
+
+// GetUserByID 通过本地用户 ID 获取用户信息
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - id: 本地用户 ID
+//
+// 返回:
+//   - *model.User: 用户信息
+//   - error: 错误信息，如果没有错误则为 nil
+func (s *UserService) GetUserByID(ctx context.Context, id uint) (*model.User, error) {
+	return s.store.Users().FindByID(ctx, id)
 }
 
 // CreateUser 创建新用户
@@ -56,19 +95,67 @@ func (s *UserService) GetUserByClerkID(ctx context.Context, clerkID string) (*mo
 // 返回:
 //   - error: 创建过程中的错误信息，如果成功则为 nil
 func (s *UserService) CreateUser(ctx context.Context, user *model.User) error {
-	return s.db.WithContext(ctx).Create(user).Error
+	return s.store.Users().Create(ctx, user)
 }
 
-// UpdateUser 更新用户信息
+// ProvisionUser 为首次访问的已认证身份自动创建本地用户
 //
 // 参数:
 //   - ctx: 上下文对象
-//   - user: 包含更新信息的用户对象
+//   - user: 由身份信息构建的用户，ClerkID 必填
+//
+// 返回:
+//   - *model.User: 创建或已存在的用户
+//   - error: 邮箱已被其他用户使用时返回 ErrEmailInUse，
+//     Clerk ID 对应的本地用户已被删除时返回 repository.ErrNotFound
+//
+// 说明:
+//
+//	使用 ON CONFLICT (clerk_id) DO NOTHING 插入，多个实例并发创建同一用户时只有一个成功，
+//	其余请求读取已创建的记录，因此该方法可以安全地并发调用。
+func (s *UserService) ProvisionUser(ctx context.Context, user *model.User) (*model.User, error) {
+	ApplyNewUserDefaults(user)
+	if _, err := s.store.Users().CreateIfNotExists(ctx, user); err != nil {
+		return nil, err
+	}
+
+	created, err := s.GetUserByClerkID(ctx, user.ClerkID)
+	if err != nil {
+		return nil, err
+	}
+	s.InvalidateUserCache(ctx, created.ID, created.ClerkID)
+	return created, nil
+}
+
+// UpdateUserProfile 更新用户的资料字段
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - user: 要更新的用户，只使用 ID 和 ClerkID
+//   - profile: 新的资料，只写入用户名、姓名、头像，以及非零的最后登录时间
 //
 // 返回:
 //   - error: 更新过程中的错误信息，如果成功则为 nil
-func (s *UserService) UpdateUser(ctx context.Context, user *model.User) error {
-	return s.db.WithContext(ctx).Save(user).Error
+//
+// 说明:
+//
+//	只更新资料字段，不会用可能过期的用户快照覆盖角色、状态、使用次数和订阅信息。
+func (s *UserService) UpdateUserProfile(ctx context.Context, user *model.User, profile *model.User) error {
+	fields := map[string]interface{}{
+		"username":   profile.Username,
+		"first_name": profile.FirstName,
+		"last_name":  profile.LastName,
+		"image_url":  profile.ImageURL,
+	}
+	if !profile.LastSignInAt.IsZero() {
+		fields["last_sign_in_at"] = profile.LastSignInAt
+	}
+
+	if err := s.store.Users().Update(ctx, user.ID, fields); err != nil {
+		return err
+	}
+	s.InvalidateUserCache(ctx, user.ID, user.ClerkID)
+	return nil
 }
 
 // UpdateUserPreferences 更新用户偏好设置
@@ -82,9 +169,10 @@ func (s *UserService) UpdateUser(ctx context.Context, user *model.User) error {
 // 返回:
 //   - error: 更新过程中的错误信息，如果成功则为 nil
 func (s *UserService) UpdateUserPreferences(ctx context.Context, clerkID string, preferences map[string]interface{}) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var user model.User
-		if err := tx.Where("clerk_id = ?", clerkID).First(&user).Error; err != nil {
+	var user *model.User
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		if user, err = tx.Users().FindByClerkIDForUpdate(ctx, clerkID); err != nil {
 			return err
 		}
 
@@ -110,27 +198,26 @@ func (s *UserService) UpdateUserPreferences(ctx context.Context, clerkID string,
 				updates["notification_web"] = value
 			default:
 				// 对于非内置字段，保存到 user_preferences 表
-				pref := model.UserPreference{
-					UserID: user.ID,
-					Key:    key,
-					Value:  value.(string),
-				}
-				if err := tx.Where("user_id = ? AND key = ?", user.ID, key).
-					Assign(pref).
-					FirstOrCreate(&pref).Error; err != nil {
+				if err := tx.Preferences().Set(ctx, user.ID, key, fmt.Sprint(value)); err != nil {
 					return err
 				}
 			}
 		}
 
 		if len(updates) > 0 {
-			if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			if err := tx.Users().Update(ctx, user.ID, updates); err != nil {
 				return err
 			}
 		}
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.InvalidateUserCache(ctx, user.ID, clerkID)
+	return nil
 }
 
 // GetUserUsage 获取用户使用统计信息
@@ -143,13 +230,8 @@ func (s *UserService) UpdateUserPreferences(ctx context.Context, clerkID string,
 //   - *model.User: 包含使用统计信息的用户对象
 //   - error: 获取过程中的错误信息，如果成功则为 nil
 func (s *UserService) GetUserUsage(ctx context.Context, clerkID string) (*model.User, error) {
-	var user model.User
-	if err := s.db.Select("usage_limit, usage_count, monthly_limit, monthly_count, last_reset_time").
-		Where("clerk_id = ?", clerkID).
-		First(&user).Error; err != nil {
-		return nil, err
-	}
-	return &user, nil
+	return s.store.Users().FindColumns(ctx, clerkID,
+		"usage_limit", "usage_count", "monthly_limit", "monthly_count", "last_reset_time")
 }
 
 // IncrementUsage 增加用户使用次数
@@ -183,13 +265,12 @@ func (s *UserService) IncrementUsage(ctx context.Context, clerkID string) error
 	}
 
 	// 更新使用次数
-	return s.db.Model(&model.User{}).
-		Where("clerk_id = ?", clerkID).
-		Updates(map[string]interface{}{
-			"usage_count":     gorm.Expr("usage_count + 1"),
-			"monthly_count":   gorm.Expr("monthly_count + 1"),
-			"last_reset_time": user.LastResetTime,
-		}).Error
+	if err := s.store.Users().IncrementUsage(ctx, user.ID, user.LastResetTime); err != nil {
+		return err
+	}
+
+	s.InvalidateUserCache(ctx, user.ID, clerkID)
+	return nil
 }
 
 // GetUserSubscription 获取用户订阅信息
@@ -202,13 +283,8 @@ func (s *UserService) IncrementUsage(ctx context.Context, clerkID string) error
 //   - *model.User: 包含订阅信息的用户对象
 //   - error: 获取过程中的错误信息，如果成功则为 nil
 func (s *UserService) GetUserSubscription(ctx context.Context, clerkID string) (*model.User, error) {
-	var user model.User
-	if err := s.db.Select(
-		"subscription_id, subscription_plan, subscription_status, subscription_start, subscription_end, trial_end",
-	).Where("clerk_id = ?", clerkID).First(&user).Error; err != nil {
-		return nil, err
-	}
-	return &user, nil
+	return s.store.Users().FindColumns(ctx, clerkID,
+		"subscription_id", "subscription_plan", "subscription_status", "subscription_start", "subscription_end", "trial_end")
 }
 
 // UpdateUserSubscription 更新用户订阅信息
@@ -221,83 +297,99 @@ func (s *UserService) GetUserSubscription(ctx context.Context, clerkID string) (
 // 返回:
 //   - error: 更新过程中的错误信息，如果成功则为 nil
 func (s *UserService) UpdateUserSubscription(ctx context.Context, clerkID string, subscription *model.User) error {
-	return s.db.Model(&model.User{}).
-		Where("clerk_id = ?", clerkID).
-		Updates(map[string]interface{}{
-			"subscription_id":     subscription.SubscriptionID,
-			"subscription_plan":   subscription.SubscriptionPlan,
-			"subscription_status": subscription.SubscriptionStatus,
-			"subscription_start":  subscription.SubscriptionStart,
-			"subscription_end":    subscription.SubscriptionEnd,
-			"trial_end":           subscription.TrialEnd,
-		}).Error
+	user, err := s.store.Users().FindColumns(ctx, clerkID, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.Users().Update(ctx, user.ID, map[string]interface{}{
+		"subscription_id":     subscription.SubscriptionID,
+		"subscription_plan":   subscription.SubscriptionPlan,
+		"subscription_status": subscription.SubscriptionStatus,
+		"subscription_start":  subscription.SubscriptionStart,
+		"subscription_end":    subscription.SubscriptionEnd,
+		"trial_end":           subscription.TrialEnd,
+	}); err != nil {
+		return err
+	}
+
+	s.InvalidateUserCache(ctx, user.ID, clerkID)
+	return nil
 }
 
 // LinkSocialAccount 关联社交账号
 func (s *UserService) LinkSocialAccount(ctx context.Context, clerkID string, account *model.SocialAccount) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var user model.User
-		if err := tx.Where("clerk_id = ?", clerkID).First(&user).Error; err != nil {
+	var user *model.User
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		if user, err = tx.Users().FindByClerkIDForUpdate(ctx, clerkID); err != nil {
 			return err
 		}
 
 		// 检查是否已存在相同的社交账号
-		var count int64
-		tx.Model(&model.SocialAccount{}).
-			Where("user_id != ? AND provider = ? AND account_id = ?", user.ID, account.Provider, account.AccountID).
-			Count(&count)
-		if count > 0 {
+		linked, err := tx.SocialAccounts().LinkedToOtherUser(ctx, user.ID, account.Provider, account.AccountID)
+		if err != nil {
+			return err
+		}
+		if linked {
 			return errors.New("该社交账号已被其他用户绑定")
 		}
 
 		account.UserID = user.ID
 		account.LastUsed = &time.Time{}
-		return tx.Create(account).Error
+		return tx.SocialAccounts().Create(ctx, account)
 	})
+	if err != nil {
+		return err
+	}
+
+	s.InvalidateUserCache(ctx, user.ID, clerkID)
+	return nil
 }
 
 // UnlinkSocialAccount 解除社交账号关联
 func (s *UserService) UnlinkSocialAccount(ctx context.Context, clerkID string, provider string, accountID string) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var user model.User
-		if err := tx.Where("clerk_id = ?", clerkID).First(&user).Error; err != nil {
+	var user *model.User
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		if user, err = tx.Users().FindByClerkIDForUpdate(ctx, clerkID); err != nil {
 			return err
 		}
 
 		// 确保用户至少保留一个登录方式
-		var count int64
-		tx.Model(&model.SocialAccount{}).Where("user_id = ?", user.ID).Count(&count)
+		count, err := tx.SocialAccounts().CountByUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
 		if count <= 1 && user.Email == "" && user.PhoneNumber == "" {
 			return errors.New("必须保留至少一种登录方式")
 		}
 
-		result := tx.Where("user_id = ? AND provider = ? AND account_id = ?",
-			user.ID, provider, accountID).
-			Delete(&model.SocialAccount{})
-		if result.RowsAffected == 0 {
+		deleted, err := tx.SocialAccounts().DeleteByAccount(ctx, user.ID, provider, accountID)
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
 			return errors.New("未找到指定的社交账号")
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.InvalidateUserCache(ctx, user.ID, clerkID)
+	return nil
 }
 
 // GetUserSocialAccounts 获取用户的社交账号列表
 func (s *UserService) GetUserSocialAccounts(ctx context.Context, clerkID string) ([]model.SocialAccount, error) {
-	var accounts []model.SocialAccount
-	err := s.db.WithContext(ctx).
-		Joins("JOIN users ON users.id = social_accounts.user_id").
-		Where("users.clerk_id = ?", clerkID).
-		Find(&accounts).Error
-	return accounts, err
+	return s.store.SocialAccounts().ListByClerkID(ctx, clerkID)
 }
 
 // GetUserPreference 获取用户的偏好设置
 func (s *UserService) GetUserPreference(ctx context.Context, clerkID string) (map[string]interface{}, error) {
-	var user model.User
-	err := s.db.WithContext(ctx).
-		Preload("Preferences").
-		Where("clerk_id = ?", clerkID).
-		First(&user).Error
+	user, err := s.store.Users().FindByClerkID(ctx, clerkID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/cache"
+	"github.com/yszaryszar/NicheFlow/backend/pkg/database"
+)
+
+// userCacheTTL 用户缓存过期时间
+// 资料变更会主动失效缓存，TTL 只用于兜底并发写入导致的短暂不一致
+const userCacheTTL = 2 * time.Minute
+
+// userCacheKeyByClerkID 按 Clerk ID 缓存用户的键
+func userCacheKeyByClerkID(clerkID string) string {
+	return "user:clerk:" + clerkID
+}
+
+// userCacheKeyByID 按本地 ID 缓存用户的键
+func userCacheKeyByID(id uint) string {
+	return fmt.Sprintf("user:id:%d", id)
+}
+
+// GetCachedUserByClerkID 通过 Clerk ID 获取用户信息，优先读取 Redis 缓存
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - clerkID: Clerk 平台的用户唯一标识
+//
+// 返回:
+//   - *model.User: 用户信息
+//   - error: 错误信息，如果没有错误则为 nil
+//
+// 说明:
+//
+//	缓存未命中时从主库加载并回写缓存，同一用户的并发加载只会查询一次数据库。
+//	Redis 不可用时直接查询数据库。返回的用户对象可能与其他请求共享，调用方不应修改。
+func (s *UserService) GetCachedUserByClerkID(ctx context.Context, clerkID string) (*model.User, error) {
+	return s.loadCachedUser(ctx, userCacheKeyByClerkID(clerkID), func(ctx context.Context) (*model.User, error) {
+		return s.GetUserByClerkID(ctx, clerkID)
+	})
+}
+
+// GetCachedUserByID 通过本地用户 ID 获取用户信息，优先读取 Redis 缓存
+func (s *UserService) GetCachedUserByID(ctx context.Context, id uint) (*model.User, error) {
+	return s.loadCachedUser(ctx, userCacheKeyByID(id), func(ctx context.Context) (*model.User, error) {
+		return s.GetUserByID(ctx, id)
+	})
+}
+
+// loadCachedUser 读穿缓存的通用实现
+func (s *UserService) loadCachedUser(ctx context.Context, key string, load func(context.Context) (*model.User, error)) (*model.User, error) {
+	var user model.User
+	err := s.cache.GetJSON(ctx, key, &user)
+	if err == nil {
+		return &user, nil
+	}
+	if !errors.Is(err, cache.ErrCacheMiss) && !errors.Is(err, cache.ErrNotInitialized) {
+		log.Printf("读取用户缓存失败: %v", err)
+	}
+
+	value, err, _ := s.loadGroup.Do(key, func() (interface{}, error) {
+		// 与发起请求的生命周期解耦，避免首个请求取消导致其他等待者失败。
+		// 缓存由所有实例共享，而写入后的主库粘滞只在写入的实例上生效，
+		// 回填缓存时总是读取主库，避免其他实例把延迟副本上的旧数据写回缓存
+		loadCtx := database.WithPrimary(context.WithoutCancel(ctx))
+		loaded, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+		s.cacheUser(loadCtx, loaded)
+		return loaded, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*model.User), nil
+}
+
+// cacheUser 以 Clerk ID 和本地 ID 两个键写入用户缓存
+func (s *UserService) cacheUser(ctx context.Context, user *model.User) {
+	for _, key := range []string{userCacheKeyByClerkID(user.ClerkID), userCacheKeyByID(user.ID)} {
+		if err := s.cache.SetJSON(ctx, key, user, userCacheTTL); err != nil && !errors.Is(err, cache.ErrNotInitialized) {
+			log.Printf("写入用户缓存失败: %v", err)
+		}
+	}
+}
+
+// InvalidateUserCache 使用户缓存失效
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - id: 本地用户 ID，为 0 时忽略
+//   - clerkID: Clerk ID，为空时忽略
+//
+// 说明:
+//
+//	所有修改用户、偏好设置或社交账号的方法在写入成功后都应调用此方法。
+//	配置了只读副本时，当前实例上该用户随后一段时间内的查询会读取主库；
+//	缓存回填总是读取主库，其他实例不会把延迟副本上的旧数据写回缓存。
+func (s *UserService) InvalidateUserCache(ctx context.Context, id uint, clerkID string) {
+	s.replicas.Touch(clerkID)
+
+	var keys []string
+	if id != 0 {
+		keys = append(keys, userCacheKeyByID(id))
+	}
+	if clerkID != "" {
+		keys = append(keys, userCacheKeyByClerkID(clerkID))
+	}
+	if err := s.cache.Delete(ctx, keys...); err != nil && !errors.Is(err, cache.ErrNotInitialized) {
+		log.Printf("删除用户缓存失败: %v", err)
+	}
+}
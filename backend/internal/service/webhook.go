@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"github.com/yszaryszar/NicheFlow/backend/internal/repository"
+)
+
+// ErrWebhookEventProcessed 表示该 Webhook 事件已经处理过
+var ErrWebhookEventProcessed = errors.New("Webhook 事件已处理")
+
+// webhookSourceClerk Clerk Webhook 事件来源标识
+const webhookSourceClerk = "clerk"
+
+// SyncClerkUser 根据 Clerk 事件创建或更新用户及其社交账号
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - eventID: Webhook 事件唯一标识，用于幂等处理
+//   - eventType: Webhook 事件类型
+//   - user: 由事件数据转换得到的用户信息
+//   - accounts: 用户在 Clerk 中关联的第三方账号
+//
+// 返回:
+//   - error: 处理过程中的错误；事件已处理过时返回 ErrWebhookEventProcessed
+//
+// 说明:
+//
+//	用户不存在时按新用户默认值创建，存在时只同步 Clerk 管理的资料字段，
+//	不会覆盖角色、状态和使用限制。本地已软删除的用户会被恢复。
+//	社交账号以 Clerk 数据为准进行全量同步。
+//	邮箱已被其他本地用户使用时跳过邮箱，其余资料照常同步，
+//	避免事件反复失败导致 Clerk 无限重试。
+func (s *UserService) SyncClerkUser(ctx context.Context, eventID, eventType string, user *model.User, accounts []model.SocialAccount) error {
+	err := s.syncClerkUser(ctx, eventID, eventType, user, accounts, true)
+	if errors.Is(err, repository.ErrDuplicateEmail) {
+		log.Printf("Clerk 用户 %s 的邮箱已被其他用户使用，跳过邮箱同步", user.ClerkID)
+		err = s.syncClerkUser(ctx, eventID, eventType, user, accounts, false)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.InvalidateUserCache(ctx, user.ID, user.ClerkID)
+	return nil
+}
+
+// syncClerkUser 在事务中同步 Clerk 用户，syncEmail 为 false 时不写入事件中的邮箱
+func (s *UserService) syncClerkUser(ctx context.Context, eventID, eventType string, user *model.User, accounts []model.SocialAccount, syncEmail bool) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := recordWebhookEvent(ctx, tx, eventID, eventType); err != nil {
+			return err
+		}
+
+		existing, err := tx.Users().FindByClerkIDUnscopedForUpdate(ctx, user.ClerkID)
+		if errors.Is(err, repository.ErrNotFound) {
+			ApplyNewUserDefaults(user)
+			if !syncEmail {
+				user.Email = ""
+				user.EmailVerified = false
+			}
+			if err := tx.Users().Create(ctx, user); err != nil {
+				return err
+			}
+			return syncSocialAccounts(ctx, tx, user.ID, accounts)
+		}
+		if err != nil {
+			return err
+		}
+
+		fields := map[string]interface{}{
+			"username":        user.Username,
+			"first_name":      user.FirstName,
+			"last_name":       user.LastName,
+			"image_url":       user.ImageURL,
+			"phone_number":    user.PhoneNumber,
+			"phone_verified":  user.PhoneVerified,
+			"last_sign_in_at": user.LastSignInAt,
+		}
+		if syncEmail {
+			fields["email"] = user.Email
+			fields["email_verified"] = user.EmailVerified
+		}
+
+		if existing.DeletedAt.Valid {
+			if !syncEmail {
+				// 删除期间原邮箱可能已被其他用户占用，恢复时清空
+				fields["email"] = ""
+				fields["email_verified"] = false
+			}
+			err = tx.Users().Restore(ctx, existing.ID, fields)
+		} else {
+			err = tx.Users().Update(ctx, existing.ID, fields)
+		}
+		if err != nil {
+			return err
+		}
+		user.ID = existing.ID
+
+		return syncSocialAccounts(ctx, tx, user.ID, accounts)
+	})
+}
+
+// DeleteClerkUser 根据 Clerk 事件软删除用户及其社交账号
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - eventID: Webhook 事件唯一标识，用于幂等处理
+//   - clerkID: 被删除用户的 Clerk ID
+//
+// 返回:
+//   - error: 处理过程中的错误；事件已处理过时返回 ErrWebhookEventProcessed
+func (s *UserService) DeleteClerkUser(ctx context.Context, eventID, clerkID string) error {
+	var userID uint
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := recordWebhookEvent(ctx, tx, eventID, "user.deleted"); err != nil {
+			return err
+		}
+
+		user, err := tx.Users().FindByClerkIDForUpdate(ctx, clerkID)
+		if errors.Is(err, repository.ErrNotFound) {
+			// 本地没有该用户，无需处理
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		userID = user.ID
+
+		if err := tx.SocialAccounts().DeleteByUser(ctx, user.ID); err != nil {
+			return err
+		}
+		return tx.Users().Delete(ctx, user.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.InvalidateUserCache(ctx, userID, clerkID)
+	return nil
+}
+
+// recordWebhookEvent 记录 Webhook 事件，事件已存在时返回 ErrWebhookEventProcessed
+func recordWebhookEvent(ctx context.Context, tx repository.Store, eventID, eventType string) error {
+	recorded, err := tx.WebhookEvents().Record(ctx, &model.WebhookEvent{
+		EventID: eventID,
+		Source:  webhookSourceClerk,
+		Type:    eventType,
+	})
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return ErrWebhookEventProcessed
+	}
+	return nil
+}
+
+// syncSocialAccounts 以传入的账号列表为准同步用户的社交账号
+func syncSocialAccounts(ctx context.Context, tx repository.Store, userID uint, accounts []model.SocialAccount) error {
+	existing, err := tx.SocialAccounts().ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]model.SocialAccount, len(existing))
+	for _, account := range existing {
+		current[account.Provider+"/"+account.AccountID] = account
+	}
+
+	keep := make(map[uint]bool, len(accounts))
+	for _, account := range accounts {
+		if found, ok := current[account.Provider+"/"+account.AccountID]; ok {
+			keep[found.ID] = true
+			if err := tx.SocialAccounts().Update(ctx, found.ID, map[string]interface{}{
+				"email":      account.Email,
+				"username":   account.Username,
+				"avatar_url": account.AvatarURL,
+				"is_active":  true,
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		account.UserID = userID
+		if err := tx.SocialAccounts().Create(ctx, &account); err != nil {
+			return err
+		}
+	}
+
+	for _, account := range existing {
+		if keep[account.ID] {
+			continue
+		}
+		if err := tx.SocialAccounts().Delete(ctx, account.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PruneWebhookEvents 删除过期的 Webhook 事件记录
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - before: 删除在该时间之前记录的事件
+//
+// 返回:
+//   - int64: 删除的事件数量
+//   - error: 删除过程中的错误信息
+//
+// 说明:
+//
+//	事件记录只用于识别重复投递，保留时间需要长于 Webhook 发送方的最长重试时间。
+func (s *UserService) PruneWebhookEvents(ctx context.Context, before time.Time) (int64, error) {
+	return s.store.WebhookEvents().DeleteBefore(ctx, before)
+}
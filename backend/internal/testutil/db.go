@@ -1,20 +1,28 @@
 package testutil
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
+	"sync/atomic"
 	"time"
 
-	"github.com/yszaryszar/NicheFlow/backend/internal/model"
+	"github.com/yszaryszar/NicheFlow/backend/migrations"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// testDBSeq 测试数据库序号，保证每次调用得到独立的内存数据库
+var testDBSeq atomic.Int64
+
 // SetupTestDB 设置测试数据库
+// 每次调用返回独立的内存数据库，可以与 container.New 一起在并行测试中创建互不影响的应用实例
 func SetupTestDB() *gorm.DB {
 	// 使用内存数据库
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{
+	dsn := fmt.Sprintf("file:testdb%d?mode=memory&cache=shared", testDBSeq.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
 		Logger: logger.New(
 			log.New(os.Stdout, "\r\n", log.LstdFlags),
 			logger.Config{
@@ -24,18 +32,21 @@ func SetupTestDB() *gorm.DB {
 				Colorful:                  false,
 			},
 		),
-		// 禁用外键约束以避免测试中的问题
-		DisableForeignKeyConstraintWhenMigrating: true,
 	})
 	if err != nil {
 		log.Fatalf("设置测试数据库失败: %v", err)
 	}
 
-	// 迁移数据库结构
-	err = db.AutoMigrate(
-		&model.User{}, // 用户表
-	)
+	// 执行 SQLite 迁移，测试数据库与本地开发使用相同的表结构
+	sqlDB, err := db.DB()
 	if err != nil {
+		log.Fatalf("获取 SQL DB 失败: %v", err)
+	}
+	migrator, err := migrations.NewMigrator(sqlDB, "sqlite")
+	if err != nil {
+		log.Fatalf("加载数据库迁移失败: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
 		log.Fatalf("迁移数据库失败: %v", err)
 	}
 
@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/service"
+)
+
+// webhookEventRetention Webhook 事件记录的保留时间
+// 需要长于 Clerk（Svix）的最长重试时间，约 3 天
+const webhookEventRetention = 30 * 24 * time.Hour
+
+// DefaultJobs 返回内置的后台任务
+//
+// 参数:
+//   - services: 业务服务集合
+//
+// 返回:
+//   - []Job: 后台任务列表
+func DefaultJobs(services *service.Services) []Job {
+	return []Job{
+		{
+			Name:     "restore-suspensions",
+			Interval: time.Minute,
+			Run: func(ctx context.Context) error {
+				count, err := services.Admin.RestoreExpiredSuspensions(ctx)
+				if count > 0 {
+					log.Printf("已恢复 %d 个暂停到期的用户", count)
+				}
+				return err
+			},
+		},
+		{
+			Name:     "prune-webhook-events",
+			Interval: time.Hour,
+			Run: func(ctx context.Context) error {
+				deleted, err := services.Users.PruneWebhookEvents(ctx, time.Now().Add(-webhookEventRetention))
+				if deleted > 0 {
+					log.Printf("已删除 %d 条过期的 Webhook 事件记录", deleted)
+				}
+				return err
+			},
+		},
+	}
+}
@@ -0,0 +1,135 @@
+// Package worker 提供后台任务的定时执行
+// 后台任务与 HTTP 服务使用相同的配置和依赖容器，由 worker 子命令单独运行，
+// 可以作为常驻进程运行，也可以每个任务只执行一次后退出，交给外部调度器触发
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job 后台任务
+type Job struct {
+	Name     string                          // 任务名称，用于日志和选择要运行的任务
+	Interval time.Duration                   // 执行间隔
+	Run      func(ctx context.Context) error // 执行一次任务
+}
+
+// Runner 后台任务执行器
+type Runner struct {
+	jobs []Job
+}
+
+// NewRunner 创建后台任务执行器
+//
+// 参数:
+//   - jobs: 要执行的任务
+//
+// 返回:
+//   - *Runner: 任务执行器
+func NewRunner(jobs ...Job) *Runner {
+	return &Runner{jobs: jobs}
+}
+
+// Select 只保留指定名称的任务
+//
+// 参数:
+//   - names: 任务名称，为空时保留全部任务
+//
+// 返回:
+//   - error: 存在未知的任务名称时返回错误
+func (r *Runner) Select(names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	byName := make(map[string]Job, len(r.jobs))
+	for _, job := range r.jobs {
+		byName[job.Name] = job
+	}
+	selected := make([]Job, 0, len(names))
+	for _, name := range names {
+		job, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("未知的后台任务 %q", name)
+		}
+		selected = append(selected, job)
+	}
+	r.jobs = selected
+	return nil
+}
+
+// Jobs 返回要执行的任务
+func (r *Runner) Jobs() []Job {
+	return r.jobs
+}
+
+// RunOnce 依次执行每个任务一次
+//
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - error: 失败任务的错误，多个错误会被合并
+func (r *Runner) RunOnce(ctx context.Context) error {
+	var errs []error
+	for _, job := range r.jobs {
+		if err := r.execute(ctx, job); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", job.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run 按间隔重复执行全部任务，直到 ctx 取消
+//
+// 参数:
+//   - ctx: 取消后不再开始新的执行
+//
+// 说明:
+//
+//	每个任务启动后立即执行一次，之后按各自的间隔执行，同一任务不会并发执行。
+//	ctx 取消时正在执行的任务不会被中断，Run 在它们完成后返回。
+//	任务失败只记录日志，下一个间隔会再次执行。
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range r.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			r.loop(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+}
+
+// loop 按间隔执行单个任务
+func (r *Runner) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		// 已开始的执行不受停止信号影响，保证任务完整结束
+		if err := r.execute(context.WithoutCancel(ctx), job); err != nil {
+			log.Printf("后台任务 %s 执行失败: %v", job.Name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// execute 执行一次任务并记录耗时
+func (r *Runner) execute(ctx context.Context, job Job) error {
+	start := time.Now()
+	if err := job.Run(ctx); err != nil {
+		return err
+	}
+	log.Printf("后台任务 %s 完成，耗时 %s", job.Name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS impersonation_sessions;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS webhook_events;
+DROP TABLE IF EXISTS user_preferences;
+DROP TABLE IF EXISTS social_accounts;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,161 @@
+-- 初始表结构，与之前 GORM AutoMigrate 创建的结构一致
+-- 已有数据库中的 users、social_accounts、user_preferences 由 AutoMigrate 按当时的模型创建，
+-- CREATE TABLE IF NOT EXISTS 会跳过这些表，之后加入的列需要用 ADD COLUMN IF NOT EXISTS 补齐；
+-- 之后加入的表在已有数据库中不存在，会直接创建
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    clerk_id varchar(100),
+    email varchar(100),
+    username varchar(50),
+    first_name varchar(50),
+    last_name varchar(50),
+    image_url varchar(255),
+    email_verified boolean DEFAULT false,
+    phone_number varchar(20),
+    phone_verified boolean DEFAULT false,
+    last_sign_in_at timestamptz,
+    language varchar(10) DEFAULT 'zh',
+    theme varchar(10) DEFAULT 'light',
+    time_zone varchar(50) DEFAULT 'Asia/Shanghai',
+    date_format varchar(20) DEFAULT 'YYYY-MM-DD',
+    time_format varchar(20) DEFAULT 'HH:mm',
+    notification_email boolean DEFAULT true,
+    notification_mobile boolean DEFAULT true,
+    notification_web boolean DEFAULT true,
+    role varchar(20) DEFAULT 'user',
+    status varchar(20) DEFAULT 'active',
+    status_reason varchar(255),
+    suspended_until timestamptz,
+    subscription_id varchar(100),
+    subscription_plan varchar(20),
+    subscription_status varchar(20),
+    subscription_start timestamptz,
+    subscription_end timestamptz,
+    trial_end timestamptz,
+    usage_limit bigint DEFAULT 5,
+    usage_count bigint DEFAULT 0,
+    monthly_limit bigint DEFAULT 3,
+    monthly_count bigint DEFAULT 0,
+    last_reset_time timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_clerk_id ON users (clerk_id);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+-- AutoMigrate 创建的 users 表没有账号状态相关的列
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_reason varchar(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_until timestamptz;
+
+CREATE TABLE IF NOT EXISTS social_accounts (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint CONSTRAINT fk_users_social_accounts REFERENCES users (id),
+    provider varchar(20),
+    account_id varchar(100),
+    email varchar(100),
+    username varchar(50),
+    avatar_url varchar(255),
+    is_active boolean DEFAULT true,
+    last_used timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_social_accounts_user_id ON social_accounts (user_id);
+CREATE INDEX IF NOT EXISTS idx_social_accounts_deleted_at ON social_accounts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_preferences (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint CONSTRAINT fk_users_preferences REFERENCES users (id),
+    key varchar(50),
+    value varchar(255)
+);
+CREATE INDEX IF NOT EXISTS idx_user_preferences_user_id ON user_preferences (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_preferences_deleted_at ON user_preferences (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhook_events (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    event_id varchar(100),
+    source varchar(20),
+    type varchar(50)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_events_event_id ON webhook_events (event_id);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint,
+    name varchar(100),
+    prefix varchar(20),
+    secret_hash varchar(64),
+    scopes varchar(255),
+    expires_at timestamptz,
+    last_used_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_deleted_at ON api_keys (deleted_at);
+
+CREATE TABLE IF NOT EXISTS roles (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    name varchar(50),
+    description varchar(255),
+    built_in boolean DEFAULT false
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_name ON roles (name);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    name varchar(50),
+    description varchar(255)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_permissions_name ON permissions (name);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id bigint CONSTRAINT fk_role_permissions_role REFERENCES roles (id),
+    permission_id bigint CONSTRAINT fk_role_permissions_permission REFERENCES permissions (id),
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    actor_id bigint,
+    subject_id bigint,
+    action varchar(50),
+    reason varchar(255),
+    detail text,
+    ip varchar(64)
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_subject_id ON audit_logs (subject_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+
+CREATE TABLE IF NOT EXISTS impersonation_sessions (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    actor_id bigint,
+    subject_id bigint,
+    token_hash varchar(64),
+    scopes varchar(255),
+    reason varchar(255),
+    expires_at timestamptz,
+    revoked_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_impersonation_sessions_token_hash ON impersonation_sessions (token_hash);
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_actor_id ON impersonation_sessions (actor_id);
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_subject_id ON impersonation_sessions (subject_id);
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_expires_at ON impersonation_sessions (expires_at);
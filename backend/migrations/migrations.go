@@ -0,0 +1,65 @@
+// Package migrations 包含数据库迁移文件，文件在编译时嵌入二进制
+// 使用 migrate create <名称> 创建新的迁移，已发布的迁移文件不能再修改
+//
+// 根目录下是 PostgreSQL 迁移，sqlite 目录下是本地开发使用的 SQLite 迁移，
+// 修改表结构时两边需要使用相同的版本号各添加一个迁移
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/yszaryszar/NicheFlow/backend/pkg/migrate"
+)
+
+// FS 嵌入的 PostgreSQL 迁移文件
+//
+//go:embed *.sql
+var FS embed.FS
+
+// sqliteFiles 嵌入的 SQLite 迁移文件
+//
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+// For 返回数据库驱动对应的迁移文件
+//
+// 参数:
+//   - driver: 数据库驱动名称，为空时视为 postgres
+//
+// 返回:
+//   - fs.FS: 迁移文件所在的文件系统
+//   - error: 不支持的驱动返回错误
+func For(driver string) (fs.FS, error) {
+	switch driver {
+	case "", "postgres":
+		return FS, nil
+	case "sqlite":
+		return fs.Sub(sqliteFiles, "sqlite")
+	default:
+		return nil, fmt.Errorf("没有数据库驱动 %s 的迁移文件", driver)
+	}
+}
+
+// NewMigrator 创建数据库驱动对应的迁移执行器
+//
+// 参数:
+//   - db: 数据库连接
+//   - driver: 数据库驱动名称，为空时视为 postgres
+//
+// 返回:
+//   - *migrate.Migrator: 使用对应方言和迁移文件的迁移执行器
+//   - error: 不支持的驱动或加载迁移文件失败时返回错误
+func NewMigrator(db *sql.DB, driver string) (*migrate.Migrator, error) {
+	dialect, err := migrate.DialectFor(driver)
+	if err != nil {
+		return nil, err
+	}
+	fsys, err := For(driver)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewWithDialect(db, fsys, dialect)
+}
@@ -0,0 +1,156 @@
+-- 初始表结构的 SQLite 版本，与 PostgreSQL 的 000001_baseline 保持一致
+-- 只用于本地开发和测试，SQLite 数据库总是由迁移创建，不需要补齐 AutoMigrate 缺少的列
+
+CREATE TABLE IF NOT EXISTS users (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    clerk_id varchar(100),
+    email varchar(100),
+    username varchar(50),
+    first_name varchar(50),
+    last_name varchar(50),
+    image_url varchar(255),
+    email_verified boolean DEFAULT false,
+    phone_number varchar(20),
+    phone_verified boolean DEFAULT false,
+    last_sign_in_at datetime,
+    language varchar(10) DEFAULT 'zh',
+    theme varchar(10) DEFAULT 'light',
+    time_zone varchar(50) DEFAULT 'Asia/Shanghai',
+    date_format varchar(20) DEFAULT 'YYYY-MM-DD',
+    time_format varchar(20) DEFAULT 'HH:mm',
+    notification_email boolean DEFAULT true,
+    notification_mobile boolean DEFAULT true,
+    notification_web boolean DEFAULT true,
+    role varchar(20) DEFAULT 'user',
+    status varchar(20) DEFAULT 'active',
+    status_reason varchar(255),
+    suspended_until datetime,
+    subscription_id varchar(100),
+    subscription_plan varchar(20),
+    subscription_status varchar(20),
+    subscription_start datetime,
+    subscription_end datetime,
+    trial_end datetime,
+    usage_limit integer DEFAULT 5,
+    usage_count integer DEFAULT 0,
+    monthly_limit integer DEFAULT 3,
+    monthly_count integer DEFAULT 0,
+    last_reset_time datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_clerk_id ON users (clerk_id);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS social_accounts (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id integer REFERENCES users (id),
+    provider varchar(20),
+    account_id varchar(100),
+    email varchar(100),
+    username varchar(50),
+    avatar_url varchar(255),
+    is_active boolean DEFAULT true,
+    last_used datetime
+);
+CREATE INDEX IF NOT EXISTS idx_social_accounts_user_id ON social_accounts (user_id);
+CREATE INDEX IF NOT EXISTS idx_social_accounts_deleted_at ON social_accounts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_preferences (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id integer REFERENCES users (id),
+    key varchar(50),
+    value varchar(255)
+);
+CREATE INDEX IF NOT EXISTS idx_user_preferences_user_id ON user_preferences (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_preferences_deleted_at ON user_preferences (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhook_events (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    event_id varchar(100),
+    source varchar(20),
+    type varchar(50)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_events_event_id ON webhook_events (event_id);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id integer,
+    name varchar(100),
+    prefix varchar(20),
+    secret_hash varchar(64),
+    scopes varchar(255),
+    expires_at datetime,
+    last_used_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_deleted_at ON api_keys (deleted_at);
+
+CREATE TABLE IF NOT EXISTS roles (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    name varchar(50),
+    description varchar(255),
+    built_in boolean DEFAULT false
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_name ON roles (name);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    name varchar(50),
+    description varchar(255)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_permissions_name ON permissions (name);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id integer REFERENCES roles (id),
+    permission_id integer REFERENCES permissions (id),
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    actor_id integer,
+    subject_id integer,
+    action varchar(50),
+    reason varchar(255),
+    detail text,
+    ip varchar(64)
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_subject_id ON audit_logs (subject_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+
+CREATE TABLE IF NOT EXISTS impersonation_sessions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    actor_id integer,
+    subject_id integer,
+    token_hash varchar(64),
+    scopes varchar(255),
+    reason varchar(255),
+    expires_at datetime,
+    revoked_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_impersonation_sessions_token_hash ON impersonation_sessions (token_hash);
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_actor_id ON impersonation_sessions (actor_id);
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_subject_id ON impersonation_sessions (subject_id);
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_expires_at ON impersonation_sessions (expires_at);
@@ -0,0 +1,3 @@
+-- 恢复为全表唯一索引，存在多个空邮箱或与已删除用户重复的邮箱时会失败，需要先手动处理
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX idx_users_email ON users (email);
@@ -0,0 +1,5 @@
+-- 邮箱唯一索引只约束非空邮箱和未删除的用户
+-- 手机号、用户名注册或令牌中没有邮箱的用户邮箱为空，之前的索引只允许一个这样的用户；
+-- 已删除用户的邮箱也不再阻止使用同一邮箱重新注册
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX idx_users_email ON users (email) WHERE email <> '' AND deleted_at IS NULL;
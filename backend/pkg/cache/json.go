@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 缓存操作错误
+var (
+	ErrCacheMiss      = errors.New("缓存未命中")
+	ErrNotInitialized = errors.New("Redis 客户端未初始化")
+)
+
+// Cache 基于 Redis 的 JSON 缓存
+// 零值和 nil 都可以使用，此时所有操作返回 ErrNotInitialized，调用方可据此回退到数据源
+type Cache struct {
+	rdb *redis.Client
+}
+
+// New 创建 JSON 缓存
+//
+// 参数:
+//   - rdb: Redis 客户端，为 nil 时所有操作返回 ErrNotInitialized
+//
+// 返回:
+//   - *Cache: 缓存实例，不负责关闭 rdb
+func New(rdb *redis.Client) *Cache {
+	return &Cache{rdb: rdb}
+}
+
+// client 返回可用的 Redis 客户端，未初始化时返回 nil
+func (c *Cache) client() *redis.Client {
+	if c == nil {
+		return nil
+	}
+	return c.rdb
+}
+
+// GetJSON 读取缓存并反序列化到 dest
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - key: 缓存键
+//   - dest: 反序列化目标，必须为指针
+//
+// 返回:
+//   - error: 未命中时返回 ErrCacheMiss，Redis 未初始化时返回 ErrNotInitialized
+func (c *Cache) GetJSON(ctx context.Context, key string, dest interface{}) error {
+	rdb := c.client()
+	if rdb == nil {
+		return ErrNotInitialized
+	}
+
+	data, err := rdb.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ErrCacheMiss
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("反序列化缓存失败: %w", err)
+	}
+	return nil
+}
+
+// SetJSON 序列化 value 并写入缓存
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - key: 缓存键
+//   - value: 要缓存的值
+//   - ttl: 过期时间
+//
+// 返回:
+//   - error: Redis 未初始化时返回 ErrNotInitialized
+func (c *Cache) SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	rdb := c.client()
+	if rdb == nil {
+		return ErrNotInitialized
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("序列化缓存失败: %w", err)
+	}
+	return rdb.Set(ctx, key, data, ttl).Err()
+}
+
+// Delete 删除缓存键
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - keys: 要删除的缓存键
+//
+// 返回:
+//   - error: Redis 未初始化时返回 ErrNotInitialized
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	rdb := c.client()
+	if rdb == nil {
+		return ErrNotInitialized
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return rdb.Del(ctx, keys...).Err()
+}
@@ -5,7 +5,6 @@ package cache
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"log"
 
@@ -13,9 +12,6 @@ import (
 	"github.com/yszaryszar/NicheFlow/backend/internal/config"
 )
 
-// 全局 Redis 客户端实例
-var rdb *redis.Client
-
 // NewRedisClient 创建 Redis 客户端连接
 //
 // 参数:
@@ -29,14 +25,12 @@ var rdb *redis.Client
 //
 //	该函数完成以下配置：
 //	1. 设置基本连接参数（地址、密码、数据库）
-//	2. 配置 TLS 连接（如果启用）
+//	2. 配置 TLS 连接（如果启用），校验服务器证书并加载客户端证书
 //	3. 测试连接可用性
 //	4. 返回可用的客户端实例
+//
+//	每次调用都会创建新的客户端，由调用方负责在不再使用时关闭
 func NewRedisClient(cfg *config.RedisConfig) (*redis.Client, error) {
-	if rdb != nil {
-		return rdb, nil
-	}
-
 	// 基本连接配置
 	options := &redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port), // Redis 服务器地址
@@ -44,52 +38,23 @@ func NewRedisClient(cfg *config.RedisConfig) (*redis.Client, error) {
 		DB:       cfg.DB,                                   // 数据库编号
 	}
 
-	// TLS 配置 (AWS ElastiCache)
-	if cfg.TLSEnable {
-		options.TLSConfig = &tls.Config{
-			MinVersion:         tls.VersionTLS12,
-			InsecureSkipVerify: true, // AWS ElastiCache 使用自签名证书
-		}
+	// TLS 配置，校验服务器证书，可选双向 TLS
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
 	}
+	options.TLSConfig = tlsConfig
 
 	// 创建客户端实例
-	rdb = redis.NewClient(options)
+	rdb := redis.NewClient(options)
 
 	// 测试连接可用性
 	ctx := context.Background()
 	if err := rdb.Ping(ctx).Err(); err != nil {
+		rdb.Close()
 		return nil, fmt.Errorf("连接 Redis 失败: %w", err)
 	}
 
 	log.Println("Redis 连接成功")
 	return rdb, nil
 }
-
-// GetRedis 获取 Redis 客户端实例
-//
-// 返回:
-//   - *redis.Client: 全局 Redis 客户端实例
-//
-// 说明:
-//
-//	返回已初始化的 Redis 客户端实例
-//	如果客户端未初始化，返回 nil
-func GetRedis() *redis.Client {
-	return rdb
-}
-
-// Close 关闭 Redis 连接
-//
-// 返回:
-//   - error: 关闭过程中的错误，如果成功则为 nil
-//
-// 说明:
-//
-//	安全地关闭 Redis 连接
-//	在应用程序退出时调用
-func Close() error {
-	if rdb != nil {
-		return rdb.Close()
-	}
-	return nil
-}
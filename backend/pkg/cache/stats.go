@@ -0,0 +1,35 @@
+package cache
+
+import "github.com/go-redis/redis/v8"
+
+// PoolStats Redis 连接池统计，字段与 redis.PoolStats 对应
+type PoolStats struct {
+	Hits       uint32 `json:"hits"`        // 从连接池取到空闲连接的次数
+	Misses     uint32 `json:"misses"`      // 连接池没有空闲连接、需要新建连接的次数
+	Timeouts   uint32 `json:"timeouts"`    // 等待连接超时的次数
+	TotalConns uint32 `json:"total_conns"` // 连接池中的连接总数
+	IdleConns  uint32 `json:"idle_conns"`  // 空闲连接数
+	StaleConns uint32 `json:"stale_conns"` // 因过期被移除的连接数
+}
+
+// StatsOf 返回 Redis 客户端的连接池统计
+//
+// 参数:
+//   - rdb: Redis 客户端，为 nil 时返回 nil
+//
+// 返回:
+//   - *PoolStats: 连接池统计
+func StatsOf(rdb *redis.Client) *PoolStats {
+	if rdb == nil {
+		return nil
+	}
+	stats := rdb.PoolStats()
+	return &PoolStats{
+		Hits:       stats.Hits,
+		Misses:     stats.Misses,
+		Timeouts:   stats.Timeouts,
+		TotalConns: stats.TotalConns,
+		IdleConns:  stats.IdleConns,
+		StaleConns: stats.StaleConns,
+	}
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+)
+
+// newTLSConfig 根据 Redis 配置创建 TLS 配置
+//
+// 参数:
+//   - cfg: Redis 配置对象
+//
+// 返回:
+//   - *tls.Config: TLS 配置，未启用 TLS 时返回 nil
+//   - error: 证书文件读取或解析失败时返回错误
+//
+// 说明:
+//
+//	服务器证书默认使用系统根证书校验，设置 tls_ca_file 时只信任该文件中的 CA。
+//	证书中的名称需要与 tls_server_name 匹配，未设置时与 host 匹配，
+//	通过 SSL 隧道连接 127.0.0.1 时需要将 tls_server_name 设置为 Redis 的实际域名。
+//	同时设置 tls_cert_file 和 tls_key_file 时向服务器出示客户端证书（双向 TLS）。
+//	tls_insecure_skip_verify 跳过服务器证书校验，连接可能被中间人劫持，只能用于本地开发。
+func newTLSConfig(cfg *config.RedisConfig) (*tls.Config, error) {
+	if !cfg.TLSEnable {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.TLSServerName,
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = cfg.Host
+	}
+
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 Redis CA 证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("Redis CA 证书 %s 中没有有效的 PEM 证书", cfg.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载 Redis 客户端证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.TLSInsecureSkipVerify {
+		log.Printf("警告: 已跳过 Redis 服务器 %s 的 TLS 证书校验，连接可能被中间人劫持，不要在本地开发以外的环境使用 redis.tls_insecure_skip_verify", cfg.Host)
+		tlsConfig.InsecureSkipVerify = true
+	}
+	return tlsConfig, nil
+}
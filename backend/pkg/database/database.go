@@ -0,0 +1,80 @@
+// Package database 提供数据库连接和管理功能
+// 包含 PostgreSQL 和 SQLite 数据库的连接池管理、配置和操作接口，
+// 以及 PostgreSQL 只读副本的读写分离
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Open 按配置的驱动创建数据库连接
+//
+// 参数:
+//   - cfg: 数据库配置对象，Driver 为空时使用 postgres
+//
+// 返回:
+//   - *gorm.DB: GORM 数据库连接实例
+//   - error: 驱动不支持或连接失败时返回错误
+func Open(cfg *config.DatabaseConfig) (*gorm.DB, error) {
+	switch cfg.Driver {
+	case "", "postgres":
+		return NewPostgresDB(cfg)
+	case "sqlite":
+		if len(cfg.Replicas) > 0 {
+			return nil, fmt.Errorf("sqlite 驱动不支持只读副本")
+		}
+		return NewSQLiteDB(cfg)
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", cfg.Driver)
+	}
+}
+
+// newGormConfig 创建 GORM 配置，设置日志和性能选项
+func newGormConfig() *gorm.Config {
+	return &gorm.Config{
+		Logger: logger.New(
+			log.New(os.Stdout, "\r\n", log.LstdFlags),
+			logger.Config{
+				SlowThreshold:             time.Second, // 慢查询阈值
+				LogLevel:                  logger.Info, // 日志级别改为 Info
+				IgnoreRecordNotFoundError: true,        // 忽略记录未找到错误
+				Colorful:                  true,        // 启用彩色输出
+			},
+		),
+	}
+}
+
+// Close 关闭数据库连接
+//
+// 参数:
+//   - db: 要关闭的数据库连接，为 nil 时忽略
+//
+// 返回:
+//   - error: 关闭过程中的错误，如果成功则为 nil
+//
+// 说明:
+//
+//	安全地关闭数据库连接，同时关闭注册在连接上的只读副本
+//	在应用程序退出时调用
+func Close(db *gorm.DB) error {
+	if db != nil {
+		var errs []error
+		if replicas := ReplicasOf(db); replicas != nil {
+			errs = append(errs, replicas.Close())
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return errors.Join(append(errs, fmt.Errorf("获取 SQL DB 失败: %w", err))...)
+		}
+		return errors.Join(append(errs, sqlDB.Close())...)
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// replicasPluginName 只读副本插件在 GORM 中的注册名称
+const replicasPluginName = "nicheflow:replicas"
+
+// replicaCheckTimeout 单次副本健康检查的超时时间
+const replicaCheckTimeout = 3 * time.Second
+
+// primaryPoolKey 路由到副本前保存主库连接池的 Statement 设置键
+const primaryPoolKey = "nicheflow:primary_pool"
+
+// sessionKey 上下文中保存会话标识的键
+type sessionKey struct{}
+
+// WithSession 将发起请求的用户标识写入上下文
+//
+// 参数:
+//   - ctx: 上下文对象
+//   - key: 用户标识，通常为 Clerk ID，为空时不做任何修改
+//
+// 返回:
+//   - context.Context: 携带会话标识的上下文
+//
+// 说明:
+//
+//	使用该上下文写入数据库后，同一用户在 ReplicaStickiness 时间内的查询都会读取主库，
+//	保证用户能读到自己刚写入的数据。没有会话标识的查询总是可以使用副本。
+//	写入记录只保存在当前实例中，跨实例的一致性需要配合 WithPrimary 使用。
+func WithSession(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, key)
+}
+
+// primaryKey 上下文中要求读取主库的标记
+type primaryKey struct{}
+
+// WithPrimary 要求使用该上下文的查询全部读取主库
+//
+// 参数:
+//   - ctx: 上下文对象
+//
+// 返回:
+//   - context.Context: 携带主库标记的上下文
+//
+// 说明:
+//
+//	WithSession 的写入记录只保存在当前实例的内存中，其他实例仍可能从延迟的副本读到旧数据。
+//	查询结果会写入多个实例共享的缓存时，需要使用该上下文读取主库，避免旧数据在缓存中长期存在。
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// primaryFrom 判断上下文是否要求读取主库
+func primaryFrom(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// sessionFrom 读取上下文中的会话标识
+func sessionFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	key, _ := ctx.Value(sessionKey{}).(string)
+	return key
+}
+
+// ReplicaStatus 只读副本的健康状态
+type ReplicaStatus struct {
+	Name      string    `json:"name"`            // 副本名称，按配置顺序编号
+	Healthy   bool      `json:"healthy"`         // 是否参与查询路由
+	Error     string    `json:"error,omitempty"` // 最近一次检查失败的原因
+	CheckedAt time.Time `json:"checked_at"`      // 最近一次检查的时间
+}
+
+// replica 单个只读副本
+type replica struct {
+	name    string
+	pool    *sql.DB
+	healthy atomic.Bool
+
+	mu        sync.Mutex
+	lastErr   error
+	checkedAt time.Time
+}
+
+// Replicas 只读副本集合
+// 作为 GORM 插件注册到主库连接上，将事务外的查询轮流分发到健康的副本，
+// 写入、事务内的查询、加锁查询和 WithPrimary 上下文中的查询始终使用主库
+type Replicas struct {
+	nodes      []*replica
+	next       atomic.Uint64
+	stickiness time.Duration
+	interval   time.Duration
+
+	mu     sync.Mutex
+	writes map[string]time.Time // 会话标识最近一次写入的时间
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newReplicas 按配置建立副本连接池并执行首次健康检查
+//
+// 参数:
+//   - cfg: 数据库配置，副本的连接池参数与主库相同
+//   - connMaxLifetime: 连接最大生命周期
+//
+// 返回:
+//   - *Replicas: 副本集合，后台健康检查已启动
+//   - error: 连接字符串无效时返回错误
+//
+// 说明:
+//
+//	启动时不可达的副本不会导致失败，只是暂不参与路由，恢复后由健康检查重新加入
+func newReplicas(cfg *config.DatabaseConfig, connMaxLifetime time.Duration) (*Replicas, error) {
+	r := &Replicas{
+		stickiness: cfg.ReplicaStickiness,
+		interval:   cfg.ReplicaHealthInterval,
+		writes:     make(map[string]time.Time),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	for i, dsn := range cfg.Replicas {
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{DisableAutomaticPing: true})
+		if err == nil {
+			var pool *sql.DB
+			if pool, err = db.DB(); err == nil {
+				pool.SetMaxIdleConns(cfg.MaxIdleConns)
+				pool.SetMaxOpenConns(cfg.MaxOpenConns)
+				pool.SetConnMaxLifetime(connMaxLifetime)
+				r.nodes = append(r.nodes, &replica{name: fmt.Sprintf("replica-%d", i), pool: pool})
+			}
+		}
+		if err != nil {
+			r.closePools()
+			return nil, fmt.Errorf("创建只读副本 replica-%d 失败: %w", i, err)
+		}
+	}
+
+	r.check()
+	if r.interval > 0 {
+		go r.run()
+	} else {
+		close(r.done)
+	}
+	return r, nil
+}
+
+// Name 返回插件名称
+func (r *Replicas) Name() string {
+	return replicasPluginName
+}
+
+// Initialize 注册查询路由和写入标记回调
+func (r *Replicas) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("nicheflow:route_read", r.routeRead); err != nil {
+		return err
+	}
+	if err := db.Callback().Query().After("gorm:after_query").Register("nicheflow:restore_primary", restorePrimary); err != nil {
+		return err
+	}
+	if err := db.Callback().Create().After("gorm:create").Register("nicheflow:mark_write", r.markWrite); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("nicheflow:mark_write", r.markWrite); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().After("gorm:delete").Register("nicheflow:mark_write", r.markWrite); err != nil {
+		return err
+	}
+	return db.Callback().Raw().After("gorm:raw").Register("nicheflow:mark_write", r.markWrite)
+}
+
+// routeRead 为查询选择副本
+func (r *Replicas) routeRead(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	// 事务中的查询必须与写入使用同一个连接
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+	// SELECT ... FOR UPDATE 等加锁查询只能在主库执行
+	if _, ok := db.Statement.Clauses["FOR"]; ok {
+		return
+	}
+	if primaryFrom(db.Statement.Context) || r.recentlyWrote(sessionFrom(db.Statement.Context)) {
+		return
+	}
+
+	node := r.pick()
+	if node == nil {
+		return
+	}
+	db.Statement.Settings.Store(primaryPoolKey, db.Statement.ConnPool)
+	db.Statement.ConnPool = node.pool
+}
+
+// restorePrimary 查询完成后恢复主库连接池
+// 链式调用会复用同一个 Statement，避免后续的写入落到副本上
+func restorePrimary(db *gorm.DB) {
+	if pool, ok := db.Statement.Settings.LoadAndDelete(primaryPoolKey); ok {
+		db.Statement.ConnPool = pool.(gorm.ConnPool)
+	}
+}
+
+// markWrite 记录会话的写入时间
+func (r *Replicas) markWrite(db *gorm.DB) {
+	if db.Error == nil {
+		r.Touch(sessionFrom(db.Statement.Context))
+	}
+}
+
+// Touch 记录用户刚刚写入了数据
+//
+// 参数:
+//   - key: 用户标识，与 WithSession 使用的标识相同，为空时忽略
+//
+// 说明:
+//
+//	通过带会话标识的上下文写入时会自动记录。修改其他用户的数据时（例如管理员操作、Webhook 同步）
+//	需要以被修改的用户调用该方法，该用户随后的查询才会读取主库。r 为 nil 时不做任何操作。
+func (r *Replicas) Touch(key string) {
+	if r == nil || key == "" || r.stickiness <= 0 {
+		return
+	}
+	r.mu.Lock()
+	r.writes[key] = time.Now()
+	r.mu.Unlock()
+}
+
+// recentlyWrote 判断会话是否在粘滞时间内写入过数据
+func (r *Replicas) recentlyWrote(key string) bool {
+	if key == "" || r.stickiness <= 0 {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.writes[key]
+	return ok && time.Since(at) < r.stickiness
+}
+
+// pick 轮流选择一个健康的副本，全部不可用时返回 nil
+func (r *Replicas) pick() *replica {
+	n := uint64(len(r.nodes))
+	if n == 0 {
+		return nil
+	}
+	start := r.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if node := r.nodes[(start+i)%n]; node.healthy.Load() {
+			return node
+		}
+	}
+	return nil
+}
+
+// run 定期检查副本健康状态并清理过期的写入记录
+func (r *Replicas) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.check()
+			r.prune()
+		}
+	}
+}
+
+// check 检查全部副本，状态变化时输出日志
+func (r *Replicas) check() {
+	for _, node := range r.nodes {
+		ctx, cancel := context.WithTimeout(context.Background(), replicaCheckTimeout)
+		err := node.pool.PingContext(ctx)
+		cancel()
+
+		node.mu.Lock()
+		first := node.checkedAt.IsZero()
+		node.lastErr = err
+		node.checkedAt = time.Now()
+		node.mu.Unlock()
+
+		// 副本初始状态为不健康，首次检查失败也需要输出日志
+		healthy := err == nil
+		if node.healthy.Swap(healthy) != healthy || (first && !healthy) {
+			switch {
+			case healthy && first:
+				log.Printf("只读副本 %s 已加入查询路由", node.name)
+			case healthy:
+				log.Printf("只读副本 %s 恢复，重新加入查询路由", node.name)
+			default:
+				log.Printf("只读副本 %s 健康检查失败，暂停使用: %v", node.name, err)
+			}
+		}
+	}
+}
+
+// prune 清理超过粘滞时间的写入记录
+func (r *Replicas) prune() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, at := range r.writes {
+		if time.Since(at) >= r.stickiness {
+			delete(r.writes, key)
+		}
+	}
+}
+
+// Status 返回全部副本的健康状态
+//
+// 返回:
+//   - []ReplicaStatus: 按配置顺序排列的副本状态，r 为 nil 时返回 nil
+func (r *Replicas) Status() []ReplicaStatus {
+	if r == nil {
+		return nil
+	}
+	statuses := make([]ReplicaStatus, 0, len(r.nodes))
+	for _, node := range r.nodes {
+		node.mu.Lock()
+		status := ReplicaStatus{
+			Name:      node.name,
+			Healthy:   node.healthy.Load(),
+			CheckedAt: node.checkedAt,
+		}
+		if node.lastErr != nil {
+			status.Error = node.lastErr.Error()
+		}
+		node.mu.Unlock()
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Close 停止健康检查并关闭全部副本连接池
+func (r *Replicas) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.stop)
+	})
+	<-r.done
+	return r.closePools()
+}
+
+// closePools 关闭全部副本连接池
+func (r *Replicas) closePools() error {
+	var errs []error
+	for _, node := range r.nodes {
+		if err := node.pool.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("关闭只读副本 %s 失败: %w", node.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReplicasOf 返回注册在数据库连接上的只读副本集合
+//
+// 参数:
+//   - db: 主库连接
+//
+// 返回:
+//   - *Replicas: 副本集合，未配置副本时返回 nil
+func ReplicasOf(db *gorm.DB) *Replicas {
+	if db == nil {
+		return nil
+	}
+	plugin, ok := db.Config.Plugins[replicasPluginName]
+	if !ok {
+		return nil
+	}
+	return plugin.(*Replicas)
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/yszaryszar/NicheFlow/backend/internal/config"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// NewSQLiteDB 创建 SQLite 数据库连接
+//
+// 参数:
+//   - cfg: 数据库配置对象，Name 为数据库文件路径，也可以是 :memory:
+//
+// 返回:
+//   - *gorm.DB: GORM 数据库连接实例
+//   - error: 创建过程中的错误，如果成功则为 nil
+//
+// 说明:
+//
+//	SQLite 只用于本地开发，不需要启动 PostgreSQL 即可运行服务。
+//	SQLite 同一时间只允许一个写入者，因此连接池限制为一个连接，
+//	这也保证了 :memory: 数据库在整个进程中是同一个库。
+//	每次调用都会创建新的连接，由调用方负责通过 Close 关闭
+func NewSQLiteDB(cfg *config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(cfg.GetDSN()), newGormConfig())
+	if err != nil {
+		return nil, fmt.Errorf("打开 SQLite 数据库失败 (%s): %w", cfg.GetDSN(), err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取 SQL DB 失败: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	return db, nil
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// PoolStats 连接池统计，字段与 sql.DBStats 对应
+type PoolStats struct {
+	MaxOpenConnections int    `json:"max_open_connections"` // 最大打开连接数，0 表示不限制
+	OpenConnections    int    `json:"open_connections"`     // 当前打开的连接数，包括使用中和空闲的连接
+	InUse              int    `json:"in_use"`               // 使用中的连接数
+	Idle               int    `json:"idle"`                 // 空闲连接数
+	WaitCount          int64  `json:"wait_count"`           // 累计等待连接的次数
+	WaitDuration       string `json:"wait_duration"`        // 累计等待连接的时长
+	MaxIdleClosed      int64  `json:"max_idle_closed"`      // 因超过最大空闲连接数而关闭的连接数
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"` // 因超过最大空闲时间而关闭的连接数
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`  // 因超过最大生命周期而关闭的连接数
+}
+
+// newPoolStats 转换 sql.DBStats
+func newPoolStats(stats sql.DBStats) PoolStats {
+	return PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration.String(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+}
+
+// ReplicaStats 只读副本的健康状态和连接池统计
+type ReplicaStats struct {
+	ReplicaStatus
+	Pool PoolStats `json:"pool"` // 副本连接池统计
+}
+
+// Stats 数据库连接池统计
+type Stats struct {
+	Driver   string         `json:"driver"`             // 数据库驱动
+	Primary  PoolStats      `json:"primary"`            // 主库连接池统计
+	Replicas []ReplicaStats `json:"replicas,omitempty"` // 只读副本统计，未配置副本时为空
+}
+
+// StatsOf 返回数据库连接及其只读副本的连接池统计
+//
+// 参数:
+//   - db: 主库连接
+//
+// 返回:
+//   - *Stats: 连接池统计
+//   - error: 获取底层连接池失败时返回错误
+func StatsOf(db *gorm.DB) (*Stats, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取 SQL DB 失败: %w", err)
+	}
+
+	stats := &Stats{
+		Driver:  db.Dialector.Name(),
+		Primary: newPoolStats(sqlDB.Stats()),
+	}
+	if replicas := ReplicasOf(db); replicas != nil {
+		for i, status := range replicas.Status() {
+			stats.Replicas = append(stats.Replicas, ReplicaStats{
+				ReplicaStatus: status,
+				Pool:          newPoolStats(replicas.nodes[i].pool.Stats()),
+			})
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,57 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// invalidNameChars 迁移名称中不允许的字符
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create 在目录中创建新的迁移文件
+//
+// 参数:
+//   - dir: 迁移目录
+//   - name: 迁移名称，会被转换为小写并以下划线分隔
+//
+// 返回:
+//   - string: 创建的 up 文件路径
+//   - string: 创建的 down 文件路径
+//   - error: 名称为空或写入失败时返回错误
+//
+// 说明:
+//
+//	版本号为目录中最大的版本号加一，使用 6 位数字，例如 000002_add_user_bio.up.sql。
+//	生成的 down 文件只有注释，在写入回滚语句之前该迁移不可回滚。
+func Create(dir, name string) (string, string, error) {
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", fmt.Errorf("迁移名称不能为空")
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	var version int64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := fmt.Sprintf("%06d_%s", version, name)
+	up := filepath.Join(dir, base+".up.sql")
+	down := filepath.Join(dir, base+".down.sql")
+	files := map[string]string{
+		up:   fmt.Sprintf("-- %s 升级\n", base),
+		down: fmt.Sprintf("-- %s 回滚，只有注释时视为不可回滚\n", base),
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return "", "", fmt.Errorf("创建迁移文件失败: %w", err)
+		}
+	}
+	return up, down, nil
+}
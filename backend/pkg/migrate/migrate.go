@@ -0,0 +1,529 @@
+// Package migrate 提供版本化的 SQL 数据库迁移
+// 迁移文件命名为 <版本号>_<名称>.up.sql 和 <版本号>_<名称>.down.sql，
+// 已执行的迁移及其校验和记录在 schema_migrations 表中
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// noTransactionDirective 迁移文件第一行为该注释时不在事务中执行，
+// 用于 CREATE INDEX CONCURRENTLY 等不能在事务中执行的语句
+const noTransactionDirective = "-- migrate:no-transaction"
+
+// lockID 迁移使用的 PostgreSQL 会话级咨询锁 ID
+const lockID int64 = 7206418350201
+
+// Dialect 数据库方言，提供迁移记录表和迁移锁使用的 SQL
+type Dialect struct {
+	name        string
+	createTable string // 创建迁移记录表
+	tableExists string // 查询迁移记录表是否存在
+	insert      string // 写入迁移记录，参数为版本号、名称和校验和
+	remove      string // 删除迁移记录，参数为版本号
+	lock        string // 获取迁移锁，为空表示不加锁
+	unlock      string // 释放迁移锁
+}
+
+// 支持的数据库方言
+var (
+	// Postgres PostgreSQL，执行期间持有会话级咨询锁
+	Postgres = Dialect{
+		name: "postgres",
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version bigint PRIMARY KEY,
+	name varchar(255) NOT NULL,
+	checksum varchar(64) NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT now()
+)`,
+		tableExists: "SELECT to_regclass('schema_migrations') IS NOT NULL",
+		insert:      "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+		remove:      "DELETE FROM schema_migrations WHERE version = $1",
+		lock:        fmt.Sprintf("SELECT pg_advisory_lock(%d)", lockID),
+		unlock:      fmt.Sprintf("SELECT pg_advisory_unlock(%d)", lockID),
+	}
+
+	// SQLite SQLite，只用于本地开发和测试，不加迁移锁，不应由多个进程同时执行迁移
+	SQLite = Dialect{
+		name: "sqlite",
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version integer PRIMARY KEY,
+	name varchar(255) NOT NULL,
+	checksum varchar(64) NOT NULL,
+	applied_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`,
+		tableExists: "SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')",
+		insert:      "INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)",
+		remove:      "DELETE FROM schema_migrations WHERE version = ?",
+	}
+)
+
+// Name 返回方言名称，与数据库驱动名称相同
+func (d Dialect) Name() string {
+	return d.name
+}
+
+// DialectFor 根据数据库驱动名称返回方言
+//
+// 参数:
+//   - driver: 数据库驱动名称，为空时视为 postgres
+//
+// 返回:
+//   - Dialect: 数据库方言
+//   - error: 不支持的驱动返回错误
+func DialectFor(driver string) (Dialect, error) {
+	switch driver {
+	case "", Postgres.name:
+		return Postgres, nil
+	case SQLite.name:
+		return SQLite, nil
+	default:
+		return Dialect{}, fmt.Errorf("不支持的数据库驱动: %s", driver)
+	}
+}
+
+var (
+	// ErrChecksumMismatch 已执行的迁移文件被修改
+	ErrChecksumMismatch = errors.New("已执行的迁移文件被修改")
+	// ErrSchemaBehind 数据库结构落后于代码
+	ErrSchemaBehind = errors.New("数据库结构版本落后")
+	// ErrIrreversible 迁移没有对应的 down 文件，或 down 文件中只有注释
+	ErrIrreversible = errors.New("迁移不可回滚")
+)
+
+// fileName 迁移文件名格式
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration 单个迁移
+type Migration struct {
+	Version  int64  // 版本号，按数值升序执行
+	Name     string // 迁移名称
+	Up       string // 升级 SQL
+	Down     string // 回滚 SQL，为空或只包含注释表示不可回滚
+	Checksum string // 升级 SQL 的 SHA-256 校验和
+}
+
+// reversible 判断回滚 SQL 中是否包含语句，只有空行和 -- 注释时视为不可回滚
+func reversible(script string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return true
+		}
+	}
+	return false
+}
+
+// transactional 判断迁移是否在事务中执行
+func transactional(script string) bool {
+	return !strings.HasPrefix(strings.TrimSpace(script), noTransactionDirective)
+}
+
+// Load 从文件系统加载迁移
+//
+// 参数:
+//   - fsys: 包含迁移文件的文件系统，只读取根目录下的 .sql 文件
+//
+// 返回:
+//   - []Migration: 按版本号升序排列的迁移
+//   - error: 文件名不合法、版本号重复或缺少 up 文件时返回错误
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移目录失败: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("迁移文件名 %s 不合法，应为 <版本号>_<名称>.up.sql 或 .down.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("迁移文件 %s 的版本号无效: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件 %s 失败: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("迁移版本 %d 重复: %s 和 %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("迁移 %d_%s 缺少 up 文件", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Record schema_migrations 表中的迁移记录
+type Record struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// 迁移状态
+const (
+	StatePending  = "pending"  // 尚未执行
+	StateApplied  = "applied"  // 已执行
+	StateModified = "modified" // 已执行，但迁移文件在执行后被修改
+	StateUnknown  = "unknown"  // 数据库中有记录，但代码中没有对应的迁移文件
+)
+
+// Status 迁移状态
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Migrator 迁移执行器
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New 创建 PostgreSQL 迁移执行器
+//
+// 参数:
+//   - db: PostgreSQL 数据库连接
+//   - fsys: 包含迁移文件的文件系统
+//
+// 返回:
+//   - *Migrator: 迁移执行器
+//   - error: 加载迁移文件失败时返回错误
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	return NewWithDialect(db, fsys, Postgres)
+}
+
+// NewWithDialect 创建指定数据库方言的迁移执行器
+//
+// 参数:
+//   - db: 数据库连接
+//   - fsys: 包含迁移文件的文件系统，迁移 SQL 需要与方言匹配
+//   - dialect: 数据库方言
+//
+// 返回:
+//   - *Migrator: 迁移执行器
+//   - error: 加载迁移文件失败时返回错误
+func NewWithDialect(db *sql.DB, fsys fs.FS, dialect Dialect) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Migrations 返回代码中的全部迁移
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up 执行全部未执行的迁移
+//
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - []Migration: 本次执行的迁移
+//   - error: 执行失败时返回错误，失败的迁移之前已执行的迁移不会回滚
+//
+// 说明:
+//
+//	PostgreSQL 执行期间持有咨询锁，多个实例同时执行时会依次等待，
+//	后获得锁的实例不会重复执行。已执行的迁移文件被修改时拒绝执行。
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		records, err := m.records(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(records); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := records[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down 回滚最近执行的迁移
+//
+// 参数:
+//   - ctx: 上下文
+//   - steps: 回滚的迁移数量
+//
+// 返回:
+//   - []Migration: 本次回滚的迁移，按回滚顺序排列
+//   - error: 回滚失败或迁移不可回滚时返回错误
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		records, err := m.records(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(records); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := records[migration.Version]; !ok {
+				continue
+			}
+			if !reversible(migration.Down) {
+				return fmt.Errorf("%w: %d_%s", ErrIrreversible, migration.Version, migration.Name)
+			}
+			if err := m.revert(ctx, conn, migration); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status 返回全部迁移的状态
+//
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - []Status: 按版本号升序排列的迁移状态，包含数据库中有记录但代码中不存在的迁移
+//   - error: 查询失败时返回错误
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	records, err := m.records(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name, State: StatePending}
+		if record, ok := records[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+			status.State = StateApplied
+			if record.Checksum != migration.Checksum {
+				status.State = StateModified
+			}
+			delete(records, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range records {
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, Status{Version: record.Version, Name: record.Name, State: StateUnknown, AppliedAt: &appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Check 检查数据库结构是否为最新
+//
+// 参数:
+//   - ctx: 上下文
+//
+// 返回:
+//   - error: 存在未执行的迁移时返回 ErrSchemaBehind，已执行的迁移文件被修改时返回 ErrChecksumMismatch
+//
+// 说明:
+//
+//	数据库中有代码不认识的迁移（例如回滚到旧版本代码）时不视为错误。
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, status := range statuses {
+		switch status.State {
+		case StateModified:
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, status.Version, status.Name)
+		case StatePending:
+			pending = append(pending, fmt.Sprintf("%d_%s", status.Version, status.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w，未执行的迁移: %s", ErrSchemaBehind, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// withLock 在持有迁移锁的连接上执行操作，方言不支持加锁时直接执行
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dialect.lock != "" {
+		if _, err := conn.ExecContext(ctx, m.dialect.lock); err != nil {
+			return fmt.Errorf("获取迁移锁失败: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), m.dialect.unlock)
+	}
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// ensureTable 创建迁移记录表
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, m.dialect.createTable)
+	if err != nil {
+		return fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+	return nil
+}
+
+// records 查询已执行的迁移，迁移记录表不存在时视为没有执行过迁移
+func (m *Migrator) records(ctx context.Context, conn *sql.Conn) (map[int64]Record, error) {
+	var exists bool
+	if err := conn.QueryRowContext(ctx, m.dialect.tableExists).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("查询迁移记录表失败: %w", err)
+	}
+	records := make(map[int64]Record)
+	if !exists {
+		return records, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record Record
+		if err := rows.Scan(&record.Version, &record.Name, &record.Checksum, &record.AppliedAt); err != nil {
+			return nil, fmt.Errorf("读取迁移记录失败: %w", err)
+		}
+		records[record.Version] = record
+	}
+	return records, rows.Err()
+}
+
+// verify 校验已执行迁移的校验和
+func (m *Migrator) verify(records map[int64]Record) error {
+	for _, migration := range m.migrations {
+		if record, ok := records[migration.Version]; ok && record.Checksum != migration.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// apply 执行升级 SQL 并记录
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	return run(ctx, conn, migration.Up, func(exec execer) error {
+		_, err := exec.ExecContext(ctx, m.dialect.insert, migration.Version, migration.Name, migration.Checksum)
+		return err
+	}, fmt.Sprintf("执行迁移 %d_%s 失败", migration.Version, migration.Name))
+}
+
+// revert 执行回滚 SQL 并删除记录
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	return run(ctx, conn, migration.Down, func(exec execer) error {
+		_, err := exec.ExecContext(ctx, m.dialect.remove, migration.Version)
+		return err
+	}, fmt.Sprintf("回滚迁移 %d_%s 失败", migration.Version, migration.Name))
+}
+
+// execer 可以执行 SQL 的连接或事务
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// run 执行迁移脚本并更新记录，脚本和记录在同一个事务中提交
+func run(ctx context.Context, conn *sql.Conn, script string, record func(execer) error, message string) error {
+	if !transactional(script) {
+		if _, err := conn.ExecContext(ctx, script); err != nil {
+			return fmt.Errorf("%s: %w", message, err)
+		}
+		if err := record(conn); err != nil {
+			return fmt.Errorf("%s: %w", message, err)
+		}
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", message, err)
+	}
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", message, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultFallbackCooldown 主限流器出错后直接使用回退限流器的时间
+const DefaultFallbackCooldown = 10 * time.Second
+
+// FallbackLimiter 带回退的限流器
+// 主限流器（通常是 RedisLimiter）出错时改用回退限流器（通常是 MemoryLimiter），
+// 并在冷却时间内跳过主限流器，避免每个请求都等待 Redis 超时。
+// 限流不会因为 Redis 故障而失效，只是退化为按实例计数。
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+	cooldown time.Duration
+	onError  func(err error)
+
+	mu          sync.Mutex
+	bypassUntil time.Time
+}
+
+// NewFallbackLimiter 创建带回退的限流器
+//
+// 参数:
+//   - primary: 主限流器
+//   - fallback: 回退限流器
+//   - cooldown: 主限流器出错后跳过它的时间，为 0 时使用 DefaultFallbackCooldown
+//   - onError: 主限流器出错时的回调，用于记录日志，可以为 nil
+func NewFallbackLimiter(primary, fallback Limiter, cooldown time.Duration, onError func(err error)) *FallbackLimiter {
+	if cooldown <= 0 {
+		cooldown = DefaultFallbackCooldown
+	}
+	return &FallbackLimiter{
+		primary:  primary,
+		fallback: fallback,
+		cooldown: cooldown,
+		onError:  onError,
+	}
+}
+
+// Allow 消耗 key 的一次请求额度
+func (l *FallbackLimiter) Allow(ctx context.Context, key string, limit Limit) (*Result, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	if !l.bypassed() {
+		result, err := l.primary.Allow(ctx, key, limit)
+		if err == nil {
+			return result, nil
+		}
+
+		l.mu.Lock()
+		l.bypassUntil = time.Now().Add(l.cooldown)
+		l.mu.Unlock()
+		if l.onError != nil {
+			l.onError(err)
+		}
+	}
+
+	return l.fallback.Allow(ctx, key, limit)
+}
+
+// bypassed 判断当前是否处于跳过主限流器的冷却期
+func (l *FallbackLimiter) bypassed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Now().Before(l.bypassUntil)
+}
@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval 清理过期限流状态的间隔
+const memorySweepInterval = time.Minute
+
+// memoryEntry 单个限流键的状态
+type memoryEntry struct {
+	hits    []time.Time // 滑动窗口内的请求时间，按时间升序
+	tokens  float64     // 令牌桶剩余令牌
+	updated time.Time   // 令牌桶最后更新时间
+	expires time.Time   // 状态过期时间，过期后可以清理
+}
+
+// MemoryLimiter 进程内限流器
+// 与 RedisLimiter 使用相同的算法，但计数只在当前进程内有效，
+// 多实例部署时每个实例各自计数。主要用于 Redis 不可用时的回退。
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryLimiter 创建进程内限流器
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		entries: make(map[string]*memoryEntry),
+		now:     time.Now,
+	}
+}
+
+// Allow 消耗 key 的一次请求额度
+func (l *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) (*Result, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	entry, ok := l.entries[key]
+	if !ok || now.After(entry.expires) {
+		entry = &memoryEntry{tokens: float64(limit.capacity()), updated: now}
+		l.entries[key] = entry
+	}
+
+	if limit.algorithm() == TokenBucket {
+		return entry.takeToken(now, limit), nil
+	}
+	return entry.hitWindow(now, limit), nil
+}
+
+// hitWindow 按滑动窗口算法记录一次请求
+func (e *memoryEntry) hitWindow(now time.Time, limit Limit) *Result {
+	cutoff := now.Add(-limit.Period)
+	i := 0
+	for i < len(e.hits) && !e.hits[i].After(cutoff) {
+		i++
+	}
+	e.hits = e.hits[i:]
+
+	result := &Result{Limit: limit.Rate}
+	if len(e.hits) < limit.Rate {
+		e.hits = append(e.hits, now)
+		result.Allowed = true
+	}
+	result.Remaining = limit.Rate - len(e.hits)
+	result.ResetAfter = e.hits[0].Add(limit.Period).Sub(now)
+	if !result.Allowed {
+		result.RetryAfter = result.ResetAfter
+	}
+	e.expires = now.Add(limit.Period)
+	return result
+}
+
+// takeToken 按令牌桶算法消耗一个令牌
+func (e *memoryEntry) takeToken(now time.Time, limit Limit) *Result {
+	capacity := float64(limit.capacity())
+	rate := float64(limit.Rate) / float64(limit.Period)
+
+	e.tokens = math.Min(capacity, e.tokens+float64(now.Sub(e.updated))*rate)
+	e.updated = now
+
+	result := &Result{Limit: limit.capacity()}
+	if e.tokens >= 1 {
+		e.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - e.tokens) / rate))
+	}
+	result.Remaining = int(e.tokens)
+	result.ResetAfter = time.Duration(math.Ceil((capacity - e.tokens) / rate))
+	e.expires = now.Add(result.ResetAfter)
+	return result
+}
+
+// sweep 定期清理已过期的限流状态，避免键数量无限增长
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < memorySweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, entry := range l.entries {
+		if now.After(entry.expires) {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Package ratelimit 提供请求速率限制功能
+// 包含基于 Redis Lua 脚本的原子限流器和进程内限流器，
+// 支持滑动窗口和令牌桶两种算法，Redis 不可用时可回退到进程内限流
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Algorithm 限流算法
+type Algorithm string
+
+// 支持的限流算法
+const (
+	SlidingWindow Algorithm = "sliding_window" // 滑动窗口，窗口内最多 Rate 次请求
+	TokenBucket   Algorithm = "token_bucket"   // 令牌桶，每 Period 补充 Rate 个令牌，最多积累 Burst 个
+)
+
+// ErrInvalidLimit 无效的限流规则
+var ErrInvalidLimit = errors.New("无效的限流规则")
+
+// Limit 限流规则
+type Limit struct {
+	Algorithm Algorithm     // 限流算法，为空时使用滑动窗口
+	Rate      int           // 每个周期允许的请求数
+	Period    time.Duration // 周期
+	Burst     int           // 令牌桶容量，为 0 时等于 Rate，滑动窗口忽略此字段
+}
+
+// Validate 校验限流规则
+func (l Limit) Validate() error {
+	if l.Rate <= 0 || l.Period <= 0 {
+		return fmt.Errorf("%w: rate 和 period 必须大于 0", ErrInvalidLimit)
+	}
+	switch l.Algorithm {
+	case "", SlidingWindow, TokenBucket:
+	default:
+		return fmt.Errorf("%w: 不支持的算法 %s", ErrInvalidLimit, l.Algorithm)
+	}
+	if l.Burst < 0 {
+		return fmt.Errorf("%w: burst 不能为负数", ErrInvalidLimit)
+	}
+	return nil
+}
+
+// capacity 返回允许的最大突发请求数，用于 X-RateLimit-Limit
+func (l Limit) capacity() int {
+	if l.algorithm() == TokenBucket && l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// algorithm 返回实际使用的算法
+func (l Limit) algorithm() Algorithm {
+	if l.Algorithm == "" {
+		return SlidingWindow
+	}
+	return l.Algorithm
+}
+
+// Result 一次限流判断的结果
+type Result struct {
+	Allowed    bool          // 是否允许本次请求
+	Limit      int           // 允许的最大请求数
+	Remaining  int           // 剩余可用请求数
+	ResetAfter time.Duration // 距离额度完全恢复的时间
+	RetryAfter time.Duration // 被拒绝时距离下一次可用的时间，允许时为 0
+}
+
+// Limiter 限流器接口
+type Limiter interface {
+	// Allow 消耗 key 的一次请求额度并返回判断结果
+	Allow(ctx context.Context, key string, limit Limit) (*Result, error)
+}
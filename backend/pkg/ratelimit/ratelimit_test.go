@@ -0,0 +1,257 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisAddrEnv 测试使用的 Redis 地址，未设置时跳过 Lua 脚本的测试
+// 测试只写入 ratelimit-test: 开头的键，例如：
+//
+//	NICHEFLOW_TEST_REDIS_ADDR=localhost:6379 go test ./pkg/ratelimit/
+const redisAddrEnv = "NICHEFLOW_TEST_REDIS_ADDR"
+
+// limitStep 一次限流判断
+type limitStep struct {
+	advance       time.Duration // 判断前经过的时间
+	key           string        // 限流键，为空时使用 "user"
+	wantAllowed   bool
+	wantRemaining int
+}
+
+// limitCases 进程内限流器和 Redis 限流器共用的用例
+var limitCases = []struct {
+	name  string
+	limit Limit
+	steps []limitStep
+}{
+	{
+		name:  "滑动窗口超出限制",
+		limit: Limit{Rate: 2, Period: time.Second},
+		steps: []limitStep{
+			{wantAllowed: true, wantRemaining: 1},
+			{wantAllowed: true, wantRemaining: 0},
+			{wantAllowed: false, wantRemaining: 0},
+			{advance: time.Second + 50*time.Millisecond, wantAllowed: true, wantRemaining: 1},
+		},
+	},
+	{
+		name:  "滑动窗口只释放移出窗口的请求",
+		limit: Limit{Algorithm: SlidingWindow, Rate: 2, Period: time.Second},
+		steps: []limitStep{
+			{wantAllowed: true, wantRemaining: 1},
+			{advance: 600 * time.Millisecond, wantAllowed: true, wantRemaining: 0},
+			{advance: 500 * time.Millisecond, wantAllowed: true, wantRemaining: 0},
+			{wantAllowed: false, wantRemaining: 0},
+		},
+	},
+	{
+		name:  "不同的键分别计数",
+		limit: Limit{Rate: 1, Period: time.Second},
+		steps: []limitStep{
+			{key: "a", wantAllowed: true, wantRemaining: 0},
+			{key: "a", wantAllowed: false, wantRemaining: 0},
+			{key: "b", wantAllowed: true, wantRemaining: 0},
+		},
+	},
+	{
+		name:  "令牌桶允许突发后按速率补充",
+		limit: Limit{Algorithm: TokenBucket, Rate: 1, Period: time.Second, Burst: 3},
+		steps: []limitStep{
+			{wantAllowed: true, wantRemaining: 2},
+			{wantAllowed: true, wantRemaining: 1},
+			{wantAllowed: true, wantRemaining: 0},
+			{wantAllowed: false, wantRemaining: 0},
+			{advance: time.Second + 50*time.Millisecond, wantAllowed: true, wantRemaining: 0},
+			{wantAllowed: false, wantRemaining: 0},
+		},
+	},
+}
+
+// TestMemoryLimiter 使用可控的时钟校验进程内限流器
+func TestMemoryLimiter(t *testing.T) {
+	for _, tc := range limitCases {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Now()
+			limiter := NewMemoryLimiter()
+			limiter.now = func() time.Time { return now }
+
+			runLimitSteps(t, limiter, "", tc.limit, tc.steps, func(d time.Duration) { now = now.Add(d) })
+		})
+	}
+}
+
+// TestRedisLimiter 在真实的 Redis 上执行 Lua 脚本，时间取自 Redis 服务器
+func TestRedisLimiter(t *testing.T) {
+	addr := os.Getenv(redisAddrEnv)
+	if addr == "" {
+		t.Skipf("未设置 %s，跳过 Redis 限流脚本测试", redisAddrEnv)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	defer rdb.Close()
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("连接 Redis 失败: %v", err)
+	}
+
+	limiter := NewRedisLimiter(rdb, "ratelimit-test:")
+	for _, tc := range limitCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// 每个用例使用独立的键前缀，重复执行时不受上次残留的计数影响
+			prefix := tc.name + ":" + time.Now().Format(time.RFC3339Nano) + ":"
+			runLimitSteps(t, limiter, prefix, tc.limit, tc.steps, time.Sleep)
+		})
+	}
+}
+
+// TestFallbackLimiter 校验主限流器出错时回退到进程内限流，并在冷却期内跳过主限流器
+func TestFallbackLimiter(t *testing.T) {
+	limit := Limit{Rate: 2, Period: time.Minute}
+	ctx := context.Background()
+
+	cases := []struct {
+		name             string
+		primaryErr       error
+		cooldown         time.Duration
+		wait             time.Duration // 第二轮请求前等待的时间
+		wantPrimaryCalls int
+		wantErrors       int
+	}{
+		{
+			name:             "主限流器正常时不使用回退",
+			wantPrimaryCalls: 6,
+		},
+		{
+			name:             "主限流器出错时回退并在冷却期内跳过",
+			primaryErr:       errors.New("redis: connection refused"),
+			cooldown:         time.Minute,
+			wantPrimaryCalls: 1,
+			wantErrors:       1,
+		},
+		{
+			name:             "冷却期结束后重试主限流器",
+			primaryErr:       errors.New("redis: connection refused"),
+			cooldown:         20 * time.Millisecond,
+			wait:             40 * time.Millisecond,
+			wantPrimaryCalls: 2,
+			wantErrors:       2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			primary := &stubLimiter{err: tc.primaryErr, fallback: NewMemoryLimiter()}
+			errs := 0
+			limiter := NewFallbackLimiter(primary, NewMemoryLimiter(), tc.cooldown, func(error) { errs++ })
+
+			// 前两次请求允许，第三次超出限制，回退后同样生效
+			for i, wantAllowed := range []bool{true, true, false} {
+				result, err := limiter.Allow(ctx, "user", limit)
+				if err != nil {
+					t.Fatalf("第 %d 次请求返回 %v", i+1, err)
+				}
+				if result.Allowed != wantAllowed {
+					t.Fatalf("第 %d 次请求 Allowed 为 %v，期望 %v", i+1, result.Allowed, wantAllowed)
+				}
+			}
+
+			time.Sleep(tc.wait)
+			for i := 0; i < 3; i++ {
+				result, err := limiter.Allow(ctx, "user", limit)
+				if err != nil {
+					t.Fatalf("第二轮第 %d 次请求返回 %v", i+1, err)
+				}
+				if result.Allowed {
+					t.Fatalf("第二轮第 %d 次请求应超出限制", i+1)
+				}
+			}
+
+			if primary.calls != tc.wantPrimaryCalls {
+				t.Fatalf("主限流器被调用了 %d 次，期望 %d 次", primary.calls, tc.wantPrimaryCalls)
+			}
+			if errs != tc.wantErrors {
+				t.Fatalf("错误回调被调用了 %d 次，期望 %d 次", errs, tc.wantErrors)
+			}
+		})
+	}
+
+	t.Run("Redis 不可用时回退", func(t *testing.T) {
+		rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+		defer rdb.Close()
+		errs := 0
+		limiter := NewFallbackLimiter(NewRedisLimiter(rdb, "ratelimit-test:"), NewMemoryLimiter(), time.Minute, func(error) { errs++ })
+
+		for i, wantAllowed := range []bool{true, true, false} {
+			result, err := limiter.Allow(ctx, "user", limit)
+			if err != nil {
+				t.Fatalf("第 %d 次请求返回 %v", i+1, err)
+			}
+			if result.Allowed != wantAllowed {
+				t.Fatalf("第 %d 次请求 Allowed 为 %v，期望 %v", i+1, result.Allowed, wantAllowed)
+			}
+		}
+		if errs != 1 {
+			t.Fatalf("错误回调被调用了 %d 次，期望 1 次", errs)
+		}
+	})
+
+	t.Run("无效的限流规则", func(t *testing.T) {
+		primary := &stubLimiter{fallback: NewMemoryLimiter()}
+		limiter := NewFallbackLimiter(primary, NewMemoryLimiter(), 0, nil)
+		if _, err := limiter.Allow(ctx, "user", Limit{Rate: 0, Period: time.Minute}); !errors.Is(err, ErrInvalidLimit) {
+			t.Fatalf("Allow 返回 %v，期望 ErrInvalidLimit", err)
+		}
+		if primary.calls != 0 {
+			t.Fatalf("规则无效时仍调用了主限流器")
+		}
+	})
+}
+
+// stubLimiter 可以模拟故障的主限流器，正常时使用进程内限流器计数
+type stubLimiter struct {
+	err      error
+	fallback *MemoryLimiter
+	calls    int
+}
+
+// Allow 记录调用次数，设置了 err 时返回错误
+func (l *stubLimiter) Allow(ctx context.Context, key string, limit Limit) (*Result, error) {
+	l.calls++
+	if l.err != nil {
+		return nil, l.err
+	}
+	return l.fallback.Allow(ctx, key, limit)
+}
+
+// runLimitSteps 依次执行限流判断并校验结果
+func runLimitSteps(t *testing.T, limiter Limiter, prefix string, limit Limit, steps []limitStep, advance func(time.Duration)) {
+	t.Helper()
+	for i, step := range steps {
+		if step.advance > 0 {
+			advance(step.advance)
+		}
+		key := step.key
+		if key == "" {
+			key = "user"
+		}
+
+		result, err := limiter.Allow(context.Background(), prefix+key, limit)
+		if err != nil {
+			t.Fatalf("第 %d 次请求返回 %v", i+1, err)
+		}
+		if result.Allowed != step.wantAllowed || result.Remaining != step.wantRemaining {
+			t.Fatalf("第 %d 次请求返回 Allowed=%v Remaining=%d，期望 Allowed=%v Remaining=%d",
+				i+1, result.Allowed, result.Remaining, step.wantAllowed, step.wantRemaining)
+		}
+		if result.Limit != limit.capacity() {
+			t.Fatalf("第 %d 次请求返回 Limit=%d，期望 %d", i+1, result.Limit, limit.capacity())
+		}
+		if !result.Allowed && result.RetryAfter <= 0 {
+			t.Fatalf("第 %d 次请求被拒绝但没有返回重试时间", i+1)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// slidingWindowScript 滑动窗口日志算法
+// 使用有序集合记录窗口内每次请求的时间，清理、计数和写入在同一脚本中原子完成。
+// 时间取自 Redis 服务器，避免多个实例之间的时钟偏差。
+//
+// KEYS[1]: 限流键
+// ARGV[1]: 窗口内允许的请求数
+// ARGV[2]: 窗口长度（毫秒）
+// ARGV[3]: 本次请求的唯一标识
+// 返回: {是否允许, 剩余次数, 重置时间（毫秒）, 重试时间（毫秒）}
+var slidingWindowScript = redis.NewScript(`
+redis.replicate_commands()
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+
+local allowed = 0
+if count < limit then
+  redis.call('ZADD', key, now, now .. '-' .. ARGV[3])
+  count = count + 1
+  allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+  reset = tonumber(oldest[2]) + window - now
+end
+
+local retry = 0
+if allowed == 0 then
+  retry = reset
+end
+return {allowed, limit - count, reset, retry}
+`)
+
+// tokenBucketScript 令牌桶算法
+// 桶状态保存在哈希中，按经过的时间补充令牌后再尝试消耗一个。
+//
+// KEYS[1]: 限流键
+// ARGV[1]: 桶容量
+// ARGV[2]: 每个周期补充的令牌数
+// ARGV[3]: 周期长度（毫秒）
+// 返回: {是否允许, 剩余令牌, 重置时间（毫秒）, 重试时间（毫秒）}
+var tokenBucketScript = redis.NewScript(`
+redis.replicate_commands()
+local key = KEYS[1]
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2]) / tonumber(ARGV[3])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = capacity
+  ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+local reset = math.ceil((capacity - tokens) / rate)
+redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', key, math.max(reset, 1))
+
+local retry = 0
+if allowed == 0 then
+  retry = math.ceil((1 - tokens) / rate)
+end
+return {allowed, math.floor(tokens), reset, retry}
+`)
+
+// RedisLimiter 基于 Redis Lua 脚本的分布式限流器
+// 每次判断只执行一个脚本，多个实例共享同一份计数
+type RedisLimiter struct {
+	rdb    *redis.Client
+	prefix string
+}
+
+// NewRedisLimiter 创建 Redis 限流器
+//
+// 参数:
+//   - rdb: Redis 客户端
+//   - prefix: 限流键前缀，例如 "rate_limit:"
+func NewRedisLimiter(rdb *redis.Client, prefix string) *RedisLimiter {
+	return &RedisLimiter{rdb: rdb, prefix: prefix}
+}
+
+// Allow 消耗 key 的一次请求额度
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (*Result, error) {
+	if err := limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	period := limit.Period.Milliseconds()
+	if period <= 0 {
+		period = 1
+	}
+
+	var (
+		values []interface{}
+		err    error
+	)
+	switch limit.algorithm() {
+	case TokenBucket:
+		values, err = tokenBucketScript.Run(ctx, l.rdb, []string{l.prefix + key},
+			limit.capacity(), limit.Rate, period).Slice()
+	default:
+		var member string
+		if member, err = requestID(); err != nil {
+			return nil, err
+		}
+		values, err = slidingWindowScript.Run(ctx, l.rdb, []string{l.prefix + key},
+			limit.Rate, period, member).Slice()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("执行限流脚本失败: %w", err)
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("限流脚本返回值无效: %v", values)
+	}
+
+	nums := make([]int64, len(values))
+	for i, v := range values {
+		n, ok := v.(int64)
+		if !ok {
+			return nil, fmt.Errorf("限流脚本返回值无效: %v", values)
+		}
+		nums[i] = n
+	}
+
+	return &Result{
+		Allowed:    nums[0] == 1,
+		Limit:      limit.capacity(),
+		Remaining:  int(max(nums[1], 0)),
+		ResetAfter: time.Duration(nums[2]) * time.Millisecond,
+		RetryAfter: time.Duration(nums[3]) * time.Millisecond,
+	}, nil
+}
+
+// requestID 生成滑动窗口中单次请求的唯一标识，避免同一毫秒内的请求互相覆盖
+func requestID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
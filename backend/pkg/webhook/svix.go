@@ -0,0 +1,142 @@
+// Package webhook 提供第三方 Webhook 的签名校验功能
+// 目前支持 Svix 风格的签名（Clerk 使用 Svix 投递 Webhook）
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Svix 请求头名称
+const (
+	HeaderSvixID        = "svix-id"        // 消息唯一标识，同一事件重投时保持不变
+	HeaderSvixTimestamp = "svix-timestamp" // 消息发送时间（Unix 秒）
+	HeaderSvixSignature = "svix-signature" // 消息签名列表
+)
+
+// DefaultTolerance 默认允许的时间戳偏差
+const DefaultTolerance = 5 * time.Minute
+
+// 签名校验错误
+var (
+	ErrMissingHeaders   = errors.New("缺少 Webhook 签名头")
+	ErrInvalidTimestamp = errors.New("无效的 Webhook 时间戳")
+	ErrTimestampTooOld  = errors.New("Webhook 时间戳已过期")
+	ErrTimestampTooNew  = errors.New("Webhook 时间戳超前")
+	ErrInvalidSignature = errors.New("Webhook 签名不匹配")
+	ErrInvalidSecret    = errors.New("无效的 Webhook 密钥")
+)
+
+const (
+	secretPrefix         = "whsec_" // 密钥前缀
+	signatureVersionTag  = "v1"     // 当前支持的签名版本
+	signatureVersionSeps = ","      // 版本与签名之间的分隔符
+)
+
+// SvixVerifier Svix 签名校验器
+type SvixVerifier struct {
+	secret    []byte           // 解码后的签名密钥
+	tolerance time.Duration    // 允许的时间戳偏差
+	now       func() time.Time // 当前时间，便于测试替换
+}
+
+// NewSvixVerifier 创建 Svix 签名校验器
+//
+// 参数:
+//   - secret: Webhook 签名密钥，格式为 whsec_<base64>
+//   - tolerance: 允许的时间戳偏差，小于等于 0 时使用 DefaultTolerance
+//
+// 返回:
+//   - *SvixVerifier: 签名校验器实例
+//   - error: 密钥无效时返回 ErrInvalidSecret
+func NewSvixVerifier(secret string, tolerance time.Duration) (*SvixVerifier, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, secretPrefix))
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+
+	return &SvixVerifier{
+		secret:    key,
+		tolerance: tolerance,
+		now:       time.Now,
+	}, nil
+}
+
+// Verify 校验 Webhook 请求
+//
+// 参数:
+//   - header: 请求头，需包含 svix-id、svix-timestamp 和 svix-signature
+//   - payload: 原始请求体
+//
+// 返回:
+//   - error: 校验失败的原因，校验通过则为 nil
+//
+// 说明:
+//
+//	时间戳超出容忍范围的请求会被拒绝，以防止旧消息被重放；
+//	同一消息的重复投递需由调用方根据 svix-id 做幂等处理。
+func (v *SvixVerifier) Verify(header http.Header, payload []byte) error {
+	msgID := header.Get(HeaderSvixID)
+	msgTimestamp := header.Get(HeaderSvixTimestamp)
+	msgSignature := header.Get(HeaderSvixSignature)
+	if msgID == "" || msgTimestamp == "" || msgSignature == "" {
+		return ErrMissingHeaders
+	}
+
+	ts, err := strconv.ParseInt(msgTimestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	sentAt := time.Unix(ts, 0)
+	now := v.now()
+	if now.Sub(sentAt) > v.tolerance {
+		return ErrTimestampTooOld
+	}
+	if sentAt.Sub(now) > v.tolerance {
+		return ErrTimestampTooNew
+	}
+
+	expected := v.sign(msgID, msgTimestamp, payload)
+	for _, versioned := range strings.Split(msgSignature, " ") {
+		version, signature, found := strings.Cut(versioned, signatureVersionSeps)
+		if !found || version != signatureVersionTag {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(signature)
+		if err != nil {
+			continue
+		}
+		if hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// Sign 生成 v1 版本的签名头，主要用于测试和本地调试
+func (v *SvixVerifier) Sign(msgID string, timestamp time.Time, payload []byte) string {
+	sig := v.sign(msgID, strconv.FormatInt(timestamp.Unix(), 10), payload)
+	return fmt.Sprintf("%s%s%s", signatureVersionTag, signatureVersionSeps, base64.StdEncoding.EncodeToString(sig))
+}
+
+// sign 计算 HMAC-SHA256 签名，签名内容为 "{id}.{timestamp}.{payload}"
+func (v *SvixVerifier) sign(msgID, timestamp string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(msgID))
+	mac.Write([]byte("."))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
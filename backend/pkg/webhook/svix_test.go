@@ -0,0 +1,197 @@
+package webhook_test
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/yszaryszar/NicheFlow/backend/pkg/webhook"
+)
+
+// 测试使用的签名密钥，轮换期间 Svix 会同时使用新旧密钥签名
+const (
+	testSecret      = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+	testRotatedFrom = "whsec_c2VjcmV0LWJlZm9yZS1yb3RhdGlvbg=="
+	testOtherSecret = "whsec_b3RoZXItZW5kcG9pbnQtc2VjcmV0"
+)
+
+// TestSvixVerifier 校验签名头、时间戳容忍范围和多签名
+func TestSvixVerifier(t *testing.T) {
+	payload := []byte(`{"type":"user.created","data":{"id":"user_1"}}`)
+	now := time.Now()
+
+	current := newVerifier(t, testSecret)
+	previous := newVerifier(t, testRotatedFrom)
+	other := newVerifier(t, testOtherSecret)
+
+	cases := []struct {
+		name      string
+		id        string
+		timestamp string
+		signature string
+		payload   []byte
+		want      error
+	}{
+		{
+			name:      "签名正确",
+			id:        "msg_1",
+			timestamp: unix(now),
+			signature: current.Sign("msg_1", now, payload),
+			want:      nil,
+		},
+		{
+			name:      "容忍范围内的旧时间戳",
+			id:        "msg_1",
+			timestamp: unix(now.Add(-4 * time.Minute)),
+			signature: current.Sign("msg_1", now.Add(-4*time.Minute), payload),
+			want:      nil,
+		},
+		{
+			name:      "容忍范围内的超前时间戳",
+			id:        "msg_1",
+			timestamp: unix(now.Add(4 * time.Minute)),
+			signature: current.Sign("msg_1", now.Add(4*time.Minute), payload),
+			want:      nil,
+		},
+		{
+			name:      "时间戳已过期",
+			id:        "msg_1",
+			timestamp: unix(now.Add(-10 * time.Minute)),
+			signature: current.Sign("msg_1", now.Add(-10*time.Minute), payload),
+			want:      webhook.ErrTimestampTooOld,
+		},
+		{
+			name:      "时间戳超前",
+			id:        "msg_1",
+			timestamp: unix(now.Add(10 * time.Minute)),
+			signature: current.Sign("msg_1", now.Add(10*time.Minute), payload),
+			want:      webhook.ErrTimestampTooNew,
+		},
+		{
+			name:      "时间戳不是整数",
+			id:        "msg_1",
+			timestamp: "yesterday",
+			signature: current.Sign("msg_1", now, payload),
+			want:      webhook.ErrInvalidTimestamp,
+		},
+		{
+			name:      "缺少签名头",
+			id:        "msg_1",
+			timestamp: unix(now),
+			want:      webhook.ErrMissingHeaders,
+		},
+		{
+			name:      "其他密钥的签名",
+			id:        "msg_1",
+			timestamp: unix(now),
+			signature: other.Sign("msg_1", now, payload),
+			want:      webhook.ErrInvalidSignature,
+		},
+		{
+			name:      "请求体被篡改",
+			id:        "msg_1",
+			timestamp: unix(now),
+			signature: current.Sign("msg_1", now, payload),
+			payload:   []byte(`{"type":"user.deleted","data":{"id":"user_1"}}`),
+			want:      webhook.ErrInvalidSignature,
+		},
+		{
+			name:      "消息 ID 被替换",
+			id:        "msg_2",
+			timestamp: unix(now),
+			signature: current.Sign("msg_1", now, payload),
+			want:      webhook.ErrInvalidSignature,
+		},
+		{
+			name:      "多个签名中有一个匹配",
+			id:        "msg_1",
+			timestamp: unix(now),
+			signature: other.Sign("msg_1", now, payload) + " " + current.Sign("msg_1", now, payload),
+			want:      nil,
+		},
+		{
+			name:      "密钥轮换期间新旧签名同时存在",
+			id:        "msg_1",
+			timestamp: unix(now),
+			signature: previous.Sign("msg_1", now, payload) + " " + current.Sign("msg_1", now, payload),
+			want:      nil,
+		},
+		{
+			name:      "只有轮换前的签名",
+			id:        "msg_1",
+			timestamp: unix(now),
+			signature: previous.Sign("msg_1", now, payload),
+			want:      webhook.ErrInvalidSignature,
+		},
+		{
+			name:      "忽略不支持的签名版本和无效编码",
+			id:        "msg_1",
+			timestamp: unix(now),
+			signature: "v2,abc v1,%%% " + current.Sign("msg_1", now, payload),
+			want:      nil,
+		},
+		{
+			name:      "只有不支持的签名版本",
+			id:        "msg_1",
+			timestamp: unix(now),
+			signature: "v1a," + current.Sign("msg_1", now, payload)[len("v1,"):],
+			want:      webhook.ErrInvalidSignature,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := tc.payload
+			if body == nil {
+				body = payload
+			}
+			header := http.Header{}
+			header.Set(webhook.HeaderSvixID, tc.id)
+			header.Set(webhook.HeaderSvixTimestamp, tc.timestamp)
+			header.Set(webhook.HeaderSvixSignature, tc.signature)
+
+			if err := current.Verify(header, body); !errors.Is(err, tc.want) {
+				t.Fatalf("Verify 返回 %v，期望 %v", err, tc.want)
+			}
+		})
+	}
+}
+
+// TestNewSvixVerifier 校验密钥格式
+func TestNewSvixVerifier(t *testing.T) {
+	cases := []struct {
+		name   string
+		secret string
+		want   error
+	}{
+		{"带前缀的密钥", testSecret, nil},
+		{"不带前缀的密钥", testSecret[len("whsec_"):], nil},
+		{"空密钥", "whsec_", webhook.ErrInvalidSecret},
+		{"不是 base64", "whsec_not base64!", webhook.ErrInvalidSecret},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := webhook.NewSvixVerifier(tc.secret, 0); !errors.Is(err, tc.want) {
+				t.Fatalf("NewSvixVerifier 返回 %v，期望 %v", err, tc.want)
+			}
+		})
+	}
+}
+
+// newVerifier 创建使用默认容忍范围的校验器
+func newVerifier(t *testing.T, secret string) *webhook.SvixVerifier {
+	t.Helper()
+	v, err := webhook.NewSvixVerifier(secret, 0)
+	if err != nil {
+		t.Fatalf("创建校验器失败: %v", err)
+	}
+	return v
+}
+
+// unix 返回 svix-timestamp 格式的时间戳
+func unix(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
@@ -24,7 +24,7 @@ locals {
     "/nicheflow/development/hk/app/mode"     = "debug"
     "/nicheflow/development/hk/app/port"     = "8080"
     "/nicheflow/development/hk/app/base_url" = "http://localhost:8080"
-    "/nicheflow/development/hk/app/region"   = "ap-east-1"
+    "/nicheflow/development/hk/app/region"   = "hk"
 
     # 数据库配置
     "/nicheflow/development/hk/database/driver"           = "postgres"
@@ -55,7 +55,7 @@ locals {
     "/nicheflow/development/us/app/mode"     = "debug"
     "/nicheflow/development/us/app/port"     = "8080"
     "/nicheflow/development/us/app/base_url" = "http://localhost:8080"
-    "/nicheflow/development/us/app/region"   = "us-east-1"
+    "/nicheflow/development/us/app/region"   = "us"
 
     # 数据库配置
     "/nicheflow/development/us/database/driver"           = "postgres"
@@ -89,7 +89,7 @@ locals {
     "/nicheflow/production/hk/app/mode"     = "release"
     "/nicheflow/production/hk/app/port"     = "80"
     "/nicheflow/production/hk/app/base_url" = "https://api.getnicheflow.com"
-    "/nicheflow/production/hk/app/region"   = "ap-east-1"
+    "/nicheflow/production/hk/app/region"   = "hk"
 
     # 数据库配置
     "/nicheflow/production/hk/database/driver"           = "postgres"
@@ -117,7 +117,7 @@ locals {
     "/nicheflow/production/us/app/mode"     = "release"
     "/nicheflow/production/us/app/port"     = "80"
     "/nicheflow/production/us/app/base_url" = "https://api.getnicheflow.com"
-    "/nicheflow/production/us/app/region"   = "us-east-1"
+    "/nicheflow/production/us/app/region"   = "us"
 
     # 数据库配置
     "/nicheflow/production/us/database/driver"           = "postgres"